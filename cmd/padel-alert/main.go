@@ -8,11 +8,14 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Embed timezone data for club-local filtering in minimal images
 
 	"github.com/rafa-garcia/padel-alert/internal/api"
 	"github.com/rafa-garcia/padel-alert/internal/config"
 	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/notification"
 	"github.com/rafa-garcia/padel-alert/internal/scheduler"
+	"github.com/rafa-garcia/padel-alert/internal/source"
 	"github.com/rafa-garcia/padel-alert/internal/storage"
 )
 
@@ -41,19 +44,80 @@ func main() {
 		}
 	}()
 
-	// Create rule and user storage
+	// Create rule, user, token, notification and seen storage
 	ruleStorage := storage.NewRedisRuleStorage(redisClient)
 	userStorage := storage.NewRedisUserStorage(redisClient)
+	tokenStorage := storage.NewRedisTokenStorage(redisClient)
+	notificationStorage := storage.NewRedisNotificationStorage(redisClient, cfg.NotificationHistorySize)
+	seenStorage := storage.NewRedisSeenStorage(redisClient)
+
+	// Convert seen sets written by earlier versions
+	migrated, err := storage.MigrateLegacySeenSets(context.Background(), redisClient)
+	if err != nil {
+		logger.Fatal("Failed to migrate seen activities", err)
+	}
+	if migrated > 0 {
+		logger.Info("Migrated legacy seen sets", "rules", migrated)
+	}
+
+	if err := storage.SyncRuleMetrics(context.Background(), redisClient); err != nil {
+		logger.Error("Failed to count rules for metrics", err)
+	}
+
+	// Create the Playtomic activity source, or the fixture-backed fake when configured
+	activitySource, err := source.New(cfg)
+	if err != nil {
+		logger.Fatal("Failed to create activity source", err)
+	}
 
 	// Initialize scheduler
-	sched := scheduler.NewScheduler(cfg, ruleStorage)
+	sched := scheduler.NewScheduler(cfg, ruleStorage, activitySource)
 	if err := sched.Start(); err != nil {
 		logger.Fatal("Failed to start scheduler", err)
 	}
 	defer sched.Stop()
 
+	// Readiness checks for the components the service depends on
+	healthChecks := []api.ComponentCheck{
+		api.RedisCheck(redisClient),
+		{Name: "scheduler", Critical: true, Check: sched.CheckHealth},
+		{Name: "schedule", Check: sched.CheckBacklog},
+		api.NotifierCheck(notification.NewDefaultRegistry(cfg)),
+	}
+
+	switch cfg.AuthMode {
+	case api.AuthModeToken:
+	case api.AuthModeLegacy:
+		logger.Warn("Legacy auth mode: user routes trust the user_id query parameter")
+	default:
+		logger.Fatal("Invalid auth mode", fmt.Errorf("AUTH_MODE must be %q or %q, got %q", api.AuthModeToken, api.AuthModeLegacy, cfg.AuthMode))
+	}
+
+	// Admin routes are left out rather than opened to the user keys
+	if len(cfg.AdminAPIKeys) == 0 {
+		logger.Warn("ADMIN_API_KEYS not set, admin routes and /metrics are disabled")
+	}
+
+	trustedProxies, err := api.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		logger.Fatal("Invalid trusted proxies", err)
+	}
+
+	// Rate limits are counted in Redis, so they hold across replicas
+	rateLimits := api.RateLimitConfig{
+		Storage:        storage.NewRedisRateLimitStorage(redisClient),
+		TrustedProxies: trustedProxies,
+		Public:         cfg.APIRateLimit,
+		User:           cfg.UserRateLimit,
+		Admin:          cfg.AdminRateLimit,
+		Auth:           cfg.AuthRateLimit,
+	}
+
 	// Create router with API keys from config
-	r := api.NewRouter(version, cfg.APIKeys, ruleStorage, userStorage)
+	r := api.NewRouter(version, healthChecks, cfg.APIKeys, cfg.AdminAPIKeys, cfg.AuthMode, rateLimits, ruleStorage, userStorage, tokenStorage, notificationStorage, seenStorage, sched, activitySource, source.Pagination{
+		PageSize: cfg.PlaytomicPageSize,
+		MaxPages: cfg.PlaytomicMaxPages,
+	})
 
 	// Create server with timeouts
 	server := &http.Server{
@@ -0,0 +1,348 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
+)
+
+const (
+	defaultAdminPageSize = 50
+	maxAdminPageSize     = 500
+)
+
+// SchedulerControl lets admins operate the scheduler. The scheduler
+// implements it; the API only depends on this interface.
+type SchedulerControl interface {
+	RunRule(ctx context.Context, ruleID string) (*model.Rule, error)
+	Pause(ctx context.Context) error
+	Resume(ctx context.Context) error
+	PausedAt(ctx context.Context) (*time.Time, error)
+}
+
+// AdminRuleListResponse represents a page of the rules of every user
+type AdminRuleListResponse struct {
+	Rules []AdminRuleResponse `json:"rules"`
+	Total int                 `json:"total"`
+	Page  int                 `json:"page"`
+	Size  int                 `json:"size"`
+}
+
+// AdminRuleResponse represents a rule along with the number of activities it
+// remembers as seen
+type AdminRuleResponse struct {
+	*model.Rule
+	SeenActivities int64 `json:"seen_activities"`
+}
+
+// SchedulerStatusResponse represents whether the scheduler is paused
+type SchedulerStatusResponse struct {
+	Paused   bool       `json:"paused"`
+	PausedAt *time.Time `json:"paused_at,omitempty"`
+}
+
+// ScheduleQueueResponse represents a page of the schedule, ordered by next run
+type ScheduleQueueResponse struct {
+	SchedulerStatusResponse
+	Rules []ScheduledRuleResponse `json:"rules"`
+	Total int64                   `json:"total"`
+	Page  int                     `json:"page"`
+	Size  int                     `json:"size"`
+}
+
+// ScheduledRuleResponse represents a rule in the schedule. A rule under lease
+// is being processed by the named scheduler, and its next run is the end of
+// the lease.
+type ScheduledRuleResponse struct {
+	RuleID         string    `json:"rule_id"`
+	Name           string    `json:"name,omitempty"`
+	Type           string    `json:"rule_type,omitempty"`
+	UserID         string    `json:"user_id,omitempty"`
+	Active         bool      `json:"active"`
+	NextRun        time.Time `json:"next_run"`
+	OverdueSeconds int       `json:"overdue_seconds,omitempty"`
+	LeasedBy       string    `json:"leased_by,omitempty"`
+}
+
+// AdminHandler handles admin API requests for operating the service
+type AdminHandler struct {
+	ruleStorage storage.RuleStorage
+	seenStorage storage.SeenStorage
+	scheduler   SchedulerControl
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(ruleStorage storage.RuleStorage, seenStorage storage.SeenStorage, scheduler SchedulerControl) *AdminHandler {
+	return &AdminHandler{
+		ruleStorage: ruleStorage,
+		seenStorage: seenStorage,
+		scheduler:   scheduler,
+	}
+}
+
+// ListRules lists the rules of every user, oldest first. The type, user_id
+// and club parameters match exactly; active and has_error take booleans.
+func (h *AdminHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	page, size, ok := parsePagination(r, defaultAdminPageSize, maxAdminPageSize)
+	if !ok {
+		respondWithError(w, "Invalid page or size parameter", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	active, ok := parseBoolFilter(query.Get("active"))
+	if !ok {
+		respondWithError(w, "Invalid active parameter", http.StatusBadRequest)
+		return
+	}
+	hasError, ok := parseBoolFilter(query.Get("has_error"))
+	if !ok {
+		respondWithError(w, "Invalid has_error parameter", http.StatusBadRequest)
+		return
+	}
+	ruleType := query.Get("type")
+	userID := query.Get("user_id")
+	club := query.Get("club")
+
+	rules, err := h.ruleStorage.ListAllRules(r.Context())
+	if err != nil {
+		logger.Error("Failed to list all rules", err)
+		respondWithError(w, "Failed to list rules", http.StatusInternalServerError)
+		return
+	}
+
+	matched := make([]*model.Rule, 0, len(rules))
+	for _, rule := range rules {
+		switch {
+		case ruleType != "" && rule.Type != ruleType:
+		case userID != "" && rule.UserID != userID:
+		case club != "" && !slices.Contains(rule.ClubIDs, club):
+		case active != nil && rule.Active != *active:
+		case hasError != nil && (rule.LastError != "") != *hasError:
+		default:
+			matched = append(matched, rule)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.Before(matched[j].CreatedAt)
+		}
+		return matched[i].ID < matched[j].ID
+	})
+
+	start := min((page-1)*size, len(matched))
+	end := min(start+size, len(matched))
+
+	ruleIDs := make([]string, 0, end-start)
+	for _, rule := range matched[start:end] {
+		ruleIDs = append(ruleIDs, rule.ID)
+	}
+	seen, err := h.seenStorage.CountSeen(r.Context(), ruleIDs)
+	if err != nil {
+		logger.Error("Failed to count seen activities", err)
+		respondWithError(w, "Failed to list rules", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]AdminRuleResponse, 0, len(ruleIDs))
+	for _, rule := range matched[start:end] {
+		results = append(results, AdminRuleResponse{Rule: rule, SeenActivities: seen[rule.ID]})
+	}
+
+	respondWithJSON(w, AdminRuleListResponse{
+		Rules: results,
+		Total: len(matched),
+		Page:  page,
+		Size:  size,
+	})
+}
+
+// RunRule checks a rule now instead of waiting until it is due, and responds
+// with the rule as updated by the check
+func (h *AdminHandler) RunRule(w http.ResponseWriter, r *http.Request) {
+	ruleID, ok := h.existingRule(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := h.scheduler.RunRule(r.Context(), ruleID)
+	if errors.Is(err, storage.ErrRuleLeased) {
+		respondWithError(w, "Rule is already being processed", http.StatusConflict)
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		logger.Warn("Rule run timed out", "rule_id", ruleID)
+		respondWithError(w, "Rule run timed out", http.StatusGatewayTimeout)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to run rule", err, "rule_id", ruleID)
+		respondWithError(w, "Failed to run rule", http.StatusInternalServerError)
+		return
+	}
+	if rule == nil {
+		respondWithError(w, "Rule not found", http.StatusNotFound)
+		return
+	}
+
+	respondWithJSON(w, rule)
+}
+
+// ResetSeen forgets the activities a rule has notified about, so the next
+// check notifies about every matching activity again
+func (h *AdminHandler) ResetSeen(w http.ResponseWriter, r *http.Request) {
+	ruleID, ok := h.existingRule(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.seenStorage.DeleteSeen(r.Context(), ruleID); err != nil {
+		logger.Error("Failed to reset seen activities", err, "rule_id", ruleID)
+		respondWithError(w, "Failed to reset seen activities", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("Seen activities reset", "rule_id", ruleID)
+	respondWithSuccess(w, "Seen activities reset successfully")
+}
+
+// SchedulerStatus reports whether the scheduler is paused
+func (h *AdminHandler) SchedulerStatus(w http.ResponseWriter, r *http.Request) {
+	status, ok := h.schedulerStatus(w, r)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, status)
+}
+
+// PauseScheduler stops every replica from checking rules and sending digests
+func (h *AdminHandler) PauseScheduler(w http.ResponseWriter, r *http.Request) {
+	if err := h.scheduler.Pause(r.Context()); err != nil {
+		logger.Error("Failed to pause scheduler", err)
+		respondWithError(w, "Failed to pause scheduler", http.StatusInternalServerError)
+		return
+	}
+
+	h.SchedulerStatus(w, r)
+}
+
+// ResumeScheduler lets every replica check rules and send digests again
+func (h *AdminHandler) ResumeScheduler(w http.ResponseWriter, r *http.Request) {
+	if err := h.scheduler.Resume(r.Context()); err != nil {
+		logger.Error("Failed to resume scheduler", err)
+		respondWithError(w, "Failed to resume scheduler", http.StatusInternalServerError)
+		return
+	}
+
+	h.SchedulerStatus(w, r)
+}
+
+// ScheduleQueue lists the scheduled rules by next run time
+func (h *AdminHandler) ScheduleQueue(w http.ResponseWriter, r *http.Request) {
+	page, size, ok := parsePagination(r, defaultAdminPageSize, maxAdminPageSize)
+	if !ok {
+		respondWithError(w, "Invalid page or size parameter", http.StatusBadRequest)
+		return
+	}
+
+	status, ok := h.schedulerStatus(w, r)
+	if !ok {
+		return
+	}
+
+	scheduled, total, err := h.ruleStorage.ListScheduledRules(r.Context(), (page-1)*size, size)
+	if err != nil {
+		logger.Error("Failed to list scheduled rules", err)
+		respondWithError(w, "Failed to list scheduled rules", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	entries := make([]ScheduledRuleResponse, 0, len(scheduled))
+	for _, entry := range scheduled {
+		resp := ScheduledRuleResponse{
+			RuleID:   entry.RuleID,
+			NextRun:  entry.DueAt,
+			LeasedBy: entry.Owner,
+		}
+		if entry.DueAt.Before(now) {
+			resp.OverdueSeconds = int(now.Sub(entry.DueAt).Seconds())
+		}
+
+		// The rule may have been deleted since the schedule was read
+		if rule, err := h.ruleStorage.GetRule(r.Context(), entry.RuleID); err == nil {
+			resp.Name = rule.Name
+			resp.Type = rule.Type
+			resp.UserID = rule.UserID
+			resp.Active = rule.Active
+		}
+		entries = append(entries, resp)
+	}
+
+	respondWithJSON(w, ScheduleQueueResponse{
+		SchedulerStatusResponse: status,
+		Rules:                   entries,
+		Total:                   total,
+		Page:                    page,
+		Size:                    size,
+	})
+}
+
+// existingRule returns the rule ID in the URL, responding with an error and
+// returning false unless the rule exists
+func (h *AdminHandler) existingRule(w http.ResponseWriter, r *http.Request) (string, bool) {
+	ruleID := chi.URLParam(r, "id")
+	if ruleID == "" {
+		respondWithError(w, "Rule ID is required", http.StatusBadRequest)
+		return "", false
+	}
+
+	if _, err := h.ruleStorage.GetRule(r.Context(), ruleID); err != nil {
+		logger.Error("Failed to get rule", err, "rule_id", ruleID)
+		respondWithError(w, "Rule not found", http.StatusNotFound)
+		return "", false
+	}
+
+	return ruleID, true
+}
+
+// schedulerStatus reads whether the scheduler is paused, responding with an
+// error and returning false if that fails
+func (h *AdminHandler) schedulerStatus(w http.ResponseWriter, r *http.Request) (SchedulerStatusResponse, bool) {
+	pausedAt, err := h.scheduler.PausedAt(r.Context())
+	if err != nil {
+		logger.Error("Failed to get scheduler status", err)
+		respondWithError(w, "Failed to get scheduler status", http.StatusInternalServerError)
+		return SchedulerStatusResponse{}, false
+	}
+
+	return SchedulerStatusResponse{
+		Paused:   pausedAt != nil,
+		PausedAt: pausedAt,
+	}, true
+}
+
+// parseBoolFilter parses an optional boolean query parameter, returning nil
+// when it is empty and false when it is not a boolean
+func parseBoolFilter(value string) (*bool, bool) {
+	if value == "" {
+		return nil, true
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, false
+	}
+	return &parsed, true
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockSeenStorage struct {
+	mock.Mock
+}
+
+func (m *MockSeenStorage) GetSeen(ctx context.Context, ruleID, activityID string) (*model.ActivityFingerprint, bool, error) {
+	args := m.Called(ctx, ruleID, activityID)
+	if args.Get(0) == nil {
+		return nil, args.Bool(1), args.Error(2)
+	}
+	return args.Get(0).(*model.ActivityFingerprint), args.Bool(1), args.Error(2)
+}
+
+func (m *MockSeenStorage) MarkSeen(ctx context.Context, ruleID string, activity model.Activity) error {
+	args := m.Called(ctx, ruleID, activity)
+	return args.Error(0)
+}
+
+func (m *MockSeenStorage) Prune(ctx context.Context, ruleID string, before time.Time) (int64, error) {
+	args := m.Called(ctx, ruleID, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSeenStorage) CountSeen(ctx context.Context, ruleIDs []string) (map[string]int64, error) {
+	args := m.Called(ctx, ruleIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (m *MockSeenStorage) DeleteSeen(ctx context.Context, ruleID string) error {
+	args := m.Called(ctx, ruleID)
+	return args.Error(0)
+}
+
+type MockSchedulerControl struct {
+	mock.Mock
+}
+
+func (m *MockSchedulerControl) RunRule(ctx context.Context, ruleID string) (*model.Rule, error) {
+	args := m.Called(ctx, ruleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Rule), args.Error(1)
+}
+
+func (m *MockSchedulerControl) Pause(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockSchedulerControl) Resume(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockSchedulerControl) PausedAt(ctx context.Context) (*time.Time, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func newAdminTestRouter(handler *AdminHandler) *chi.Mux {
+	r := chi.NewRouter()
+	r.Get("/rules", handler.ListRules)
+	r.Post("/rules/{id}/run", handler.RunRule)
+	r.Delete("/rules/{id}/seen", handler.ResetSeen)
+	r.Get("/scheduler", handler.SchedulerStatus)
+	r.Post("/scheduler/pause", handler.PauseScheduler)
+	r.Post("/scheduler/resume", handler.ResumeScheduler)
+	r.Get("/scheduler/queue", handler.ScheduleQueue)
+	return r
+}
+
+func TestAdminHandler_ListRules(t *testing.T) {
+	failure := "club API down"
+	base := time.Now().Add(-time.Hour)
+	rules := []*model.Rule{
+		{ID: "rule-c", Type: "class", UserID: "user-2", ClubIDs: []string{"club-2"}, Active: true, CreatedAt: base.Add(2 * time.Minute)},
+		{ID: "rule-a", Type: "match", UserID: "user-1", ClubIDs: []string{"club-1"}, Active: true, CreatedAt: base},
+		{ID: "rule-b", Type: "match", UserID: "user-1", ClubIDs: []string{"club-1", "club-2"}, Active: false, CreatedAt: base.Add(time.Minute), LastError: failure},
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedIDs  []string
+		expectedCode int
+		total        int
+	}{
+		{name: "all rules, oldest first", query: "", expectedIDs: []string{"rule-a", "rule-b", "rule-c"}, total: 3},
+		{name: "by type", query: "?type=match", expectedIDs: []string{"rule-a", "rule-b"}, total: 2},
+		{name: "by user", query: "?user_id=user-2", expectedIDs: []string{"rule-c"}, total: 1},
+		{name: "by club", query: "?club=club-2", expectedIDs: []string{"rule-b", "rule-c"}, total: 2},
+		{name: "inactive", query: "?active=false", expectedIDs: []string{"rule-b"}, total: 1},
+		{name: "failing", query: "?has_error=true", expectedIDs: []string{"rule-b"}, total: 1},
+		{name: "healthy and active", query: "?has_error=false&active=true", expectedIDs: []string{"rule-a", "rule-c"}, total: 2},
+		{name: "second page", query: "?page=2&size=2", expectedIDs: []string{"rule-c"}, total: 3},
+		{name: "past the end", query: "?page=5&size=2", expectedIDs: []string{}, total: 3},
+		{name: "invalid active", query: "?active=maybe", expectedCode: http.StatusBadRequest},
+		{name: "invalid page", query: "?page=0", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ruleStorage := new(MockRuleStorage)
+			ruleStorage.On("ListAllRules", mock.Anything).Return(rules, nil)
+			seenStorage := new(MockSeenStorage)
+			seenStorage.On("CountSeen", mock.Anything, mock.Anything).Return(map[string]int64{"rule-a": 4, "rule-c": 1}, nil)
+			r := newAdminTestRouter(NewAdminHandler(ruleStorage, seenStorage, new(MockSchedulerControl)))
+
+			req := httptest.NewRequest(http.MethodGet, "/rules"+tt.query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if tt.expectedCode != 0 {
+				assert.Equal(t, tt.expectedCode, w.Code)
+				return
+			}
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var resp struct {
+				Data AdminRuleListResponse `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+			ids := make([]string, 0, len(resp.Data.Rules))
+			for _, rule := range resp.Data.Rules {
+				ids = append(ids, rule.ID)
+				assert.Equal(t, map[string]int64{"rule-a": 4, "rule-c": 1}[rule.ID], rule.SeenActivities)
+			}
+			assert.Equal(t, tt.expectedIDs, ids)
+			assert.Equal(t, tt.total, resp.Data.Total)
+		})
+	}
+}
+
+func TestAdminHandler_RunRule(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	scheduler := new(MockSchedulerControl)
+	r := newAdminTestRouter(NewAdminHandler(ruleStorage, new(MockSeenStorage), scheduler))
+
+	checked := time.Now()
+	ruleStorage.On("GetRule", mock.Anything, "rule-1").Return(&model.Rule{ID: "rule-1"}, nil)
+	ruleStorage.On("GetRule", mock.Anything, "rule-2").Return(&model.Rule{ID: "rule-2"}, nil)
+	ruleStorage.On("GetRule", mock.Anything, "rule-3").Return(&model.Rule{ID: "rule-3"}, nil)
+	ruleStorage.On("GetRule", mock.Anything, "missing").Return(nil, errors.New("get rule: redis: nil"))
+	scheduler.On("RunRule", mock.Anything, "rule-1").Return(&model.Rule{ID: "rule-1", LastChecked: checked}, nil)
+	scheduler.On("RunRule", mock.Anything, "rule-2").Return(nil, storage.ErrRuleLeased)
+	scheduler.On("RunRule", mock.Anything, "rule-3").Return(nil, fmt.Errorf("run rule: %w", context.DeadlineExceeded))
+
+	w := serve(r, http.MethodPost, "/rules/rule-1/run")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data model.Rule `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, checked.Unix(), resp.Data.LastChecked.Unix())
+
+	assert.Equal(t, http.StatusConflict, serve(r, http.MethodPost, "/rules/rule-2/run").Code)
+	assert.Equal(t, http.StatusGatewayTimeout, serve(r, http.MethodPost, "/rules/rule-3/run").Code)
+	assert.Equal(t, http.StatusNotFound, serve(r, http.MethodPost, "/rules/missing/run").Code)
+	scheduler.AssertNotCalled(t, "RunRule", mock.Anything, "missing")
+}
+
+func TestAdminHandler_ResetSeen(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	seenStorage := new(MockSeenStorage)
+	r := newAdminTestRouter(NewAdminHandler(ruleStorage, seenStorage, new(MockSchedulerControl)))
+
+	ruleStorage.On("GetRule", mock.Anything, "rule-1").Return(&model.Rule{ID: "rule-1"}, nil)
+	ruleStorage.On("GetRule", mock.Anything, "missing").Return(nil, errors.New("get rule: redis: nil"))
+	seenStorage.On("DeleteSeen", mock.Anything, "rule-1").Return(nil)
+
+	assert.Equal(t, http.StatusOK, serve(r, http.MethodDelete, "/rules/rule-1/seen").Code)
+	assert.Equal(t, http.StatusNotFound, serve(r, http.MethodDelete, "/rules/missing/seen").Code)
+	seenStorage.AssertExpectations(t)
+	seenStorage.AssertNumberOfCalls(t, "DeleteSeen", 1)
+}
+
+func TestAdminHandler_PauseAndResume(t *testing.T) {
+	scheduler := new(MockSchedulerControl)
+	r := newAdminTestRouter(NewAdminHandler(new(MockRuleStorage), new(MockSeenStorage), scheduler))
+
+	pausedAt := time.Now().Truncate(time.Second)
+	scheduler.On("Pause", mock.Anything).Return(nil)
+	scheduler.On("PausedAt", mock.Anything).Return(&pausedAt, nil).Once()
+	scheduler.On("Resume", mock.Anything).Return(nil)
+	scheduler.On("PausedAt", mock.Anything).Return(nil, nil).Once()
+
+	var resp struct {
+		Data SchedulerStatusResponse `json:"data"`
+	}
+
+	w := serve(r, http.MethodPost, "/scheduler/pause")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Data.Paused)
+	require.NotNil(t, resp.Data.PausedAt)
+	assert.True(t, pausedAt.Equal(*resp.Data.PausedAt))
+
+	w = serve(r, http.MethodPost, "/scheduler/resume")
+	require.Equal(t, http.StatusOK, w.Code)
+	resp.Data = SchedulerStatusResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.False(t, resp.Data.Paused)
+	assert.Nil(t, resp.Data.PausedAt)
+
+	scheduler.AssertExpectations(t)
+}
+
+func TestAdminHandler_ScheduleQueue(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	scheduler := new(MockSchedulerControl)
+	r := newAdminTestRouter(NewAdminHandler(ruleStorage, new(MockSeenStorage), scheduler))
+
+	now := time.Now()
+	scheduler.On("PausedAt", mock.Anything).Return(nil, nil)
+	ruleStorage.On("ListScheduledRules", mock.Anything, 2, 2).Return([]storage.ScheduledRule{
+		{RuleID: "rule-1", DueAt: now.Add(-90 * time.Second)},
+		{RuleID: "rule-2", DueAt: now.Add(time.Minute), Owner: "replica-1"},
+	}, int64(4), nil)
+	ruleStorage.On("GetRule", mock.Anything, "rule-1").Return(&model.Rule{ID: "rule-1", Name: "Evening matches", Type: "match", UserID: "user-1", Active: true}, nil)
+	ruleStorage.On("GetRule", mock.Anything, "rule-2").Return(nil, errors.New("get rule: redis: nil"))
+
+	w := serve(r, http.MethodGet, "/scheduler/queue?page=2&size=2")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data ScheduleQueueResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.False(t, resp.Data.Paused)
+	assert.Equal(t, int64(4), resp.Data.Total)
+	require.Len(t, resp.Data.Rules, 2)
+
+	assert.Equal(t, "Evening matches", resp.Data.Rules[0].Name)
+	assert.InDelta(t, 90, resp.Data.Rules[0].OverdueSeconds, 1)
+	assert.Empty(t, resp.Data.Rules[0].LeasedBy)
+
+	assert.Equal(t, "rule-2", resp.Data.Rules[1].RuleID)
+	assert.Zero(t, resp.Data.Rules[1].OverdueSeconds)
+	assert.Equal(t, "replica-1", resp.Data.Rules[1].LeasedBy)
+}
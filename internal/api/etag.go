@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+)
+
+// ruleETag returns the entity tag of a rule's current version
+func ruleETag(rule *model.Rule) string {
+	return strconv.Quote(strconv.FormatInt(rule.Version, 10))
+}
+
+// ifMatch checks the request's If-Match header against a rule. Requests
+// without the header always match, as does "*". Weak tags never match, since
+// If-Match uses strong comparison (RFC 9110, section 13.1.1).
+func ifMatch(r *http.Request, rule *model.Rule) bool {
+	header := strings.Join(r.Header.Values("If-Match"), ",")
+	if header == "" {
+		return true
+	}
+
+	etag := ruleETag(rule)
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
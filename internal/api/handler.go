@@ -1,13 +1,30 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/notification"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
 )
 
+// Health statuses reported for the service and each of its components
+const (
+	HealthOK       = "OK"
+	HealthDegraded = "DEGRADED"
+	HealthDown     = "DOWN"
+)
+
+// healthCheckTimeout bounds how long a single component check may take
+const healthCheckTimeout = 2 * time.Second
+
 // HealthResponse represents the response from the health check endpoint
 type HealthResponse struct {
 	Status    string `json:"status"`
@@ -15,15 +32,78 @@ type HealthResponse struct {
 	Timestamp string `json:"timestamp"`
 }
 
-// HealthHandler handles the health check endpoint
+// ReadinessResponse represents the response from the readiness endpoint
+type ReadinessResponse struct {
+	Status     string                     `json:"status"`
+	Version    string                     `json:"version"`
+	Timestamp  string                     `json:"timestamp"`
+	Components map[string]ComponentHealth `json:"components"`
+}
+
+// ComponentHealth is the result of checking a single component
+type ComponentHealth struct {
+	Status    string                 `json:"status"`
+	LatencyMs float64                `json:"latency_ms"`
+	Error     string                 `json:"error,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// ComponentCheck checks a component the service depends on. A failing
+// critical component makes the service not ready; any other failure only
+// marks the service as degraded.
+type ComponentCheck struct {
+	Name     string
+	Critical bool
+	Check    func(ctx context.Context) (map[string]interface{}, error)
+}
+
+// RedisCheck returns a critical check that pings Redis
+func RedisCheck(redis *storage.RedisClient) ComponentCheck {
+	return ComponentCheck{
+		Name:     "redis",
+		Critical: true,
+		Check: func(ctx context.Context) (map[string]interface{}, error) {
+			return nil, redis.CheckHealth(ctx)
+		},
+	}
+}
+
+// NotifierCheck returns a check that reports which notification channels
+// are missing the settings they need to deliver
+func NotifierCheck(registry *notification.Registry) ComponentCheck {
+	return ComponentCheck{
+		Name: "notifiers",
+		Check: func(ctx context.Context) (map[string]interface{}, error) {
+			details := make(map[string]interface{})
+			var missing []string
+			for channel, err := range registry.CheckConfig() {
+				if err != nil {
+					details[channel] = err.Error()
+					missing = append(missing, channel)
+					continue
+				}
+				details[channel] = "configured"
+			}
+
+			if len(missing) > 0 {
+				sort.Strings(missing)
+				return details, fmt.Errorf("channels not configured: %s", strings.Join(missing, ", "))
+			}
+			return details, nil
+		},
+	}
+}
+
+// HealthHandler handles the health check endpoints
 type HealthHandler struct {
 	Version string
+	Checks  []ComponentCheck
 }
 
 // HealthCheck returns the health status of the service
 func (h *HealthHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	healthResp := HealthResponse{
-		Status:    "OK",
+		Status:    HealthOK,
 		Version:   h.Version,
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 	}
@@ -40,3 +120,100 @@ func (h *HealthHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 		logger.Error("Failed to encode JSON response", err)
 	}
 }
+
+// Live reports that the process is up and serving requests. It checks no
+// dependencies, so a failing dependency never gets the service restarted.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	h.HealthCheck(w, r)
+}
+
+// Ready checks every component and reports whether the service can do its
+// work, responding with 503 when a critical component is down
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	components := h.checkComponents(r.Context())
+
+	readiness := ReadinessResponse{
+		Status:     HealthOK,
+		Version:    h.Version,
+		Timestamp:  time.Now().UTC().Format(time.RFC3339),
+		Components: components,
+	}
+
+	for _, check := range h.Checks {
+		switch components[check.Name].Status {
+		case HealthDown:
+			readiness.Status = HealthDown
+		case HealthDegraded:
+			if readiness.Status == HealthOK {
+				readiness.Status = HealthDegraded
+			}
+		}
+	}
+
+	status := http.StatusOK
+	resp := Response{
+		Data:   readiness,
+		Status: status,
+	}
+	if readiness.Status == HealthDown {
+		status = http.StatusServiceUnavailable
+		msg := "Service not ready"
+		resp.Error = &msg
+		resp.Status = status
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Error("Failed to encode JSON response", err)
+	}
+}
+
+// checkComponents runs the component checks concurrently, each under its own timeout
+func (h *HealthHandler) checkComponents(ctx context.Context) map[string]ComponentHealth {
+	components := make(map[string]ComponentHealth, len(h.Checks))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range h.Checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			health := runCheck(ctx, check)
+
+			mu.Lock()
+			components[check.Name] = health
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return components
+}
+
+// runCheck runs a single component check and times it
+func runCheck(ctx context.Context, check ComponentCheck) ComponentHealth {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := check.Check(ctx)
+	health := ComponentHealth{
+		Status:    HealthOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   details,
+	}
+
+	if err != nil {
+		logger.Warn("Health check failed", "component", check.Name, "error", err.Error())
+
+		health.Error = err.Error()
+		health.Status = HealthDegraded
+		if check.Critical {
+			health.Status = HealthDown
+		}
+	}
+
+	return health
+}
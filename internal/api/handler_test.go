@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func okCheck(name string, critical bool) ComponentCheck {
+	return ComponentCheck{
+		Name:     name,
+		Critical: critical,
+		Check: func(ctx context.Context) (map[string]interface{}, error) {
+			return map[string]interface{}{"checked": true}, nil
+		},
+	}
+}
+
+func failingCheck(name string, critical bool) ComponentCheck {
+	return ComponentCheck{
+		Name:     name,
+		Critical: critical,
+		Check: func(ctx context.Context) (map[string]interface{}, error) {
+			return nil, errors.New(name + " unavailable")
+		},
+	}
+}
+
+func getReadiness(t *testing.T, handler *HealthHandler) (int, ReadinessResponse) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/health/ready", nil)
+	rec := httptest.NewRecorder()
+	handler.Ready(rec, req)
+
+	var resp struct {
+		Data   ReadinessResponse `json:"data"`
+		Error  *string           `json:"error"`
+		Status int               `json:"status"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, rec.Code, resp.Status)
+
+	return rec.Code, resp.Data
+}
+
+func TestHealthHandler_Live(t *testing.T) {
+	handler := &HealthHandler{
+		Version: "1.0.0",
+		Checks:  []ComponentCheck{failingCheck("redis", true)},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/health/live", nil)
+	rec := httptest.NewRecorder()
+	handler.Live(rec, req)
+
+	// Liveness does not depend on other components
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Data HealthResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, HealthOK, resp.Data.Status)
+	assert.Equal(t, "1.0.0", resp.Data.Version)
+}
+
+func TestHealthHandler_Ready(t *testing.T) {
+	tests := []struct {
+		name           string
+		checks         []ComponentCheck
+		expectedCode   int
+		expectedStatus string
+		components     map[string]string
+	}{
+		{
+			name:           "all components healthy",
+			checks:         []ComponentCheck{okCheck("redis", true), okCheck("notifiers", false)},
+			expectedCode:   http.StatusOK,
+			expectedStatus: HealthOK,
+			components:     map[string]string{"redis": HealthOK, "notifiers": HealthOK},
+		},
+		{
+			name:           "non-critical component failing",
+			checks:         []ComponentCheck{okCheck("redis", true), failingCheck("notifiers", false)},
+			expectedCode:   http.StatusOK,
+			expectedStatus: HealthDegraded,
+			components:     map[string]string{"redis": HealthOK, "notifiers": HealthDegraded},
+		},
+		{
+			name:           "critical component failing",
+			checks:         []ComponentCheck{failingCheck("redis", true), failingCheck("notifiers", false)},
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedStatus: HealthDown,
+			components:     map[string]string{"redis": HealthDown, "notifiers": HealthDegraded},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, readiness := getReadiness(t, &HealthHandler{Version: "1.0.0", Checks: tt.checks})
+
+			assert.Equal(t, tt.expectedCode, code)
+			assert.Equal(t, tt.expectedStatus, readiness.Status)
+			require.Len(t, readiness.Components, len(tt.components))
+			for name, status := range tt.components {
+				assert.Equal(t, status, readiness.Components[name].Status, name)
+				assert.GreaterOrEqual(t, readiness.Components[name].LatencyMs, float64(0))
+			}
+		})
+	}
+}
+
+func TestHealthHandler_Ready_ReportsDetailsAndErrors(t *testing.T) {
+	handler := &HealthHandler{Checks: []ComponentCheck{okCheck("scheduler", true), failingCheck("redis", true)}}
+
+	_, readiness := getReadiness(t, handler)
+
+	assert.Equal(t, map[string]interface{}{"checked": true}, readiness.Components["scheduler"].Details)
+	assert.Empty(t, readiness.Components["scheduler"].Error)
+	assert.Equal(t, "redis unavailable", readiness.Components["redis"].Error)
+}
+
+func TestHealthHandler_Ready_CheckTimeout(t *testing.T) {
+	blocking := ComponentCheck{
+		Name:     "redis",
+		Critical: true,
+		Check: func(ctx context.Context) (map[string]interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+
+	code, readiness := getReadiness(t, &HealthHandler{Checks: []ComponentCheck{blocking}})
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, context.DeadlineExceeded.Error(), readiness.Components["redis"].Error)
+	assert.GreaterOrEqual(t, readiness.Components["redis"].LatencyMs, float64(healthCheckTimeout.Milliseconds()))
+}
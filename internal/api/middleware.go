@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"net/http"
 	"runtime/debug"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
 	"github.com/rafa-garcia/padel-alert/internal/logger"
 	"github.com/rafa-garcia/padel-alert/internal/metrics"
+	"github.com/rafa-garcia/padel-alert/internal/validation"
 )
 
 // ctxKey is a custom type for context keys to avoid collisions
@@ -23,18 +27,11 @@ const (
 func APIKeyAuth(validAPIKeys []string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Check header
-			key := r.Header.Get("X-API-Key")
-
-			// If not in header, check query param
-			if key == "" {
-				key = r.URL.Query().Get("api_key")
-			}
+			key := requestAPIKey(r)
 
 			// Validate key
 			if key == "" || !isValidAPIKey(key, validAPIKeys) {
 				logger.Warn("Unauthorized API request", "ip", r.RemoteAddr, "path", r.URL.Path)
-				metrics.HttpRequestsTotal.WithLabelValues(r.Method, r.URL.Path, "401").Inc()
 				respondWithError(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
@@ -50,6 +47,9 @@ func RequestLogger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
+		metrics.HttpRequestsInFlight.Inc()
+		defer metrics.HttpRequestsInFlight.Dec()
+
 		// Create a wrapped response writer that captures the status code
 		ww := NewWrapResponseWriter(w)
 
@@ -60,14 +60,19 @@ func RequestLogger(next http.Handler) http.Handler {
 		duration := time.Since(start)
 		statusCode := ww.Status()
 
+		// Label by the matched route so path parameters don't create new series
+		route := routePattern(r)
+
 		// Update metrics
-		metrics.HttpRequestsTotal.WithLabelValues(r.Method, r.URL.Path, http.StatusText(statusCode)).Inc()
-		metrics.HttpRequestDuration.WithLabelValues(r.Method, r.URL.Path).Observe(duration.Seconds())
+		metrics.HttpRequestsTotal.WithLabelValues(r.Method, route, strconv.Itoa(statusCode)).Inc()
+		metrics.HttpRequestDuration.WithLabelValues(r.Method, route).Observe(duration.Seconds())
+		metrics.HttpResponseSize.WithLabelValues(r.Method, route).Observe(float64(ww.BytesWritten()))
 
 		// Log request details
 		logger.Info("HTTP request",
 			"method", r.Method,
 			"path", r.URL.Path,
+			"route", route,
 			"status", statusCode,
 			"duration", duration.String(),
 			"size", ww.BytesWritten(),
@@ -76,6 +81,31 @@ func RequestLogger(next http.Handler) http.Handler {
 	})
 }
 
+// unmatchedRoute labels requests that did not match any registered route
+const unmatchedRoute = "unmatched"
+
+// routePattern returns the chi route pattern that matched the request, such as
+// "/api/v1/rules/{id}", or unmatchedRoute when no route handled it.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return unmatchedRoute
+	}
+
+	pattern := rctx.RoutePattern()
+	if pattern == "" {
+		return unmatchedRoute
+	}
+
+	// Sub-routers mounted with r.Route register their index as "/", so trim
+	// the trailing slash to keep "/api/v1/rules/" and "/api/v1/rules" together
+	if len(pattern) > 1 {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+
+	return pattern
+}
+
 // RequestID adds a unique request ID to the context
 func RequestID(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -123,9 +153,9 @@ func Recoverer(next http.Handler) http.Handler {
 func CORS() func(http.Handler) http.Handler {
 	return cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"}, // Adjust this in production
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-API-Key"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "If-Match", "X-CSRF-Token", "X-API-Key"},
+		ExposedHeaders:   []string{"ETag", "Link"},
 		AllowCredentials: true,
 		MaxAge:           300, // Maximum value not freely browsable in seconds
 	})
@@ -145,6 +175,15 @@ func SecurityHeaders(next http.Handler) http.Handler {
 	})
 }
 
+// requestAPIKey returns the API key from the X-API-Key header, or failing
+// that the api_key query parameter
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	return r.URL.Query().Get("api_key")
+}
+
 // isValidAPIKey checks if the provided key is in the list of valid keys
 func isValidAPIKey(key string, validAPIKeys []string) bool {
 	for _, validKey := range validAPIKeys {
@@ -171,6 +210,27 @@ func respondWithError(w http.ResponseWriter, message string, status int) {
 	}
 }
 
+// respondWithValidationErrors sends a 400 response listing every invalid field
+func respondWithValidationErrors(w http.ResponseWriter, errs validation.Errors) {
+	respondWithFieldErrors(w, errs, http.StatusBadRequest)
+}
+
+// respondWithFieldErrors sends a response with the given status listing every invalid field
+func respondWithFieldErrors(w http.ResponseWriter, errs validation.Errors, status int) {
+	msg := "Validation failed"
+	resp := Response{
+		Error:  &msg,
+		Errors: errs,
+		Status: status,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Error("Failed to encode JSON response", err)
+	}
+}
+
 // respondWithJSON sends a JSON response
 func respondWithJSON(w http.ResponseWriter, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rafa-garcia/padel-alert/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMetricsTestRouter builds a router with the request logger and a few routes
+// shaped like the real API
+func newMetricsTestRouter(inHandler func()) *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(RequestLogger)
+
+	r.Route("/api/v1/rules", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			respondWithJSON(w, []string{})
+		})
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+				if inHandler != nil {
+					inHandler()
+				}
+				respondWithJSON(w, chi.URLParam(r, "id"))
+			})
+		})
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(APIKeyAuth([]string{"secret"}))
+		r.Get("/api/v1/protected/{id}", func(w http.ResponseWriter, r *http.Request) {
+			respondWithSuccess(w, "ok")
+		})
+	})
+
+	return r
+}
+
+func resetHTTPMetrics() {
+	metrics.HttpRequestsTotal.Reset()
+	metrics.HttpRequestDuration.Reset()
+	metrics.HttpResponseSize.Reset()
+	metrics.HttpRequestsInFlight.Set(0)
+}
+
+func serve(r http.Handler, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequestLogger_LabelsByRoutePattern(t *testing.T) {
+	resetHTTPMetrics()
+	r := newMetricsTestRouter(nil)
+
+	for _, id := range []string{"a1", "b2", "c3", "d4"} {
+		rec := serve(r, http.MethodGet, "/api/v1/rules/"+id)
+		require.Equal(t, http.StatusOK, rec.Code)
+	}
+	serve(r, http.MethodGet, "/api/v1/rules")
+	serve(r, http.MethodGet, "/api/v1/rules/")
+
+	// One series per route, however many IDs were requested
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.HttpRequestsTotal))
+	assert.Equal(t, float64(4), testutil.ToFloat64(metrics.HttpRequestsTotal.WithLabelValues("GET", "/api/v1/rules/{id}", "200")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.HttpRequestsTotal.WithLabelValues("GET", "/api/v1/rules", "200")))
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.HttpRequestDuration))
+	assert.Equal(t, 2, testutil.CollectAndCount(metrics.HttpResponseSize))
+}
+
+func TestRequestLogger_UnmatchedRoutes(t *testing.T) {
+	resetHTTPMetrics()
+	r := newMetricsTestRouter(nil)
+
+	for _, path := range []string{"/nope", "/wp-admin/setup.php", "/api/v1/unknown/123"} {
+		rec := serve(r, http.MethodGet, path)
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	}
+
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.HttpRequestsTotal))
+	assert.Equal(t, float64(3), testutil.ToFloat64(metrics.HttpRequestsTotal.WithLabelValues("GET", unmatchedRoute, "404")))
+}
+
+func TestRequestLogger_NumericStatus(t *testing.T) {
+	resetHTTPMetrics()
+	r := newMetricsTestRouter(nil)
+
+	serve(r, http.MethodGet, "/api/v1/protected/1")
+	serve(r, http.MethodGet, "/api/v1/protected/2")
+	serve(r, http.MethodGet, "/api/v1/protected/3?api_key=secret")
+
+	expected := `
+# HELP padel_alert_http_requests_total The total number of HTTP requests
+# TYPE padel_alert_http_requests_total counter
+padel_alert_http_requests_total{endpoint="/api/v1/protected/{id}",method="GET",status="200"} 1
+padel_alert_http_requests_total{endpoint="/api/v1/protected/{id}",method="GET",status="401"} 2
+`
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(metrics.HttpRequestsTotal)
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "padel_alert_http_requests_total"))
+}
+
+func TestRequestLogger_InFlightAndResponseSize(t *testing.T) {
+	resetHTTPMetrics()
+
+	var inFlight float64
+	r := newMetricsTestRouter(func() {
+		inFlight = testutil.ToFloat64(metrics.HttpRequestsInFlight)
+	})
+
+	rec := serve(r, http.MethodGet, "/api/v1/rules/abc")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, float64(1), inFlight)
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.HttpRequestsInFlight))
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(metrics.HttpResponseSize)
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	require.Len(t, families[0].GetMetric(), 1)
+
+	histogram := families[0].GetMetric()[0].GetHistogram()
+	assert.Equal(t, uint64(1), histogram.GetSampleCount())
+	assert.Equal(t, float64(rec.Body.Len()), histogram.GetSampleSum())
+}
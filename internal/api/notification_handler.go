@@ -0,0 +1,114 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
+)
+
+const (
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 100
+)
+
+// NotificationListResponse represents a page of a rule's notification history
+type NotificationListResponse struct {
+	Notifications []*model.Notification `json:"notifications"`
+	Total         int64                 `json:"total"`
+	Page          int                   `json:"page"`
+	Size          int                   `json:"size"`
+}
+
+// NotificationHandler handles API requests for notification history
+type NotificationHandler struct {
+	ruleStorage         storage.RuleStorage
+	notificationStorage storage.NotificationStorage
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(ruleStorage storage.RuleStorage, notificationStorage storage.NotificationStorage) *NotificationHandler {
+	return &NotificationHandler{
+		ruleStorage:         ruleStorage,
+		notificationStorage: notificationStorage,
+	}
+}
+
+// ListNotifications lists the notification history of a rule, newest first
+func (h *NotificationHandler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		respondWithError(w, "User ID not found in request context", http.StatusUnauthorized)
+		return
+	}
+
+	ruleID := chi.URLParam(r, "id")
+	if ruleID == "" {
+		respondWithError(w, "Rule ID is required", http.StatusBadRequest)
+		return
+	}
+
+	page, size, ok := parsePagination(r, defaultNotificationPageSize, maxNotificationPageSize)
+	if !ok {
+		respondWithError(w, "Invalid page or size parameter", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := h.ruleStorage.GetRule(r.Context(), ruleID)
+	if err != nil {
+		logger.Error("Failed to get rule", err, "rule_id", ruleID)
+		respondWithError(w, "Rule not found", http.StatusNotFound)
+		return
+	}
+
+	if rule.UserID != userID {
+		respondWithError(w, "Not authorized to access this rule", http.StatusForbidden)
+		return
+	}
+
+	notifications, total, err := h.notificationStorage.ListNotifications(r.Context(), ruleID, (page-1)*size, size)
+	if err != nil {
+		logger.Error("Failed to list notifications", err, "rule_id", ruleID)
+		respondWithError(w, "Failed to list notifications", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, NotificationListResponse{
+		Notifications: notifications,
+		Total:         total,
+		Page:          page,
+		Size:          size,
+	})
+}
+
+// parsePagination reads 1-based page and size query parameters, capping the size
+func parsePagination(r *http.Request, defaultSize, maxSize int) (int, int, bool) {
+	query := r.URL.Query()
+
+	page := 1
+	if pageStr := query.Get("page"); pageStr != "" {
+		parsed, err := strconv.Atoi(pageStr)
+		if err != nil || parsed < 1 {
+			return 0, 0, false
+		}
+		page = parsed
+	}
+
+	size := defaultSize
+	if sizeStr := query.Get("size"); sizeStr != "" {
+		parsed, err := strconv.Atoi(sizeStr)
+		if err != nil || parsed < 1 {
+			return 0, 0, false
+		}
+		size = parsed
+	}
+
+	if size > maxSize {
+		size = maxSize
+	}
+
+	return page, size, true
+}
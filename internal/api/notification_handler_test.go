@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockNotificationStorage struct {
+	mock.Mock
+}
+
+func (m *MockNotificationStorage) AddNotification(ctx context.Context, notification *model.Notification) error {
+	args := m.Called(ctx, notification)
+	return args.Error(0)
+}
+
+func (m *MockNotificationStorage) ListNotifications(ctx context.Context, ruleID string, offset, limit int) ([]*model.Notification, int64, error) {
+	args := m.Called(ctx, ruleID, offset, limit)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*model.Notification), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockNotificationStorage) DeleteNotifications(ctx context.Context, ruleID string) error {
+	args := m.Called(ctx, ruleID)
+	return args.Error(0)
+}
+
+func TestNotificationHandler_ListNotifications(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	notificationStorage := new(MockNotificationStorage)
+	handler := NewNotificationHandler(ruleStorage, notificationStorage)
+
+	userID := "test-user-123"
+	ruleID := "rule-1"
+
+	ruleStorage.On("GetRule", mock.Anything, ruleID).Return(&model.Rule{ID: ruleID, UserID: userID}, nil)
+	notificationStorage.On("ListNotifications", mock.Anything, ruleID, 10, 10).Return([]*model.Notification{
+		{ID: "notification-1", RuleID: ruleID, Channel: model.ChannelEmail, Status: model.NotificationStatusSent},
+	}, int64(11), nil)
+
+	r := chi.NewRouter()
+	r.Get("/{id}/notifications", handler.ListNotifications)
+
+	req := httptest.NewRequest("GET", "/"+ruleID+"/notifications?page=2&size=10", nil)
+	req = req.WithContext(WithUserID(req.Context(), userID))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data NotificationListResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, int64(11), resp.Data.Total)
+	assert.Equal(t, 2, resp.Data.Page)
+	assert.Equal(t, 10, resp.Data.Size)
+	assert.Len(t, resp.Data.Notifications, 1)
+
+	notificationStorage.AssertExpectations(t)
+}
+
+func TestNotificationHandler_ListNotifications_Forbidden(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	notificationStorage := new(MockNotificationStorage)
+	handler := NewNotificationHandler(ruleStorage, notificationStorage)
+
+	ruleStorage.On("GetRule", mock.Anything, "rule-1").Return(&model.Rule{ID: "rule-1", UserID: "someone-else"}, nil)
+
+	r := chi.NewRouter()
+	r.Get("/{id}/notifications", handler.ListNotifications)
+
+	req := httptest.NewRequest("GET", "/rule-1/notifications", nil)
+	req = req.WithContext(WithUserID(req.Context(), "test-user-123"))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	notificationStorage.AssertNotCalled(t, "ListNotifications", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/metrics"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
+)
+
+// rateLimitWindow is the window API rate limits are counted over
+const rateLimitWindow = time.Minute
+
+// Route groups with their own rate limit
+const (
+	rateLimitGroupPublic = "public"
+	rateLimitGroupUser   = "user"
+	rateLimitGroupAdmin  = "admin"
+
+	// Per client IP limits on the user and admin groups, counted before
+	// authentication so that failed attempts count too
+	rateLimitGroupUserIP  = "user_ip"
+	rateLimitGroupAdminIP = "admin_ip"
+)
+
+// RateLimitConfig configures the requests per minute allowed on each route
+// group. A limit of zero, or no storage, disables limiting.
+type RateLimitConfig struct {
+	Storage        storage.RateLimitStorage
+	TrustedProxies []*net.IPNet // Proxies whose X-Forwarded-For header is trusted
+	Public         int          // Public routes, per client IP
+	User           int          // User routes, per user, or per API key in the legacy auth mode
+	Admin          int          // Admin routes and metrics, per admin key
+	Auth           int          // User and admin routes, per client IP before authentication
+}
+
+// RateLimit limits the requests in a route group to limit per minute for each
+// identity returned by identify, or for each client IP when identify is nil or
+// returns "". Responses carry RateLimit-* headers, and rejected requests get a
+// 429. Requests are let through if the limit cannot be checked, so a Redis
+// outage does not take the API down with it.
+func RateLimit(cfg RateLimitConfig, group string, limit int, identify func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if cfg.Storage == nil || limit <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var identity string
+			if identify != nil {
+				identity = identify(r)
+			}
+			if identity == "" {
+				identity = "ip:" + clientIP(r, cfg.TrustedProxies)
+			}
+
+			result, err := cfg.Storage.Allow(r.Context(), group+":"+identity, limit, rateLimitWindow)
+			if err != nil {
+				logger.Error("Failed to check rate limit, allowing request", err, "group", group)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reset := strconv.Itoa(int(math.Ceil(result.Reset.Seconds())))
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", reset)
+			w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit, int(rateLimitWindow.Seconds())))
+
+			if !result.Allowed {
+				metrics.HttpRateLimited.WithLabelValues(group).Inc()
+				logger.Warn("Rate limit exceeded", "group", group, "identity", identity, "path", r.URL.Path)
+
+				w.Header().Set("Retry-After", reset)
+				respondWithError(w, "Rate limit exceeded, retry in "+reset+"s", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// apiKeyIdentity identifies a request by its API key. Only a hash of the key
+// ends up in Redis.
+func apiKeyIdentity(r *http.Request) string {
+	key := requestAPIKey(r)
+	if key == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return "key:" + hex.EncodeToString(sum[:8])
+}
+
+// userIdentity identifies a request by the authenticated user
+func userIdentity(r *http.Request) string {
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		return ""
+	}
+	return "user:" + userID
+}
+
+// ParseTrustedProxies parses proxy addresses and CIDR ranges, such as
+// "10.0.0.1" or "10.0.0.0/8"
+func ParseTrustedProxies(values []string) ([]*net.IPNet, error) {
+	proxies := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		if strings.Contains(value, "/") {
+			_, network, err := net.ParseCIDR(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+			}
+			proxies = append(proxies, network)
+			continue
+		}
+
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", value)
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 8 * net.IPv4len
+		}
+		proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+
+	return proxies, nil
+}
+
+// clientIP returns the address of the client that made a request. When the
+// request comes from a trusted proxy, X-Forwarded-For is walked from the
+// nearest hop back, and the first address that is not a trusted proxy wins.
+// Addresses further back are set by the client and cannot be trusted.
+func clientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !isTrustedProxy(ip, trustedProxies) {
+		return host
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break // Malformed, so nothing before it can be trusted
+		}
+		ip = hop
+		if !isTrustedProxy(hop, trustedProxies) {
+			break
+		}
+	}
+
+	return ip.String()
+}
+
+// isTrustedProxy checks whether an address belongs to a trusted proxy
+func isTrustedProxy(ip net.IP, trustedProxies []*net.IPNet) bool {
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rafa-garcia/padel-alert/internal/metrics"
+	"github.com/rafa-garcia/padel-alert/internal/source"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRateLimitStorage struct {
+	mock.Mock
+}
+
+func (m *MockRateLimitStorage) Allow(ctx context.Context, key string, limit int, window time.Duration) (*storage.RateLimitResult, error) {
+	args := m.Called(ctx, key, limit, window)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*storage.RateLimitResult), args.Error(1)
+}
+
+func newRateLimitTestStorage(t *testing.T) storage.RateLimitStorage {
+	mini, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mini.Close)
+
+	return storage.NewRedisRateLimitStorage(&storage.RedisClient{Client: redis.NewClient(&redis.Options{Addr: mini.Addr()})})
+}
+
+func newRateLimitTestRouter(cfg RateLimitConfig, limit int, identify func(r *http.Request) string) *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(RateLimit(cfg, rateLimitGroupPublic, limit, identify))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		respondWithSuccess(w, "ok")
+	})
+	return r
+}
+
+func serveFrom(r http.Handler, remoteAddr string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit_RejectsOverLimit(t *testing.T) {
+	metrics.HttpRateLimited.Reset()
+	r := newRateLimitTestRouter(RateLimitConfig{Storage: newRateLimitTestStorage(t)}, 2, nil)
+
+	w := serveFrom(r, "192.0.2.1:1234", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+
+	w = serveFrom(r, "192.0.2.1:1234", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	w = serveFrom(r, "192.0.2.1:5678", nil)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Error)
+	assert.Contains(t, *resp.Error, "Rate limit exceeded")
+	assert.Equal(t, http.StatusTooManyRequests, resp.Status)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.HttpRateLimited.WithLabelValues(rateLimitGroupPublic)))
+
+	// Other clients have their own limit
+	assert.Equal(t, http.StatusOK, serveFrom(r, "192.0.2.2:1234", nil).Code)
+}
+
+func TestRateLimit_Identities(t *testing.T) {
+	cfg := RateLimitConfig{Storage: newRateLimitTestStorage(t)}
+
+	// Users behind the same address are limited separately
+	users := chi.NewRouter()
+	users.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithUserID(r.Context(), r.Header.Get("X-User"))))
+		})
+	})
+	users.Mount("/", newRateLimitTestRouter(cfg, 1, userIdentity))
+
+	assert.Equal(t, http.StatusOK, serveFrom(users, "192.0.2.1:1", http.Header{"X-User": {"user-1"}}).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serveFrom(users, "192.0.2.1:1", http.Header{"X-User": {"user-1"}}).Code)
+	assert.Equal(t, http.StatusOK, serveFrom(users, "192.0.2.1:1", http.Header{"X-User": {"user-2"}}).Code)
+
+	// API keys are limited across addresses
+	keys := newRateLimitTestRouter(cfg, 1, apiKeyIdentity)
+	assert.Equal(t, http.StatusOK, serveFrom(keys, "192.0.2.1:1", http.Header{"X-Api-Key": {"key-1"}}).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serveFrom(keys, "192.0.2.2:1", http.Header{"X-Api-Key": {"key-1"}}).Code)
+	assert.Equal(t, http.StatusOK, serveFrom(keys, "192.0.2.2:1", http.Header{"X-Api-Key": {"key-2"}}).Code)
+}
+
+func TestNewRouter_LimitsFailedAuthentication(t *testing.T) {
+	tokenStorage := new(MockTokenStorage)
+	tokenStorage.On("Authenticate", mock.Anything, mock.Anything).Return(nil, storage.ErrTokenNotFound)
+
+	rateLimits := RateLimitConfig{Storage: newRateLimitTestStorage(t), User: 100, Admin: 100, Auth: 2}
+	r := NewRouter("test", nil, nil, []string{"admin-key"}, AuthModeToken, rateLimits, new(MockRuleStorage), new(MockUserStorage), tokenStorage, new(MockNotificationStorage), new(MockSeenStorage), new(MockSchedulerControl), nil, source.Pagination{})
+
+	request := func(path string, header http.Header) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Guessing keys is cut off after the per-IP limit, even though no guess succeeds
+	wrongKey := http.Header{"X-Api-Key": {"guess"}}
+	assert.Equal(t, http.StatusUnauthorized, request("/admin/rules", wrongKey))
+	assert.Equal(t, http.StatusUnauthorized, request("/admin/rules", wrongKey))
+	assert.Equal(t, http.StatusTooManyRequests, request("/admin/rules", wrongKey))
+
+	wrongToken := http.Header{"Authorization": {"Bearer pat_guess"}}
+	assert.Equal(t, http.StatusUnauthorized, request("/api/v1/rules", wrongToken))
+	assert.Equal(t, http.StatusUnauthorized, request("/api/v1/rules", wrongToken))
+	assert.Equal(t, http.StatusTooManyRequests, request("/api/v1/rules", wrongToken))
+	tokenStorage.AssertNumberOfCalls(t, "Authenticate", 2)
+}
+
+func TestRateLimit_Disabled(t *testing.T) {
+	limiter := new(MockRateLimitStorage)
+
+	r := newRateLimitTestRouter(RateLimitConfig{Storage: limiter}, 0, nil)
+	w := serveFrom(r, "192.0.2.1:1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+
+	r = newRateLimitTestRouter(RateLimitConfig{}, 10, nil)
+	assert.Equal(t, http.StatusOK, serveFrom(r, "192.0.2.1:1", nil).Code)
+
+	limiter.AssertNotCalled(t, "Allow", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRateLimit_StorageErrorAllowsRequest(t *testing.T) {
+	limiter := new(MockRateLimitStorage)
+	limiter.On("Allow", mock.Anything, "public:ip:192.0.2.1", 5, rateLimitWindow).Return(nil, errors.New("connection refused"))
+
+	r := newRateLimitTestRouter(RateLimitConfig{Storage: limiter}, 5, nil)
+	assert.Equal(t, http.StatusOK, serveFrom(r, "192.0.2.1:1", nil).Code)
+	limiter.AssertExpectations(t)
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.10"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		forwardedFor  []string
+		expectedIP    string
+		noTrustedList bool
+	}{
+		{name: "direct client", remoteAddr: "198.51.100.7:1234", expectedIP: "198.51.100.7"},
+		{name: "spoofed header from untrusted client", remoteAddr: "198.51.100.7:1234", forwardedFor: []string{"203.0.113.1"}, expectedIP: "198.51.100.7"},
+		{name: "trusted proxy", remoteAddr: "10.1.2.3:1234", forwardedFor: []string{"203.0.113.1"}, expectedIP: "203.0.113.1"},
+		{name: "chain of trusted proxies", remoteAddr: "10.1.2.3:1234", forwardedFor: []string{"203.0.113.1, 192.0.2.10", "10.9.9.9"}, expectedIP: "203.0.113.1"},
+		{name: "client-supplied hops are ignored", remoteAddr: "10.1.2.3:1234", forwardedFor: []string{"1.1.1.1, 203.0.113.1"}, expectedIP: "203.0.113.1"},
+		{name: "malformed hop", remoteAddr: "10.1.2.3:1234", forwardedFor: []string{"203.0.113.1, nonsense, 10.9.9.9"}, expectedIP: "10.9.9.9"},
+		{name: "trusted proxy without header", remoteAddr: "10.1.2.3:1234", expectedIP: "10.1.2.3"},
+		{name: "no trusted proxies", remoteAddr: "10.1.2.3:1234", forwardedFor: []string{"203.0.113.1"}, expectedIP: "10.1.2.3", noTrustedList: true},
+		{name: "IPv6", remoteAddr: "[2001:db8::1]:1234", expectedIP: "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwardedFor {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+
+			proxies := trusted
+			if tt.noTrustedList {
+				proxies = nil
+			}
+			assert.Equal(t, tt.expectedIP, clientIP(req, proxies))
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", " 192.0.2.1 ", "2001:db8::/32", ""})
+	require.NoError(t, err)
+	require.Len(t, proxies, 3)
+	assert.Equal(t, "192.0.2.1/32", proxies[1].String())
+
+	_, err = ParseTrustedProxies([]string{"not-an-ip"})
+	assert.Error(t, err)
+	_, err = ParseTrustedProxies([]string{"10.0.0.0/99"})
+	assert.Error(t, err)
+}
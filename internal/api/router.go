@@ -6,11 +6,17 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rafa-garcia/padel-alert/internal/source"
 	"github.com/rafa-garcia/padel-alert/internal/storage"
 )
 
-// NewRouter creates a new Chi router with the configured routes
-func NewRouter(version string, apiKeys []string, ruleStorage storage.RuleStorage, userStorage storage.UserStorage) *chi.Mux {
+// NewRouter creates a new Chi router with the configured routes. The admin
+// keys protect admin routes, which are not mounted at all without any keys.
+// User routes authenticate with per-user tokens, or with the shared API keys
+// and ?user_id= in the legacy auth mode. Each route group other than the
+// health checks has its own rate limit, and the user and admin groups also
+// limit each client IP before authenticating.
+func NewRouter(version string, healthChecks []ComponentCheck, apiKeys, adminKeys []string, authMode string, rateLimits RateLimitConfig, ruleStorage storage.RuleStorage, userStorage storage.UserStorage, tokenStorage storage.TokenStorage, notificationStorage storage.NotificationStorage, seenStorage storage.SeenStorage, scheduler SchedulerControl, activitySource source.ActivitySource, pagination source.Pagination) *chi.Mux {
 	r := chi.NewRouter()
 
 	// Common middleware - order matters
@@ -25,36 +31,115 @@ func NewRouter(version string, apiKeys []string, ruleStorage storage.RuleStorage
 	// Create handlers
 	healthHandler := &HealthHandler{
 		Version: version,
+		Checks:  healthChecks,
 	}
 
-	searchHandler := NewSearchHandler()
+	searchHandler := NewSearchHandler(activitySource, pagination)
 
 	ruleHandler := NewRuleHandler(ruleStorage, userStorage)
 
-	// Public routes
+	notificationHandler := NewNotificationHandler(ruleStorage, notificationStorage)
+
+	userHandler := NewUserHandler(userStorage)
+
+	tokenHandler := NewTokenHandler(tokenStorage, userStorage)
+
+	adminHandler := NewAdminHandler(ruleStorage, seenStorage, scheduler)
+
+	// Health checks, never rate limited so that probes always get an answer
 	r.Group(func(r chi.Router) {
 		r.Get("/api/v1/health", healthHandler.HealthCheck)
+		r.Get("/api/v1/health/live", healthHandler.Live)
+		r.Get("/api/v1/health/ready", healthHandler.Ready)
+	})
+
+	// Public routes
+	r.Group(func(r chi.Router) {
+		r.Use(RateLimit(rateLimits, rateLimitGroupPublic, rateLimits.Public, nil))
+
 		r.Get("/api/v1/search", searchHandler.Search)
 	})
 
-	// Protected routes
+	// Admin routes, protected by the admin keys
 	r.Group(func(r chi.Router) {
-		r.Use(APIKeyAuth(apiKeys))
+		if len(adminKeys) == 0 {
+			return
+		}
+
+		r.Use(RateLimit(rateLimits, rateLimitGroupAdminIP, rateLimits.Auth, nil))
+		r.Use(APIKeyAuth(adminKeys))
+		r.Use(RateLimit(rateLimits, rateLimitGroupAdmin, rateLimits.Admin, apiKeyIdentity))
 
 		// Protected metrics endpoint
 		r.Handle("/metrics", promhttp.Handler()) // Prometheus metrics endpoint
 
+		r.Route("/admin", func(r chi.Router) {
+			r.Post("/users", userHandler.CreateUser)
+			r.Post("/users/{id}/tokens", tokenHandler.IssueToken)
+
+			r.Get("/rules", adminHandler.ListRules)
+			r.Post("/rules/{id}/run", adminHandler.RunRule)
+			r.Delete("/rules/{id}/seen", adminHandler.ResetSeen)
+
+			r.Route("/scheduler", func(r chi.Router) {
+				r.Get("/", adminHandler.SchedulerStatus)
+				r.Post("/pause", adminHandler.PauseScheduler)
+				r.Post("/resume", adminHandler.ResumeScheduler)
+				r.Get("/queue", adminHandler.ScheduleQueue)
+			})
+		})
+	})
+
+	// User routes
+	r.Group(func(r chi.Router) {
+		r.Use(RateLimit(rateLimits, rateLimitGroupUserIP, rateLimits.Auth, nil))
+
+		if authMode == AuthModeLegacy {
+			r.Use(APIKeyAuth(apiKeys))
+			r.Use(UserIDMiddleware) // Extract user ID from query parameter
+
+			// Callers pick the user ID, so limit the key they share instead
+			r.Use(RateLimit(rateLimits, rateLimitGroupUser, rateLimits.User, apiKeyIdentity))
+		} else {
+			r.Use(TokenAuth(tokenStorage))
+			r.Use(RateLimit(rateLimits, rateLimitGroupUser, rateLimits.User, userIdentity))
+		}
+
 		// Rules API endpoints
 		r.Route("/api/v1/rules", func(r chi.Router) {
-			r.Use(UserIDMiddleware) // Extract user ID from query parameter
 			r.Get("/", ruleHandler.ListRules)
 			r.Post("/", ruleHandler.CreateRule)
 			r.Route("/{id}", func(r chi.Router) {
 				r.Get("/", ruleHandler.GetRule)
 				r.Put("/", ruleHandler.UpdateRule)
+				r.Patch("/", ruleHandler.PatchRule)
 				r.Delete("/", ruleHandler.DeleteRule)
+				r.Post("/pause", ruleHandler.PauseRule)
+				r.Post("/resume", ruleHandler.ResumeRule)
+				r.Post("/snooze", ruleHandler.SnoozeRule)
+				r.Get("/notifications", notificationHandler.ListNotifications)
 			})
 		})
+
+		// Users API endpoints
+		r.Route("/api/v1/users", func(r chi.Router) {
+			r.Post("/", userHandler.CreateUser)
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", userHandler.GetUser)
+				r.Put("/", userHandler.UpdateUser)
+				r.Delete("/", userHandler.DeleteUser)
+			})
+		})
+
+		// Token API endpoints. In the legacy mode callers pick the user, so
+		// tokens are only issued through the admin routes.
+		if authMode != AuthModeLegacy {
+			r.Route("/api/v1/tokens", func(r chi.Router) {
+				r.Get("/", tokenHandler.ListTokens)
+				r.Post("/", tokenHandler.CreateToken)
+				r.Delete("/{id}", tokenHandler.RevokeToken)
+			})
+		}
 	})
 
 	return r
@@ -2,6 +2,8 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"mime"
 	"net/http"
 	"time"
 
@@ -10,6 +12,7 @@ import (
 	"github.com/rafa-garcia/padel-alert/internal/logger"
 	"github.com/rafa-garcia/padel-alert/internal/storage"
 	"github.com/rafa-garcia/padel-alert/internal/util"
+	"github.com/rafa-garcia/padel-alert/internal/validation"
 )
 
 // RuleHandler handles API requests for rules
@@ -31,25 +34,111 @@ type CreateRuleRequest struct {
 	Name          string   `json:"name"`
 	ClubIDs       []string `json:"club_ids"`
 	UserID        string   `json:"user_id"`
-	UserName      string   `json:"user_name"`
-	Email         string   `json:"email"`
+	UserName      string   `json:"user_name,omitempty"` // Names the user account created for a new user_id
+	Email         string   `json:"email,omitempty"`     // Email of the user account created for a new user_id
+	TelegramID    string   `json:"telegram_id,omitempty"`
+	Channels      []string `json:"channels,omitempty"`
+	WebhookURL    string   `json:"webhook_url,omitempty"`
+	PushTopic     string   `json:"push_topic,omitempty"`
 	MinRanking    *float64 `json:"min_ranking,omitempty"`
 	MaxRanking    *float64 `json:"max_ranking,omitempty"`
 	StartDate     string   `json:"start_date,omitempty"`
 	EndDate       string   `json:"end_date,omitempty"`
 	TitleContains *string  `json:"title_contains,omitempty"`
+	DaysOfWeek    []string `json:"days_of_week,omitempty"`
+	TimeOfDay     []string `json:"time_of_day,omitempty"`
+	ReAlertOn     []string `json:"re_alert_on,omitempty"`
+
+	CheckIntervalSeconds int                   `json:"check_interval_seconds,omitempty"`
+	QuietHours           *model.QuietHours     `json:"quiet_hours,omitempty"`
+	Digest               *model.DigestSchedule `json:"digest,omitempty"`
 }
 
 // UpdateRuleRequest represents a request to update an existing rule
 type UpdateRuleRequest struct {
 	Name          string     `json:"name"`
-	UserName      string     `json:"user_name"`
+	TelegramID    string     `json:"telegram_id,omitempty"`
+	Channels      []string   `json:"channels,omitempty"`
+	WebhookURL    string     `json:"webhook_url,omitempty"`
+	PushTopic     string     `json:"push_topic,omitempty"`
 	ClubIDs       []string   `json:"club_ids"`
 	MinRanking    *float64   `json:"min_ranking,omitempty"`
 	MaxRanking    *float64   `json:"max_ranking,omitempty"`
 	StartDate     *time.Time `json:"start_date,omitempty"`
 	EndDate       *time.Time `json:"end_date,omitempty"`
 	TitleContains *string    `json:"title_contains,omitempty"`
+	DaysOfWeek    []string   `json:"days_of_week,omitempty"`
+	TimeOfDay     []string   `json:"time_of_day,omitempty"`
+	ReAlertOn     []string   `json:"re_alert_on,omitempty"`
+
+	CheckIntervalSeconds int                   `json:"check_interval_seconds,omitempty"`
+	QuietHours           *model.QuietHours     `json:"quiet_hours,omitempty"`
+	Digest               *model.DigestSchedule `json:"digest,omitempty"`
+}
+
+// PatchRuleRequest is the document a PATCH request merges its patch into. It
+// holds the fields of an update, plus whether the rule is active and snoozed.
+type PatchRuleRequest struct {
+	UpdateRuleRequest
+	Active       bool       `json:"active"`
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+}
+
+// nonNullablePatchFields are the fields a patch may not clear with null,
+// since clearing them would silently set a zero value such as "active": false
+var nonNullablePatchFields = []string{"active", "name", "rule_type"}
+
+// SnoozeRuleRequest represents a request to stop checking a rule for a while
+type SnoozeRuleRequest struct {
+	Until time.Time `json:"until"`
+}
+
+// newPatchRuleRequest returns the patchable fields of a rule
+func newPatchRuleRequest(rule *model.Rule) PatchRuleRequest {
+	return PatchRuleRequest{
+		UpdateRuleRequest: UpdateRuleRequest{
+			Name:                 rule.Name,
+			TelegramID:           rule.TelegramID,
+			Channels:             rule.Channels,
+			WebhookURL:           rule.WebhookURL,
+			PushTopic:            rule.PushTopic,
+			ClubIDs:              rule.ClubIDs,
+			MinRanking:           rule.MinRanking,
+			MaxRanking:           rule.MaxRanking,
+			StartDate:            rule.StartDate,
+			EndDate:              rule.EndDate,
+			TitleContains:        rule.TitleContains,
+			DaysOfWeek:           rule.DaysOfWeek,
+			TimeOfDay:            rule.TimeOfDay,
+			ReAlertOn:            rule.ReAlertOn,
+			CheckIntervalSeconds: rule.CheckIntervalSeconds,
+			QuietHours:           rule.QuietHours,
+			Digest:               rule.Digest,
+		},
+		Active:       rule.Active,
+		SnoozedUntil: rule.SnoozedUntil,
+	}
+}
+
+// apply replaces the rule's editable fields with the request's
+func (req *UpdateRuleRequest) apply(rule *model.Rule) {
+	rule.Name = req.Name
+	rule.TelegramID = req.TelegramID
+	rule.Channels = req.Channels
+	rule.WebhookURL = req.WebhookURL
+	rule.PushTopic = req.PushTopic
+	rule.ClubIDs = req.ClubIDs
+	rule.MinRanking = req.MinRanking
+	rule.MaxRanking = req.MaxRanking
+	rule.StartDate = req.StartDate
+	rule.EndDate = req.EndDate
+	rule.TitleContains = req.TitleContains
+	rule.DaysOfWeek = req.DaysOfWeek
+	rule.TimeOfDay = req.TimeOfDay
+	rule.ReAlertOn = req.ReAlertOn
+	rule.CheckIntervalSeconds = req.CheckIntervalSeconds
+	rule.QuietHours = req.QuietHours
+	rule.Digest = req.Digest
 }
 
 // ListRules lists all rules for a user
@@ -102,6 +191,7 @@ func (h *RuleHandler) GetRule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", ruleETag(rule))
 	respondWithJSON(w, rule)
 }
 
@@ -120,61 +210,25 @@ func (h *RuleHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Name == "" || len(req.ClubIDs) == 0 {
-		respondWithError(w, "Name and club_ids are required", http.StatusBadRequest)
-		return
-	}
-
-	if req.Type != "match" && req.Type != "class" && req.Type != "lesson" {
-		respondWithError(w, "Invalid rule_type: must be 'match', 'class', or 'lesson'", http.StatusBadRequest)
-		return
-	}
-
-	if req.UserID == "" && requestUserID == "" {
-		respondWithError(w, "user_id is required", http.StatusBadRequest)
-		return
-	}
-
-	if req.Email == "" {
-		respondWithError(w, "email is required", http.StatusBadRequest)
-		return
-	}
-
 	effectiveUserID := requestUserID
 	if effectiveUserID == "" {
 		effectiveUserID = req.UserID
 	}
 
-	var startDate, endDate *time.Time
-
-	if req.StartDate != "" {
-		parsedTime, err := time.Parse("2006-01-02", req.StartDate)
-		if err != nil {
-			respondWithError(w, "Invalid start_date format. Use YYYY-MM-DD", http.StatusBadRequest)
-			return
-		}
-		startDate = &parsedTime
-	}
-
-	if req.EndDate != "" {
-		parsedTime, err := time.Parse("2006-01-02", req.EndDate)
-		if err != nil {
-			respondWithError(w, "Invalid end_date format. Use YYYY-MM-DD", http.StatusBadRequest)
-			return
-		}
-		endDate = &parsedTime
-	}
-
-	// Check for username
-	if req.UserName == "" {
-		req.UserName = effectiveUserID // Use user ID as fallback
+	var errs validation.Errors
+	startDate := parseDate(&errs, "start_date", req.StartDate)
+	endDate := parseDate(&errs, "end_date", req.EndDate)
+	if req.Email != "" && !validation.IsEmail(req.Email) {
+		errs.Add("email", validation.CodeInvalid, "must be a valid email address")
 	}
 
 	rule := &model.Rule{
 		ID:            util.GenerateID(),
 		UserID:        effectiveUserID,
-		UserName:      req.UserName,
-		Email:         req.Email,
+		TelegramID:    req.TelegramID,
+		Channels:      req.Channels,
+		WebhookURL:    req.WebhookURL,
+		PushTopic:     req.PushTopic,
 		Type:          req.Type,
 		Name:          req.Name,
 		ClubIDs:       req.ClubIDs,
@@ -183,9 +237,26 @@ func (h *RuleHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
 		StartDate:     startDate,
 		EndDate:       endDate,
 		TitleContains: req.TitleContains,
+		DaysOfWeek:    req.DaysOfWeek,
+		TimeOfDay:     req.TimeOfDay,
+		ReAlertOn:     req.ReAlertOn,
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 		Active:        true, // Set rules to active by default
+
+		CheckIntervalSeconds: req.CheckIntervalSeconds,
+		QuietHours:           req.QuietHours,
+		Digest:               req.Digest,
+	}
+
+	errs = append(errs, validation.ValidateRule(rule)...)
+	if len(errs) > 0 {
+		respondWithValidationErrors(w, errs)
+		return
+	}
+
+	if !h.ensureUser(w, r, effectiveUserID, req.UserName, req.Email) {
+		return
 	}
 
 	if err := h.ruleStorage.CreateRule(r.Context(), rule); err != nil {
@@ -199,98 +270,291 @@ func (h *RuleHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
 		// Don't return error to client, just log it
 	}
 
+	w.Header().Set("ETag", ruleETag(rule))
 	w.WriteHeader(http.StatusCreated)
 	respondWithJSON(w, rule)
 }
 
-// UpdateRule updates an existing rule
+// UpdateRule replaces the settings of an existing rule
 func (h *RuleHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
-	contextUserID, ok := GetUserID(r.Context())
+	var req UpdateRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rule, ok := h.ownedRule(w, r, "update")
 	if !ok {
-		respondWithError(w, "User ID not found in request context", http.StatusUnauthorized)
 		return
 	}
 
-	userID := contextUserID
+	req.apply(rule)
+	rule.UpdatedAt = time.Now()
 
-	ruleID := chi.URLParam(r, "id")
-	if ruleID == "" {
-		respondWithError(w, "Rule ID is required", http.StatusBadRequest)
+	if errs := validation.ValidateRule(rule); len(errs) > 0 {
+		respondWithValidationErrors(w, errs)
 		return
 	}
 
-	var req UpdateRuleRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+	h.saveRule(w, r, rule, false)
+}
+
+// PatchRule updates some fields of a rule with a JSON merge patch (RFC 7396).
+// Fields left out of the patch keep their value and fields set to null are
+// cleared. The patch may also pause, resume or snooze the rule.
+func (h *RuleHandler) PatchRule(w http.ResponseWriter, r *http.Request) {
+	if contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); contentType != "" &&
+		contentType != "application/merge-patch+json" && contentType != "application/json" {
+		respondWithError(w, "Content-Type must be application/merge-patch+json", http.StatusUnsupportedMediaType)
 		return
 	}
 
-	rule, err := h.ruleStorage.GetRule(r.Context(), ruleID)
+	var patch map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		respondWithError(w, "Invalid patch: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rule, ok := h.ownedRule(w, r, "update")
+	if !ok {
+		return
+	}
+
+	var nullErrs validation.Errors
+	for _, field := range nonNullablePatchFields {
+		if value, ok := patch[field]; ok && value == nil {
+			nullErrs.Add(field, validation.CodeRequired, "must not be null")
+		}
+	}
+	if len(nullErrs) > 0 {
+		respondWithFieldErrors(w, nullErrs, http.StatusUnprocessableEntity)
+		return
+	}
+
+	current, err := toJSONObject(newPatchRuleRequest(rule))
 	if err != nil {
-		logger.Error("Failed to get rule", err, "rule_id", ruleID)
-		respondWithError(w, "Rule not found", http.StatusNotFound)
+		logger.Error("Failed to encode rule", err, "rule_id", rule.ID)
+		respondWithError(w, "Failed to update rule", http.StatusInternalServerError)
 		return
 	}
 
-	if rule.UserID != userID {
-		respondWithError(w, "Not authorized to update this rule", http.StatusForbidden)
+	var req PatchRuleRequest
+	if err := decodeStrict(mergePatch(current, patch), &req); err != nil {
+		respondWithError(w, "Invalid patch: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	rule.Name = req.Name
-	if req.UserName != "" {
-		rule.UserName = req.UserName
+	now := time.Now()
+	wasChecked := rule.Active && !rule.IsSnoozed(now)
+
+	req.apply(rule)
+	rule.Active = req.Active
+	rule.SnoozedUntil = req.SnoozedUntil
+	rule.UpdatedAt = now
+
+	errs := validation.ValidateRule(rule)
+	if _, changed := patch["snoozed_until"]; changed && rule.SnoozedUntil != nil {
+		errs = append(errs, validation.ValidateSnooze("snoozed_until", *rule.SnoozedUntil, now)...)
 	}
-	rule.ClubIDs = req.ClubIDs
-	rule.MinRanking = req.MinRanking
-	rule.MaxRanking = req.MaxRanking
-	rule.StartDate = req.StartDate
-	rule.EndDate = req.EndDate
-	rule.TitleContains = req.TitleContains
+	if len(errs) > 0 {
+		respondWithValidationErrors(w, errs)
+		return
+	}
+
+	// A rule that was paused or snoozed is checked straight away once it resumes
+	h.saveRule(w, r, rule, !wasChecked && rule.Active && !rule.IsSnoozed(now))
+}
+
+// PauseRule stops checking a rule until it is resumed
+func (h *RuleHandler) PauseRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.ownedRule(w, r, "update")
+	if !ok {
+		return
+	}
+
+	rule.Active = false
 	rule.UpdatedAt = time.Now()
 
-	if err := h.ruleStorage.UpdateRule(r.Context(), rule); err != nil {
-		logger.Error("Failed to update rule", err, "rule_id", ruleID)
-		respondWithError(w, "Failed to update rule", http.StatusInternalServerError)
+	h.saveRule(w, r, rule, false)
+}
+
+// ResumeRule checks a paused or snoozed rule again, starting straight away
+func (h *RuleHandler) ResumeRule(w http.ResponseWriter, r *http.Request) {
+	rule, ok := h.ownedRule(w, r, "update")
+	if !ok {
 		return
 	}
 
-	respondWithJSON(w, rule)
+	rule.Active = true
+	rule.SnoozedUntil = nil
+	rule.UpdatedAt = time.Now()
+
+	h.saveRule(w, r, rule, true)
+}
+
+// SnoozeRule stops checking a rule until a given time. The rule stays
+// scheduled and is checked again once the snooze ends.
+func (h *RuleHandler) SnoozeRule(w http.ResponseWriter, r *http.Request) {
+	var req SnoozeRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if errs := validation.ValidateSnooze("until", req.Until, now); len(errs) > 0 {
+		respondWithValidationErrors(w, errs)
+		return
+	}
+
+	rule, ok := h.ownedRule(w, r, "update")
+	if !ok {
+		return
+	}
+
+	rule.SnoozedUntil = &req.Until
+	rule.UpdatedAt = now
+
+	h.saveRule(w, r, rule, false)
 }
 
 // DeleteRule deletes a rule
 func (h *RuleHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
-	contextUserID, ok := GetUserID(r.Context())
+	rule, ok := h.ownedRule(w, r, "delete")
 	if !ok {
-		respondWithError(w, "User ID not found in request context", http.StatusUnauthorized)
 		return
 	}
 
-	userID := contextUserID
+	if err := h.ruleStorage.DeleteRule(r.Context(), rule.ID); err != nil {
+		logger.Error("Failed to delete rule", err, "rule_id", rule.ID)
+		respondWithError(w, "Failed to delete rule", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithSuccess(w, "Rule deleted successfully")
+}
+
+// ensureUser makes sure a new rule's user has an account. Rules reference
+// their user rather than copying contact details, so the first rule for a
+// user ID without an account creates one from the name and email in the
+// request. It responds with an error and returns false on failure.
+func (h *RuleHandler) ensureUser(w http.ResponseWriter, r *http.Request, userID, name, email string) bool {
+	_, err := h.userStorage.GetUser(r.Context(), userID)
+	if err == nil {
+		return true
+	}
+	if !errors.Is(err, storage.ErrUserNotFound) {
+		logger.Error("Failed to get user", err, "user_id", userID)
+		respondWithError(w, "Failed to get user", http.StatusInternalServerError)
+		return false
+	}
+
+	user := &model.User{ID: userID, Name: name, Email: email}
+	if errs := validateUser(user); len(errs) > 0 {
+		respondWithValidationErrors(w, errs)
+		return false
+	}
+
+	if taken, err := emailTaken(r.Context(), h.userStorage, user); err != nil {
+		logger.Error("Failed to look up user by email", err)
+		respondWithError(w, "Failed to create user", http.StatusInternalServerError)
+		return false
+	} else if taken {
+		respondWithError(w, "email is already used by another user", http.StatusConflict)
+		return false
+	}
+
+	if err := h.userStorage.CreateUser(r.Context(), user); err != nil {
+		logger.Error("Failed to create user", err, "user_id", userID)
+		respondWithError(w, "Failed to create user", http.StatusInternalServerError)
+		return false
+	}
+
+	return true
+}
+
+// parseDate parses an optional YYYY-MM-DD date, recording an error for field
+// if it is malformed
+func parseDate(errs *validation.Errors, field, value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		errs.Add(field, validation.CodeInvalid, "must be a date in YYYY-MM-DD format")
+		return nil
+	}
+	return &parsed
+}
+
+// ownedRule returns the rule in the URL, responding with an error and
+// returning false unless it exists, belongs to the authenticated user and
+// matches the request's If-Match header
+func (h *RuleHandler) ownedRule(w http.ResponseWriter, r *http.Request, action string) (*model.Rule, bool) {
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		respondWithError(w, "User ID not found in request context", http.StatusUnauthorized)
+		return nil, false
+	}
 
 	ruleID := chi.URLParam(r, "id")
 	if ruleID == "" {
 		respondWithError(w, "Rule ID is required", http.StatusBadRequest)
-		return
+		return nil, false
 	}
 
 	rule, err := h.ruleStorage.GetRule(r.Context(), ruleID)
 	if err != nil {
 		logger.Error("Failed to get rule", err, "rule_id", ruleID)
 		respondWithError(w, "Rule not found", http.StatusNotFound)
-		return
+		return nil, false
 	}
 
 	if rule.UserID != userID {
-		respondWithError(w, "Not authorized to delete this rule", http.StatusForbidden)
-		return
+		respondWithError(w, "Not authorized to "+action+" this rule", http.StatusForbidden)
+		return nil, false
 	}
 
-	if err := h.ruleStorage.DeleteRule(r.Context(), ruleID); err != nil {
-		logger.Error("Failed to delete rule", err, "rule_id", ruleID)
-		respondWithError(w, "Failed to delete rule", http.StatusInternalServerError)
+	if !ifMatch(r, rule) {
+		w.Header().Set("ETag", ruleETag(rule))
+		respondWithError(w, "Rule has changed, fetch it again before retrying", http.StatusPreconditionFailed)
+		return nil, false
+	}
+
+	return rule, true
+}
+
+// saveRule stores an updated rule and responds with it and its new ETag. Rules
+// that should be checked straight away, such as resumed rules, are
+// rescheduled for now.
+func (h *RuleHandler) saveRule(w http.ResponseWriter, r *http.Request, rule *model.Rule, checkNow bool) {
+	err := h.ruleStorage.UpdateRule(r.Context(), rule)
+	switch {
+	case errors.Is(err, storage.ErrRuleVersionConflict):
+		// Changed by another request since it was read
+		status := http.StatusConflict
+		if r.Header.Get("If-Match") != "" {
+			status = http.StatusPreconditionFailed
+		}
+		respondWithError(w, "Rule has changed, fetch it again before retrying", status)
+		return
+	case errors.Is(err, storage.ErrRuleNotFound):
+		respondWithError(w, "Rule not found", http.StatusNotFound)
+		return
+	case err != nil:
+		logger.Error("Failed to update rule", err, "rule_id", rule.ID)
+		respondWithError(w, "Failed to update rule", http.StatusInternalServerError)
 		return
 	}
 
-	respondWithSuccess(w, "Rule deleted successfully")
+	if checkNow {
+		if err := h.ruleStorage.ScheduleRule(r.Context(), rule.ID, time.Now()); err != nil {
+			logger.Error("Failed to schedule rule", err, "rule_id", rule.ID)
+			// The rule is still checked at its previous next run
+		}
+	}
+
+	w.Header().Set("ETag", ruleETag(rule))
+	respondWithJSON(w, rule)
 }
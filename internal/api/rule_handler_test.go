@@ -4,15 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
+	"github.com/rafa-garcia/padel-alert/internal/validation"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockRuleStorage struct {
@@ -45,6 +50,11 @@ func (m *MockRuleStorage) UpdateRule(ctx context.Context, rule *model.Rule) erro
 	return args.Error(0)
 }
 
+func (m *MockRuleStorage) UpdateRuleState(ctx context.Context, ruleID string, state model.RuleState) error {
+	args := m.Called(ctx, ruleID, state)
+	return args.Error(0)
+}
+
 func (m *MockRuleStorage) DeleteRule(ctx context.Context, ruleID string) error {
 	args := m.Called(ctx, ruleID)
 	return args.Error(0)
@@ -60,6 +70,44 @@ func (m *MockRuleStorage) GetScheduledRules(ctx context.Context, until time.Time
 	return args.Get(0).([]string), args.Error(1)
 }
 
+func (m *MockRuleStorage) ClaimScheduledRules(ctx context.Context, now time.Time, owner string, lease time.Duration, limit int) ([]storage.ScheduledRule, error) {
+	args := m.Called(ctx, now, owner, lease, limit)
+	return args.Get(0).([]storage.ScheduledRule), args.Error(1)
+}
+
+func (m *MockRuleStorage) CompleteScheduledRule(ctx context.Context, ruleID, owner string, nextRun time.Time) (bool, error) {
+	args := m.Called(ctx, ruleID, owner, nextRun)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRuleStorage) OldestScheduledRule(ctx context.Context) (*storage.ScheduledRule, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*storage.ScheduledRule), args.Error(1)
+}
+
+func (m *MockRuleStorage) ClaimRule(ctx context.Context, ruleID, owner string, lease time.Duration) error {
+	args := m.Called(ctx, ruleID, owner, lease)
+	return args.Error(0)
+}
+
+func (m *MockRuleStorage) RenewRuleLease(ctx context.Context, ruleID, owner string, lease time.Duration) (bool, error) {
+	args := m.Called(ctx, ruleID, owner, lease)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRuleStorage) ListAllRules(ctx context.Context) ([]*model.Rule, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*model.Rule), args.Error(1)
+}
+
+func (m *MockRuleStorage) ListScheduledRules(ctx context.Context, offset, limit int) ([]storage.ScheduledRule, int64, error) {
+	args := m.Called(ctx, offset, limit)
+	return args.Get(0).([]storage.ScheduledRule), args.Get(1).(int64), args.Error(2)
+}
+
 type MockUserStorage struct {
 	mock.Mock
 }
@@ -90,6 +138,11 @@ func (m *MockUserStorage) GetUserByEmail(ctx context.Context, email string) (*mo
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
+func (m *MockUserStorage) DeleteUser(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
 func TestRuleHandler_ListRules(t *testing.T) {
 	ruleStorage := new(MockRuleStorage)
 	userStorage := new(MockUserStorage)
@@ -180,10 +233,18 @@ func TestRuleHandler_CreateRule(t *testing.T) {
 
 	body, _ := json.Marshal(createReq)
 
+	// The first rule for a user creates their account from the inline details
+	userStorage.On("GetUser", mock.Anything, userID).Return(nil, storage.ErrUserNotFound)
+	userStorage.On("GetUserByEmail", mock.Anything, "test@example.com").Return(nil, storage.ErrUserNotFound)
+	userStorage.On("CreateUser", mock.Anything, mock.MatchedBy(func(user *model.User) bool {
+		return user.ID == userID && user.Name == "Test User" && user.Email == "test@example.com"
+	})).Return(nil)
+
 	ruleStorage.On("CreateRule", mock.Anything, mock.MatchedBy(func(rule *model.Rule) bool {
 		return rule.Name == createReq.Name &&
 			rule.UserID == userID &&
-			rule.UserName == createReq.UserName
+			rule.Email == "" &&
+			rule.UserName == ""
 	})).Return(nil)
 
 	ruleStorage.On("ScheduleRule", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
@@ -198,6 +259,582 @@ func TestRuleHandler_CreateRule(t *testing.T) {
 	assert.Equal(t, http.StatusCreated, w.Code)
 
 	ruleStorage.AssertExpectations(t)
+	userStorage.AssertExpectations(t)
+}
+
+func TestRuleHandler_CreateRule_ExistingUser(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	userStorage := new(MockUserStorage)
+	handler := NewRuleHandler(ruleStorage, userStorage)
+
+	userID := "test-user-123"
+
+	// Users with an account don't need to repeat their email
+	body, _ := json.Marshal(CreateRuleRequest{
+		Type:    "match",
+		Name:    "Test Rule",
+		ClubIDs: []string{"club-1"},
+	})
+
+	userStorage.On("GetUser", mock.Anything, userID).Return(&model.User{ID: userID, Email: "test@example.com"}, nil)
+	ruleStorage.On("CreateRule", mock.Anything, mock.MatchedBy(func(rule *model.Rule) bool {
+		return rule.UserID == userID
+	})).Return(nil)
+	ruleStorage.On("ScheduleRule", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
+
+	req := httptest.NewRequest("POST", "/api/v1/rules", bytes.NewReader(body))
+	req = req.WithContext(WithUserID(req.Context(), userID))
+
+	w := httptest.NewRecorder()
+	handler.CreateRule(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	ruleStorage.AssertExpectations(t)
+	userStorage.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+}
+
+func TestRuleHandler_CreateRule_NewUserWithoutEmail(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	userStorage := new(MockUserStorage)
+	handler := NewRuleHandler(ruleStorage, userStorage)
+
+	body, _ := json.Marshal(CreateRuleRequest{
+		Type:    "match",
+		Name:    "Test Rule",
+		ClubIDs: []string{"club-1"},
+	})
+
+	userStorage.On("GetUser", mock.Anything, "test-user-123").Return(nil, storage.ErrUserNotFound)
+
+	req := httptest.NewRequest("POST", "/api/v1/rules", bytes.NewReader(body))
+	req = req.WithContext(WithUserID(req.Context(), "test-user-123"))
+
+	w := httptest.NewRecorder()
+	handler.CreateRule(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	ruleStorage.AssertNotCalled(t, "CreateRule", mock.Anything, mock.Anything)
+}
+
+func TestRuleHandler_CreateRule_InvalidSchedule(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	userStorage := new(MockUserStorage)
+	handler := NewRuleHandler(ruleStorage, userStorage)
+
+	createReq := CreateRuleRequest{
+		Type:       "match",
+		Name:       "Test Rule",
+		ClubIDs:    []string{"club-1"},
+		Email:      "test@example.com",
+		DaysOfWeek: []string{"monday", "someday"},
+	}
+
+	body, _ := json.Marshal(createReq)
+
+	req := httptest.NewRequest("POST", "/api/v1/rules", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(WithUserID(req.Context(), "test-user-123"))
+
+	w := httptest.NewRecorder()
+	handler.CreateRule(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	ruleStorage.AssertNotCalled(t, "CreateRule", mock.Anything, mock.Anything)
+}
+
+func TestRuleHandler_CreateRule_InvalidReAlertOn(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	userStorage := new(MockUserStorage)
+	handler := NewRuleHandler(ruleStorage, userStorage)
+
+	createReq := CreateRuleRequest{
+		Type:      "match",
+		Name:      "Test Rule",
+		ClubIDs:   []string{"club-1"},
+		Email:     "test@example.com",
+		ReAlertOn: []string{"spot_reopened", "weather_changed"},
+	}
+
+	body, _ := json.Marshal(createReq)
+
+	req := httptest.NewRequest("POST", "/api/v1/rules", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(WithUserID(req.Context(), "test-user-123"))
+
+	w := httptest.NewRecorder()
+	handler.CreateRule(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "re_alert_on")
+	ruleStorage.AssertNotCalled(t, "CreateRule", mock.Anything, mock.Anything)
+}
+
+func TestRuleHandler_CreateRule_InvalidCheckSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  CreateRuleRequest
+		expected string
+	}{
+		{
+			name:     "Interval too short",
+			request:  CreateRuleRequest{CheckIntervalSeconds: 10},
+			expected: "check_interval_seconds",
+		},
+		{
+			name:     "Bad quiet hours",
+			request:  CreateRuleRequest{QuietHours: &model.QuietHours{Start: "23:00", End: "8am"}},
+			expected: "quiet_hours",
+		},
+		{
+			name:     "Unknown digest mode",
+			request:  CreateRuleRequest{Digest: &model.DigestSchedule{Mode: "monthly"}},
+			expected: "digest",
+		},
+		{
+			name:     "Daily digest without time",
+			request:  CreateRuleRequest{Digest: &model.DigestSchedule{Mode: model.DigestDaily}},
+			expected: "digest",
+		},
+		{
+			name:     "Digest without email channel",
+			request:  CreateRuleRequest{Digest: &model.DigestSchedule{Mode: model.DigestHourly}, Channels: []string{"push"}, PushTopic: "topic"},
+			expected: "digest",
+		},
+		{
+			name:     "Unknown timezone",
+			request:  CreateRuleRequest{QuietHours: &model.QuietHours{Start: "23:00", End: "08:00", Timezone: "Nowhere/Town"}},
+			expected: "quiet_hours",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ruleStorage := new(MockRuleStorage)
+			userStorage := new(MockUserStorage)
+			handler := NewRuleHandler(ruleStorage, userStorage)
+
+			createReq := tt.request
+			createReq.Type = "match"
+			createReq.Name = "Test Rule"
+			createReq.ClubIDs = []string{"club-1"}
+			createReq.Email = "test@example.com"
+
+			body, _ := json.Marshal(createReq)
+
+			req := httptest.NewRequest("POST", "/api/v1/rules", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(WithUserID(req.Context(), "test-user-123"))
+
+			w := httptest.NewRecorder()
+			handler.CreateRule(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expected)
+			ruleStorage.AssertNotCalled(t, "CreateRule", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRuleHandler_CreateRule_ReportsEveryError(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	userStorage := new(MockUserStorage)
+	handler := NewRuleHandler(ruleStorage, userStorage)
+
+	minRanking, maxRanking := -1.0, 3.0
+	body, _ := json.Marshal(CreateRuleRequest{
+		Type:       "match",
+		Name:       "Test Rule",
+		ClubIDs:    []string{"club-1", ""},
+		Email:      "not-an-email",
+		MinRanking: &minRanking,
+		MaxRanking: &maxRanking,
+		StartDate:  "2030-06-10",
+		EndDate:    "2030-06-01",
+	})
+
+	req := httptest.NewRequest("POST", "/api/v1/rules", bytes.NewReader(body))
+	req = req.WithContext(WithUserID(req.Context(), "test-user-123"))
+
+	w := httptest.NewRecorder()
+	handler.CreateRule(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	codes := make(map[string]string, len(resp.Errors))
+	for _, err := range resp.Errors {
+		codes[err.Field] = err.Code
+		assert.NotEmpty(t, err.Message, err.Field)
+	}
+	assert.Equal(t, map[string]string{
+		"email":       validation.CodeInvalid,
+		"club_ids[1]": validation.CodeRequired,
+		"min_ranking": validation.CodeOutOfRange,
+		"end_date":    validation.CodeInvalidRange,
+	}, codes)
+
+	ruleStorage.AssertNotCalled(t, "CreateRule", mock.Anything, mock.Anything)
+	userStorage.AssertNotCalled(t, "GetUser", mock.Anything, mock.Anything)
+}
+
+func TestRuleHandler_UpdateRule_Invalid(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	userStorage := new(MockUserStorage)
+	handler := NewRuleHandler(ruleStorage, userStorage)
+
+	ruleStorage.On("GetRule", mock.Anything, "rule-1").Return(&model.Rule{
+		ID:      "rule-1",
+		UserID:  "test-user-123",
+		Type:    "match",
+		Name:    "Test Rule",
+		ClubIDs: []string{"club-1"},
+	}, nil)
+
+	minRanking, maxRanking := 5.0, 3.0
+	body, _ := json.Marshal(UpdateRuleRequest{
+		Name:       "Test Rule",
+		ClubIDs:    []string{"club-1", "club-1"},
+		MinRanking: &minRanking,
+		MaxRanking: &maxRanking,
+	})
+
+	req := httptest.NewRequest("PUT", "/api/v1/rules/rule-1", bytes.NewReader(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "rule-1")
+	req = req.WithContext(context.WithValue(WithUserID(req.Context(), "test-user-123"), chi.RouteCtxKey, rctx))
+
+	w := httptest.NewRecorder()
+	handler.UpdateRule(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []validation.FieldError{
+		{Field: "club_ids[1]", Code: validation.CodeDuplicate, Message: `club "club-1" is listed more than once`},
+		{Field: "max_ranking", Code: validation.CodeInvalidRange, Message: "must not be less than min_ranking"},
+	}, resp.Errors)
+
+	ruleStorage.AssertNotCalled(t, "UpdateRule", mock.Anything, mock.Anything)
+}
+
+func newRuleTestRouter(handler *RuleHandler) *chi.Mux {
+	r := chi.NewRouter()
+	r.Get("/{id}", handler.GetRule)
+	r.Put("/{id}", handler.UpdateRule)
+	r.Patch("/{id}", handler.PatchRule)
+	r.Delete("/{id}", handler.DeleteRule)
+	r.Post("/{id}/pause", handler.PauseRule)
+	r.Post("/{id}/resume", handler.ResumeRule)
+	r.Post("/{id}/snooze", handler.SnoozeRule)
+	return r
+}
+
+func patchTestRule() *model.Rule {
+	minRanking, maxRanking := 2.5, 4.0
+	title := "beginner"
+	return &model.Rule{
+		ID:            "rule-1",
+		UserID:        "test-user-123",
+		Type:          "match",
+		Name:          "Test Rule",
+		ClubIDs:       []string{"club-1", "club-2"},
+		MinRanking:    &minRanking,
+		MaxRanking:    &maxRanking,
+		TitleContains: &title,
+		QuietHours:    &model.QuietHours{Start: "23:00", End: "08:00", Timezone: "Europe/Madrid"},
+		Active:        true,
+	}
+}
+
+func TestRuleHandler_PatchRule(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	r := newRuleTestRouter(NewRuleHandler(ruleStorage, new(MockUserStorage)))
+
+	ruleStorage.On("GetRule", mock.Anything, "rule-1").Return(patchTestRule(), nil)
+	ruleStorage.On("UpdateRule", mock.Anything, mock.Anything).Return(nil)
+
+	w := serveUserRequest(r, http.MethodPatch, "/rule-1", "test-user-123", map[string]any{
+		"name":           "Renamed",
+		"title_contains": nil,
+		"quiet_hours":    map[string]any{"end": "07:00"},
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	updated := ruleStorage.Calls[1].Arguments.Get(1).(*model.Rule)
+	assert.Equal(t, "Renamed", updated.Name)
+	assert.Nil(t, updated.TitleContains, "null clears a field")
+	assert.Equal(t, []string{"club-1", "club-2"}, updated.ClubIDs, "fields left out keep their value")
+	assert.Equal(t, 2.5, *updated.MinRanking)
+	assert.Equal(t, 4.0, *updated.MaxRanking)
+	assert.Equal(t, &model.QuietHours{Start: "23:00", End: "07:00", Timezone: "Europe/Madrid"}, updated.QuietHours, "objects are merged")
+	assert.True(t, updated.Active)
+	ruleStorage.AssertNotCalled(t, "ScheduleRule", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRuleHandler_PatchRule_Activation(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	r := newRuleTestRouter(NewRuleHandler(ruleStorage, new(MockUserStorage)))
+
+	paused := patchTestRule()
+	paused.Active = false
+	ruleStorage.On("GetRule", mock.Anything, "rule-1").Return(paused, nil)
+	ruleStorage.On("UpdateRule", mock.Anything, paused).Return(nil)
+	ruleStorage.On("ScheduleRule", mock.Anything, "rule-1", mock.AnythingOfType("time.Time")).Return(nil)
+
+	w := serveUserRequest(r, http.MethodPatch, "/rule-1", "test-user-123", map[string]any{"active": true})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, paused.Active)
+	ruleStorage.AssertExpectations(t)
+}
+
+func TestRuleHandler_PatchRule_NullFields(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	r := newRuleTestRouter(NewRuleHandler(ruleStorage, new(MockUserStorage)))
+	ruleStorage.On("GetRule", mock.Anything, "rule-1").Return(patchTestRule(), nil)
+
+	// Null would otherwise decode to false and pause the rule
+	w := serveUserRequest(r, http.MethodPatch, "/rule-1", "test-user-123", map[string]any{
+		"active":         nil,
+		"name":           nil,
+		"rule_type":      nil,
+		"title_contains": nil,
+	})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []validation.FieldError{
+		{Field: "active", Code: validation.CodeRequired, Message: "must not be null"},
+		{Field: "name", Code: validation.CodeRequired, Message: "must not be null"},
+		{Field: "rule_type", Code: validation.CodeRequired, Message: "must not be null"},
+	}, resp.Errors)
+	ruleStorage.AssertNotCalled(t, "UpdateRule", mock.Anything, mock.Anything)
+}
+
+func TestRuleHandler_PatchRule_Rejected(t *testing.T) {
+	tests := []struct {
+		name         string
+		userID       string
+		contentType  string
+		patch        any
+		expectedCode int
+	}{
+		{
+			name:         "Read-only field",
+			userID:       "test-user-123",
+			patch:        map[string]any{"user_id": "someone-else"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Wrong type",
+			userID:       "test-user-123",
+			patch:        map[string]any{"min_ranking": "high"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid result",
+			userID:       "test-user-123",
+			patch:        map[string]any{"max_ranking": 1.0, "club_ids": nil},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Snooze in the past",
+			userID:       "test-user-123",
+			patch:        map[string]any{"snoozed_until": "2020-01-01T00:00:00Z"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Not a JSON object",
+			userID:       "test-user-123",
+			patch:        []string{"name"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unsupported content type",
+			userID:       "test-user-123",
+			contentType:  "application/json-patch+json",
+			patch:        map[string]any{"name": "Renamed"},
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:         "Someone else's rule",
+			userID:       "other-user",
+			patch:        map[string]any{"name": "Renamed"},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ruleStorage := new(MockRuleStorage)
+			r := newRuleTestRouter(NewRuleHandler(ruleStorage, new(MockUserStorage)))
+			ruleStorage.On("GetRule", mock.Anything, "rule-1").Return(patchTestRule(), nil)
+
+			body, _ := json.Marshal(tt.patch)
+			req := httptest.NewRequest(http.MethodPatch, "/rule-1", bytes.NewReader(body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			req = req.WithContext(WithUserID(req.Context(), tt.userID))
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			ruleStorage.AssertNotCalled(t, "UpdateRule", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRuleHandler_PauseResumeRule(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	r := newRuleTestRouter(NewRuleHandler(ruleStorage, new(MockUserStorage)))
+
+	rule := patchTestRule()
+	snoozedUntil := time.Now().Add(time.Hour)
+	rule.SnoozedUntil = &snoozedUntil
+	ruleStorage.On("GetRule", mock.Anything, "rule-1").Return(rule, nil)
+	ruleStorage.On("UpdateRule", mock.Anything, rule).Return(nil)
+
+	w := serveUserRequest(r, http.MethodPost, "/rule-1/pause", "test-user-123", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, rule.Active)
+	ruleStorage.AssertNotCalled(t, "ScheduleRule", mock.Anything, mock.Anything, mock.Anything)
+
+	// Resuming also ends the snooze and checks the rule straight away
+	ruleStorage.On("ScheduleRule", mock.Anything, "rule-1", mock.AnythingOfType("time.Time")).Return(nil)
+
+	w = serveUserRequest(r, http.MethodPost, "/rule-1/resume", "test-user-123", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, rule.Active)
+	assert.Nil(t, rule.SnoozedUntil)
+	ruleStorage.AssertExpectations(t)
+
+	w = serveUserRequest(r, http.MethodPost, "/rule-1/pause", "other-user", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	ruleStorage.AssertNumberOfCalls(t, "UpdateRule", 2)
+}
+
+func TestRuleHandler_SnoozeRule(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	r := newRuleTestRouter(NewRuleHandler(ruleStorage, new(MockUserStorage)))
+
+	rule := patchTestRule()
+	ruleStorage.On("GetRule", mock.Anything, "rule-1").Return(rule, nil)
+	ruleStorage.On("UpdateRule", mock.Anything, rule).Return(nil)
+
+	until := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	w := serveUserRequest(r, http.MethodPost, "/rule-1/snooze", "test-user-123", SnoozeRuleRequest{Until: until})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, rule.SnoozedUntil)
+	assert.True(t, until.Equal(*rule.SnoozedUntil))
+	assert.True(t, rule.Active, "snoozing does not pause the rule")
+	ruleStorage.AssertNotCalled(t, "ScheduleRule", mock.Anything, mock.Anything, mock.Anything)
+
+	for _, until := range []time.Time{{}, time.Now().Add(-time.Minute), time.Now().AddDate(2, 0, 0)} {
+		w = serveUserRequest(r, http.MethodPost, "/rule-1/snooze", "test-user-123", SnoozeRuleRequest{Until: until})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"until"`)
+	}
+	ruleStorage.AssertNumberOfCalls(t, "UpdateRule", 1)
+}
+
+func TestRuleHandler_ETag(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	r := newRuleTestRouter(NewRuleHandler(ruleStorage, new(MockUserStorage)))
+
+	rule := patchTestRule()
+	rule.Version = 7
+	ruleStorage.On("GetRule", mock.Anything, "rule-1").Return(rule, nil)
+
+	w := serveUserRequest(r, http.MethodGet, "/rule-1", "test-user-123", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"7"`, w.Header().Get("ETag"))
+
+	// Updates respond with the ETag of the new version
+	ruleStorage.On("UpdateRule", mock.Anything, rule).Run(func(args mock.Arguments) {
+		args.Get(1).(*model.Rule).Version++
+	}).Return(nil).Once()
+
+	req := httptest.NewRequest(http.MethodPatch, "/rule-1", strings.NewReader(`{"name":"Renamed"}`))
+	req.Header.Set("If-Match", `"6", "7"`)
+	req = req.WithContext(WithUserID(req.Context(), "test-user-123"))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"8"`, w.Header().Get("ETag"))
+}
+
+func TestRuleHandler_IfMatch(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		ifMatch      string
+		expectedCode int
+	}{
+		{name: "Stale update", method: http.MethodPut, path: "/rule-1", body: `{"name":"Renamed","club_ids":["club-1"]}`, ifMatch: `"2"`, expectedCode: http.StatusPreconditionFailed},
+		{name: "Stale patch", method: http.MethodPatch, path: "/rule-1", body: `{"name":"Renamed"}`, ifMatch: `"2"`, expectedCode: http.StatusPreconditionFailed},
+		{name: "Stale pause", method: http.MethodPost, path: "/rule-1/pause", ifMatch: `"2"`, expectedCode: http.StatusPreconditionFailed},
+		{name: "Stale delete", method: http.MethodDelete, path: "/rule-1", ifMatch: `"2"`, expectedCode: http.StatusPreconditionFailed},
+		{name: "Weak tag", method: http.MethodPatch, path: "/rule-1", body: `{"name":"Renamed"}`, ifMatch: `W/"3"`, expectedCode: http.StatusPreconditionFailed},
+		{name: "Current version", method: http.MethodPatch, path: "/rule-1", body: `{"name":"Renamed"}`, ifMatch: `"3"`, expectedCode: http.StatusOK},
+		{name: "Any version", method: http.MethodDelete, path: "/rule-1", ifMatch: "*", expectedCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ruleStorage := new(MockRuleStorage)
+			r := newRuleTestRouter(NewRuleHandler(ruleStorage, new(MockUserStorage)))
+
+			rule := patchTestRule()
+			rule.Version = 3
+			ruleStorage.On("GetRule", mock.Anything, "rule-1").Return(rule, nil)
+			ruleStorage.On("UpdateRule", mock.Anything, rule).Return(nil)
+			ruleStorage.On("DeleteRule", mock.Anything, "rule-1").Return(nil)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("If-Match", tt.ifMatch)
+			req = req.WithContext(WithUserID(req.Context(), "test-user-123"))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusPreconditionFailed {
+				assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+				ruleStorage.AssertNotCalled(t, "UpdateRule", mock.Anything, mock.Anything)
+				ruleStorage.AssertNotCalled(t, "DeleteRule", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestRuleHandler_UpdateRule_VersionConflict(t *testing.T) {
+	ruleStorage := new(MockRuleStorage)
+	r := newRuleTestRouter(NewRuleHandler(ruleStorage, new(MockUserStorage)))
+
+	// Another request updates the rule between reading and writing it
+	ruleStorage.On("GetRule", mock.Anything, "rule-1").Return(patchTestRule(), nil)
+	ruleStorage.On("UpdateRule", mock.Anything, mock.Anything).Return(fmt.Errorf("update rule rule-1: %w", storage.ErrRuleVersionConflict))
+
+	w := serveUserRequest(r, http.MethodPut, "/rule-1", "test-user-123", UpdateRuleRequest{Name: "Renamed", ClubIDs: []string{"club-1"}})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	req := httptest.NewRequest(http.MethodPatch, "/rule-1", strings.NewReader(`{"name":"Renamed"}`))
+	req.Header.Set("If-Match", `"0"`)
+	req = req.WithContext(WithUserID(req.Context(), "test-user-123"))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
 }
 
 func TestRuleHandler_DeleteRule(t *testing.T) {
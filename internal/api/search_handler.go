@@ -11,10 +11,10 @@ import (
 	"sync"
 	"time"
 
-	"github.com/rafa-garcia/go-playtomic-api/client"
 	playtomicmodels "github.com/rafa-garcia/go-playtomic-api/models"
 	"github.com/rafa-garcia/padel-alert/internal/domain/model"
 	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/source"
 	"github.com/rafa-garcia/padel-alert/internal/transformer"
 )
 
@@ -24,15 +24,14 @@ type ActivitySearchResponse struct {
 }
 
 type SearchHandler struct {
-	playtomicClient *client.Client
+	activitySource source.ActivitySource
+	pagination     source.Pagination
 }
 
-func NewSearchHandler() *SearchHandler {
+func NewSearchHandler(activitySource source.ActivitySource, pagination source.Pagination) *SearchHandler {
 	return &SearchHandler{
-		playtomicClient: client.NewClient(
-			client.WithTimeout(30*time.Second),
-			client.WithRetries(3),
-		),
+		activitySource: activitySource,
+		pagination:     pagination,
 	}
 }
 
@@ -102,18 +101,17 @@ func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	pageSizeStr := query.Get("size")
-	pageSize := 500
-	if pageSizeStr != "" {
-		var err error
-		pageSize, err = strconv.Atoi(pageSizeStr)
-		if err != nil {
+	pagination := h.pagination
+	if pageSizeStr := query.Get("size"); pageSizeStr != "" {
+		pageSize, err := strconv.Atoi(pageSizeStr)
+		if err != nil || pageSize <= 0 {
 			respondWithError(w, "Invalid size parameter", http.StatusBadRequest)
 			return
 		}
 		if pageSize > 500 {
 			pageSize = 500
 		}
+		pagination.PageSize = pageSize
 	}
 
 	includeSummary := true
@@ -211,19 +209,19 @@ func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
 		go func() {
 			defer wg.Done()
 
-			classParams := &playtomicmodels.SearchClassesParams{
-				Sort:             "start_date,created_at,ASC",
-				Status:           status,
-				Type:             classType,
-				TenantIDs:        clubIDs,
-				IncludeSummary:   includeSummary,
-				Size:             pageSize,
-				Page:             0,
-				CourseVisibility: "PUBLIC",
-				FromStartDate:    fromStartDate,
-			}
-
-			classes, err := h.playtomicClient.GetClasses(ctx, classParams)
+			classes, _, err := source.FetchAll(ctx, "classes", pagination, func(ctx context.Context, page, size int) ([]playtomicmodels.Class, error) {
+				return h.activitySource.GetClasses(ctx, &playtomicmodels.SearchClassesParams{
+					Sort:             "start_date,created_at,ASC",
+					Status:           status,
+					Type:             classType,
+					TenantIDs:        clubIDs,
+					IncludeSummary:   includeSummary,
+					Size:             size,
+					Page:             page,
+					CourseVisibility: "PUBLIC",
+					FromStartDate:    fromStartDate,
+				})
+			})
 			if err != nil {
 				logger.Error("Error fetching classes", err)
 				errCh <- fmt.Errorf("error fetching class data: %w", err)
@@ -248,18 +246,18 @@ func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
 		go func() {
 			defer wg.Done()
 
-			matchParams := &playtomicmodels.SearchMatchesParams{
-				Sort:          "start_date,created_at,DESC",
-				HasPlayers:    true,
-				SportID:       "PADEL",
-				TenantIDs:     clubIDs,
-				Visibility:    "VISIBLE",
-				FromStartDate: fromStartDate,
-				Size:          pageSize,
-				Page:          0,
-			}
-
-			matches, err := h.playtomicClient.GetMatches(ctx, matchParams)
+			matches, _, err := source.FetchAll(ctx, "matches", pagination, func(ctx context.Context, page, size int) ([]playtomicmodels.Match, error) {
+				return h.activitySource.GetMatches(ctx, &playtomicmodels.SearchMatchesParams{
+					Sort:          "start_date,created_at,DESC",
+					HasPlayers:    true,
+					SportID:       "PADEL",
+					TenantIDs:     clubIDs,
+					Visibility:    "VISIBLE",
+					FromStartDate: fromStartDate,
+					Size:          size,
+					Page:          page,
+				})
+			})
 			if err != nil {
 				logger.Error("Error fetching matches", err)
 				errCh <- fmt.Errorf("error fetching match data: %w", err)
@@ -295,17 +293,17 @@ func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
 			go func(tenantID string) {
 				defer wg.Done()
 
-				lessonParams := &playtomicmodels.SearchLessonsParams{
-					Sort:                 "start_date,created_at,ASC",
-					TenantID:             tenantID,
-					TournamentVisibility: "PUBLIC",
-					Status:               "REGISTRATION_OPEN,REGISTRATION_CLOSED,IN_PROGRESS",
-					Size:                 pageSize,
-					Page:                 0,
-					FromStartDate:        fromStartDate,
-				}
-
-				lessons, err := h.playtomicClient.GetLessons(ctx, lessonParams)
+				lessons, _, err := source.FetchAll(ctx, "lessons", pagination, func(ctx context.Context, page, size int) ([]playtomicmodels.Lesson, error) {
+					return h.activitySource.GetLessons(ctx, &playtomicmodels.SearchLessonsParams{
+						Sort:                 "start_date,created_at,ASC",
+						TenantID:             tenantID,
+						TournamentVisibility: "PUBLIC",
+						Status:               "REGISTRATION_OPEN,REGISTRATION_CLOSED,IN_PROGRESS",
+						Size:                 size,
+						Page:                 page,
+						FromStartDate:        fromStartDate,
+					})
+				})
 				if err != nil {
 					logger.Error("Error fetching lessons", err, "tenantID", tenantID)
 					errCh <- fmt.Errorf("error fetching lesson data for tenant %s: %w", tenantID, err)
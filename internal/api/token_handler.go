@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
+)
+
+// maxTokenNameLength caps the length of a token's name
+const maxTokenNameLength = 100
+
+// TokenHandler handles API requests for per-user API tokens
+type TokenHandler struct {
+	tokenStorage storage.TokenStorage
+	userStorage  storage.UserStorage
+}
+
+// NewTokenHandler creates a new token handler
+func NewTokenHandler(tokenStorage storage.TokenStorage, userStorage storage.UserStorage) *TokenHandler {
+	return &TokenHandler{
+		tokenStorage: tokenStorage,
+		userStorage:  userStorage,
+	}
+}
+
+// CreateTokenRequest represents a request to create an API token
+type CreateTokenRequest struct {
+	Name string `json:"name"`
+}
+
+// CreateTokenResponse is a newly created token along with its secret, which
+// is only ever returned here
+type CreateTokenResponse struct {
+	*model.APIToken
+	Token string `json:"token"`
+}
+
+// CreateToken creates a token for the requesting user
+func (h *TokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		respondWithError(w, "User ID not found in request context", http.StatusUnauthorized)
+		return
+	}
+
+	h.issueToken(w, r, userID)
+}
+
+// IssueToken creates a token for the user in the URL. It is an admin route,
+// used to hand a user their first token.
+func (h *TokenHandler) IssueToken(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+	if userID == "" {
+		respondWithError(w, "User ID is required", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.userStorage.GetUser(r.Context(), userID); err != nil {
+		respondWithUserError(w, err, userID, "Failed to get user")
+		return
+	}
+
+	h.issueToken(w, r, userID)
+}
+
+// ListTokens lists the requesting user's tokens, without their secrets
+func (h *TokenHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		respondWithError(w, "User ID not found in request context", http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := h.tokenStorage.ListTokens(r.Context(), userID)
+	if err != nil {
+		logger.Error("Failed to list tokens", err, "user_id", userID)
+		respondWithError(w, "Failed to list tokens", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithJSON(w, tokens)
+}
+
+// RevokeToken revokes one of the requesting user's tokens
+func (h *TokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		respondWithError(w, "User ID not found in request context", http.StatusUnauthorized)
+		return
+	}
+
+	tokenID := chi.URLParam(r, "id")
+	if tokenID == "" {
+		respondWithError(w, "Token ID is required", http.StatusBadRequest)
+		return
+	}
+
+	err := h.tokenStorage.RevokeToken(r.Context(), userID, tokenID)
+	if errors.Is(err, storage.ErrTokenNotFound) {
+		respondWithError(w, "Token not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to revoke token", err, "user_id", userID, "token_id", tokenID)
+		respondWithError(w, "Failed to revoke token", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithSuccess(w, "Token revoked successfully")
+}
+
+// issueToken creates a token for a user and responds with its secret
+func (h *TokenHandler) issueToken(w http.ResponseWriter, r *http.Request, userID string) {
+	var req CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if len(req.Name) > maxTokenNameLength {
+		respondWithError(w, fmt.Sprintf("Invalid name: must be at most %d characters", maxTokenNameLength), http.StatusBadRequest)
+		return
+	}
+
+	token, secret, err := h.tokenStorage.CreateToken(r.Context(), userID, req.Name)
+	if err != nil {
+		logger.Error("Failed to create token", err, "user_id", userID)
+		respondWithError(w, "Failed to create token", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("API token created", "user_id", userID, "token_id", token.ID)
+
+	w.WriteHeader(http.StatusCreated)
+	respondWithJSON(w, CreateTokenResponse{APIToken: token, Token: secret})
+}
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/source"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockTokenStorage struct {
+	mock.Mock
+}
+
+func (m *MockTokenStorage) CreateToken(ctx context.Context, userID, name string) (*model.APIToken, string, error) {
+	args := m.Called(ctx, userID, name)
+	if args.Get(0) == nil {
+		return nil, "", args.Error(2)
+	}
+	return args.Get(0).(*model.APIToken), args.String(1), args.Error(2)
+}
+
+func (m *MockTokenStorage) Authenticate(ctx context.Context, secret string) (*model.APIToken, error) {
+	args := m.Called(ctx, secret)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.APIToken), args.Error(1)
+}
+
+func (m *MockTokenStorage) ListTokens(ctx context.Context, userID string) ([]*model.APIToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.APIToken), args.Error(1)
+}
+
+func (m *MockTokenStorage) RevokeToken(ctx context.Context, userID, tokenID string) error {
+	args := m.Called(ctx, userID, tokenID)
+	return args.Error(0)
+}
+
+func (m *MockTokenStorage) RevokeUserTokens(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func TestTokenHandler_CreateToken(t *testing.T) {
+	tokenStorage := new(MockTokenStorage)
+	handler := NewTokenHandler(tokenStorage, new(MockUserStorage))
+
+	token := &model.APIToken{ID: "token-1", UserID: "user-1", Name: "laptop", Prefix: "pat_12345678"}
+	tokenStorage.On("CreateToken", mock.Anything, "user-1", "laptop").Return(token, "pat_secret", nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tokens", strings.NewReader(`{"name": " laptop "}`))
+	req = req.WithContext(WithUserID(req.Context(), "user-1"))
+	w := httptest.NewRecorder()
+	handler.CreateToken(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var resp struct {
+		Data CreateTokenResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "token-1", resp.Data.ID)
+	assert.Equal(t, "pat_12345678", resp.Data.Prefix)
+	assert.Equal(t, "pat_secret", resp.Data.Token)
+}
+
+func TestTokenHandler_IssueToken(t *testing.T) {
+	tokenStorage := new(MockTokenStorage)
+	userStorage := new(MockUserStorage)
+	handler := NewTokenHandler(tokenStorage, userStorage)
+
+	userStorage.On("GetUser", mock.Anything, "user-1").Return(&model.User{ID: "user-1"}, nil)
+	userStorage.On("GetUser", mock.Anything, "user-2").Return(nil, storage.ErrUserNotFound)
+	tokenStorage.On("CreateToken", mock.Anything, "user-1", "").Return(&model.APIToken{ID: "token-1", UserID: "user-1"}, "pat_secret", nil)
+
+	r := chi.NewRouter()
+	r.Post("/admin/users/{id}/tokens", handler.IssueToken)
+
+	// The body is optional
+	req := httptest.NewRequest(http.MethodPost, "/admin/users/user-1/tokens", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/users/user-2/tokens", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	tokenStorage.AssertNumberOfCalls(t, "CreateToken", 1)
+}
+
+func TestTokenHandler_ListAndRevoke(t *testing.T) {
+	tokenStorage := new(MockTokenStorage)
+	handler := NewTokenHandler(tokenStorage, new(MockUserStorage))
+
+	tokenStorage.On("ListTokens", mock.Anything, "user-1").Return([]*model.APIToken{{ID: "token-1", UserID: "user-1"}}, nil)
+	tokenStorage.On("RevokeToken", mock.Anything, "user-1", "token-1").Return(nil)
+	tokenStorage.On("RevokeToken", mock.Anything, "user-1", "token-2").Return(storage.ErrTokenNotFound)
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithUserID(r.Context(), "user-1")))
+		})
+	})
+	r.Get("/", handler.ListTokens)
+	r.Delete("/{id}", handler.RevokeToken)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "token-1")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/token-1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/token-2", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestTokenAuth(t *testing.T) {
+	tokenStorage := new(MockTokenStorage)
+	tokenStorage.On("Authenticate", mock.Anything, "pat_valid").Return(&model.APIToken{ID: "token-1", UserID: "user-1"}, nil)
+	tokenStorage.On("Authenticate", mock.Anything, "pat_revoked").Return(nil, storage.ErrTokenNotFound)
+
+	var seenUserID string
+	handler := TokenAuth(tokenStorage)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenUserID, _ = GetUserID(r.Context())
+	}))
+
+	tests := []struct {
+		name          string
+		authorization string
+		expectedCode  int
+	}{
+		{name: "valid token", authorization: "Bearer pat_valid", expectedCode: http.StatusOK},
+		{name: "scheme is case insensitive", authorization: "bearer pat_valid", expectedCode: http.StatusOK},
+		{name: "revoked token", authorization: "Bearer pat_revoked", expectedCode: http.StatusUnauthorized},
+		{name: "missing header", expectedCode: http.StatusUnauthorized},
+		{name: "wrong scheme", authorization: "Basic dXNlcjpwYXNz", expectedCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seenUserID = ""
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/rules", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusOK {
+				assert.Equal(t, "user-1", seenUserID)
+			} else {
+				assert.Empty(t, seenUserID)
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestNewRouter_AuthModes(t *testing.T) {
+	newRouter := func(authMode string) (http.Handler, *MockRuleStorage) {
+		ruleStorage := new(MockRuleStorage)
+		ruleStorage.On("ListRules", mock.Anything, mock.Anything).Return([]*model.Rule{}, nil)
+
+		tokenStorage := new(MockTokenStorage)
+		tokenStorage.On("Authenticate", mock.Anything, "pat_valid").Return(&model.APIToken{UserID: "user-1"}, nil)
+		tokenStorage.On("Authenticate", mock.Anything, mock.Anything).Return(nil, storage.ErrTokenNotFound)
+
+		r := NewRouter("test", nil, []string{"user-key"}, []string{"admin-key"}, authMode, RateLimitConfig{}, ruleStorage, new(MockUserStorage), tokenStorage, new(MockNotificationStorage), new(MockSeenStorage), new(MockSchedulerControl), nil, source.Pagination{})
+		return r, ruleStorage
+	}
+
+	request := func(r http.Handler, path, apiKey, bearer string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("token mode", func(t *testing.T) {
+		r, ruleStorage := newRouter(AuthModeToken)
+
+		assert.Equal(t, http.StatusOK, request(r, "/api/v1/rules", "", "pat_valid"))
+		ruleStorage.AssertCalled(t, "ListRules", mock.Anything, "user-1")
+
+		// The query parameter no longer picks the user
+		assert.Equal(t, http.StatusOK, request(r, "/api/v1/rules?user_id=user-2", "", "pat_valid"))
+		ruleStorage.AssertNotCalled(t, "ListRules", mock.Anything, "user-2")
+
+		// Admin keys only open admin routes, and neither tokens nor user keys open them
+		assert.Equal(t, http.StatusUnauthorized, request(r, "/api/v1/rules?user_id=user-2", "admin-key", ""))
+		assert.Equal(t, http.StatusUnauthorized, request(r, "/api/v1/rules?user_id=user-2", "user-key", ""))
+		assert.Equal(t, http.StatusUnauthorized, request(r, "/metrics", "", "pat_valid"))
+		assert.Equal(t, http.StatusUnauthorized, request(r, "/metrics", "user-key", ""))
+		assert.Equal(t, http.StatusOK, request(r, "/metrics", "admin-key", ""))
+	})
+
+	t.Run("no admin keys", func(t *testing.T) {
+		r := NewRouter("test", nil, []string{"user-key"}, nil, AuthModeLegacy, RateLimitConfig{}, new(MockRuleStorage), new(MockUserStorage), new(MockTokenStorage), new(MockNotificationStorage), new(MockSeenStorage), new(MockSchedulerControl), nil, source.Pagination{})
+
+		// Admin routes are not served, rather than opened to user keys
+		assert.Equal(t, http.StatusNotFound, request(r, "/admin/rules", "user-key", ""))
+		assert.Equal(t, http.StatusNotFound, request(r, "/admin/rules", "", ""))
+		assert.Equal(t, http.StatusNotFound, request(r, "/metrics", "user-key", ""))
+	})
+
+	t.Run("legacy mode", func(t *testing.T) {
+		r, ruleStorage := newRouter(AuthModeLegacy)
+
+		assert.Equal(t, http.StatusOK, request(r, "/api/v1/rules?user_id=user-2", "user-key", ""))
+		ruleStorage.AssertCalled(t, "ListRules", mock.Anything, "user-2")
+
+		assert.Equal(t, http.StatusUnauthorized, request(r, "/api/v1/rules?user_id=user-2", "", "pat_valid"))
+
+		// User keys stay separate from admin keys
+		assert.Equal(t, http.StatusUnauthorized, request(r, "/api/v1/rules?user_id=user-2", "admin-key", ""))
+		assert.Equal(t, http.StatusUnauthorized, request(r, "/admin/rules", "user-key", ""))
+
+		// Anyone with a user key could mint tokens for any user
+		assert.Equal(t, http.StatusNotFound, request(r, "/api/v1/tokens?user_id=user-2", "user-key", ""))
+	})
+}
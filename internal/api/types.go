@@ -1,9 +1,12 @@
 package api
 
+import "github.com/rafa-garcia/padel-alert/internal/validation"
+
 // Response represents a standard API response
 type Response struct {
-	Data    interface{} `json:"data,omitempty"`
-	Error   *string     `json:"error,omitempty"`
-	Message string      `json:"message,omitempty"`
-	Status  int         `json:"status"`
+	Data    interface{}             `json:"data,omitempty"`
+	Error   *string                 `json:"error,omitempty"`
+	Errors  []validation.FieldError `json:"errors,omitempty"` // Every invalid field when validation fails
+	Message string                  `json:"message,omitempty"`
+	Status  int                     `json:"status"`
 }
@@ -0,0 +1,257 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
+	"github.com/rafa-garcia/padel-alert/internal/util"
+	"github.com/rafa-garcia/padel-alert/internal/validation"
+)
+
+// UserHandler handles API requests for user accounts
+type UserHandler struct {
+	userStorage storage.UserStorage
+}
+
+// NewUserHandler creates a new user handler
+func NewUserHandler(userStorage storage.UserStorage) *UserHandler {
+	return &UserHandler{
+		userStorage: userStorage,
+	}
+}
+
+// CreateUserRequest represents a request to create a user account
+type CreateUserRequest struct {
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// UpdateUserRequest represents a request to update a user account
+type UpdateUserRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// CreateUser creates a new user account. The ID defaults to the requesting
+// user's ID, or a generated one when the request has none.
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := req.ID
+	if contextUserID, ok := GetUserID(r.Context()); ok {
+		if userID != "" && userID != contextUserID {
+			respondWithError(w, "Not authorized to create this user", http.StatusForbidden)
+			return
+		}
+		userID = contextUserID
+	}
+	if userID == "" {
+		userID = util.GenerateID()
+	}
+
+	user := &model.User{
+		ID:    userID,
+		Name:  req.Name,
+		Email: req.Email,
+	}
+
+	if errs := validateUser(user); len(errs) > 0 {
+		respondWithValidationErrors(w, errs)
+		return
+	}
+
+	_, err := h.userStorage.GetUser(r.Context(), userID)
+	if err == nil {
+		respondWithError(w, "User already exists", http.StatusConflict)
+		return
+	}
+	if !errors.Is(err, storage.ErrUserNotFound) {
+		logger.Error("Failed to get user", err, "user_id", userID)
+		respondWithError(w, "Failed to create user", http.StatusInternalServerError)
+		return
+	}
+
+	if !h.checkEmailAvailable(w, r, user) {
+		return
+	}
+
+	if err := h.userStorage.CreateUser(r.Context(), user); err != nil {
+		logger.Error("Failed to create user", err, "user_id", userID)
+		respondWithError(w, "Failed to create user", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	respondWithJSON(w, user)
+}
+
+// GetUser gets the requesting user's account
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authorizeUser(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.userStorage.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithUserError(w, err, userID, "Failed to get user")
+		return
+	}
+
+	respondWithJSON(w, user)
+}
+
+// UpdateUser updates the requesting user's name and email. The new email
+// applies to every rule the user owns.
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authorizeUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userStorage.GetUser(r.Context(), userID)
+	if err != nil {
+		respondWithUserError(w, err, userID, "Failed to get user")
+		return
+	}
+
+	user.Name = req.Name
+	user.Email = req.Email
+
+	if errs := validateUser(user); len(errs) > 0 {
+		respondWithValidationErrors(w, errs)
+		return
+	}
+
+	if !h.checkEmailAvailable(w, r, user) {
+		return
+	}
+
+	if err := h.userStorage.UpdateUser(r.Context(), user); err != nil {
+		respondWithUserError(w, err, userID, "Failed to update user")
+		return
+	}
+
+	respondWithJSON(w, user)
+}
+
+// DeleteUser deletes the requesting user's account along with their rules,
+// seen activities and pending digest
+func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.authorizeUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.userStorage.DeleteUser(r.Context(), userID); err != nil {
+		respondWithUserError(w, err, userID, "Failed to delete user")
+		return
+	}
+
+	respondWithSuccess(w, "User deleted successfully")
+}
+
+// authorizeUser returns the user ID in the URL, responding with an error and
+// returning false unless it belongs to the requesting user
+func (h *UserHandler) authorizeUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	contextUserID, ok := GetUserID(r.Context())
+	if !ok {
+		respondWithError(w, "User ID not found in request context", http.StatusUnauthorized)
+		return "", false
+	}
+
+	userID := chi.URLParam(r, "id")
+	if userID == "" {
+		respondWithError(w, "User ID is required", http.StatusBadRequest)
+		return "", false
+	}
+
+	if userID != contextUserID {
+		respondWithError(w, "Not authorized to access this user", http.StatusForbidden)
+		return "", false
+	}
+
+	return userID, true
+}
+
+// checkEmailAvailable responds with a conflict and returns false if another
+// user already has the user's email
+func (h *UserHandler) checkEmailAvailable(w http.ResponseWriter, r *http.Request, user *model.User) bool {
+	taken, err := emailTaken(r.Context(), h.userStorage, user)
+	if err != nil {
+		logger.Error("Failed to look up user by email", err, "user_id", user.ID)
+		respondWithError(w, "Failed to save user", http.StatusInternalServerError)
+		return false
+	}
+	if taken {
+		respondWithError(w, "email is already used by another user", http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// respondWithUserError responds with 404 for a missing user and logs any other error
+func respondWithUserError(w http.ResponseWriter, err error, userID, message string) {
+	if errors.Is(err, storage.ErrUserNotFound) {
+		respondWithError(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	logger.Error(message, err, "user_id", userID)
+	respondWithError(w, message, http.StatusInternalServerError)
+}
+
+// emailTaken checks whether a user other than the given one has its email
+func emailTaken(ctx context.Context, userStorage storage.UserStorage, user *model.User) (bool, error) {
+	existing, err := userStorage.GetUserByEmail(ctx, user.Email)
+	if errors.Is(err, storage.ErrUserNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return existing.ID != user.ID, nil
+}
+
+// validateUser checks a user's name and email, trimming surrounding spaces
+func validateUser(user *model.User) validation.Errors {
+	user.Name = strings.TrimSpace(user.Name)
+	user.Email = strings.TrimSpace(user.Email)
+
+	var errs validation.Errors
+	switch {
+	case user.Email == "":
+		errs.Add("email", validation.CodeRequired, "is required")
+	case !validation.IsEmail(user.Email):
+		errs.Add("email", validation.CodeInvalid, "must be a valid email address")
+	}
+
+	if len([]rune(user.Name)) > validation.MaxNameLength {
+		errs.Add("name", validation.CodeTooLong, fmt.Sprintf("must be at most %d characters", validation.MaxNameLength))
+	}
+
+	if user.Name == "" {
+		user.Name = user.ID
+	}
+
+	return errs
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
+	"github.com/rafa-garcia/padel-alert/internal/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newUserTestRouter(handler *UserHandler) *chi.Mux {
+	r := chi.NewRouter()
+	r.Post("/", handler.CreateUser)
+	r.Get("/{id}", handler.GetUser)
+	r.Put("/{id}", handler.UpdateUser)
+	r.Delete("/{id}", handler.DeleteUser)
+	return r
+}
+
+func serveUserRequest(r http.Handler, method, path, userID string, body interface{}) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	if userID != "" {
+		req = req.WithContext(WithUserID(req.Context(), userID))
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestUserHandler_CreateUser(t *testing.T) {
+	userStorage := new(MockUserStorage)
+	r := newUserTestRouter(NewUserHandler(userStorage))
+
+	userStorage.On("GetUser", mock.Anything, "user-1").Return(nil, storage.ErrUserNotFound)
+	userStorage.On("GetUserByEmail", mock.Anything, "ana@example.com").Return(nil, storage.ErrUserNotFound)
+	userStorage.On("CreateUser", mock.Anything, mock.MatchedBy(func(user *model.User) bool {
+		return user.ID == "user-1" && user.Name == "Ana" && user.Email == "ana@example.com"
+	})).Return(nil)
+
+	w := serveUserRequest(r, http.MethodPost, "/", "user-1", CreateUserRequest{Name: " Ana ", Email: "ana@example.com"})
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var resp struct {
+		Data model.User `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "user-1", resp.Data.ID)
+	assert.Equal(t, "Ana", resp.Data.Name)
+	userStorage.AssertExpectations(t)
+}
+
+func TestUserHandler_CreateUser_Rejected(t *testing.T) {
+	tests := []struct {
+		name         string
+		userID       string
+		request      CreateUserRequest
+		setup        func(userStorage *MockUserStorage)
+		expectedCode int
+	}{
+		{
+			name:         "invalid email",
+			userID:       "user-1",
+			request:      CreateUserRequest{Name: "Ana", Email: "not-an-email"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "missing email",
+			userID:       "user-1",
+			request:      CreateUserRequest{Name: "Ana"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "someone else's ID",
+			userID:       "user-1",
+			request:      CreateUserRequest{ID: "user-2", Name: "Ana", Email: "ana@example.com"},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:    "existing user",
+			userID:  "user-1",
+			request: CreateUserRequest{Name: "Ana", Email: "ana@example.com"},
+			setup: func(userStorage *MockUserStorage) {
+				userStorage.On("GetUser", mock.Anything, "user-1").Return(&model.User{ID: "user-1"}, nil)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:    "email used by another user",
+			userID:  "user-1",
+			request: CreateUserRequest{Name: "Ana", Email: "ana@example.com"},
+			setup: func(userStorage *MockUserStorage) {
+				userStorage.On("GetUser", mock.Anything, "user-1").Return(nil, storage.ErrUserNotFound)
+				userStorage.On("GetUserByEmail", mock.Anything, "ana@example.com").Return(&model.User{ID: "user-2"}, nil)
+			},
+			expectedCode: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userStorage := new(MockUserStorage)
+			if tt.setup != nil {
+				tt.setup(userStorage)
+			}
+			r := newUserTestRouter(NewUserHandler(userStorage))
+
+			w := serveUserRequest(r, http.MethodPost, "/", tt.userID, tt.request)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			userStorage.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUserHandler_CreateUser_FieldErrors(t *testing.T) {
+	r := newUserTestRouter(NewUserHandler(new(MockUserStorage)))
+
+	w := serveUserRequest(r, http.MethodPost, "/", "user-1", CreateUserRequest{
+		Name:  strings.Repeat("a", validation.MaxNameLength+1),
+		Email: "Ana <ana@example.com>",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []validation.FieldError{
+		{Field: "email", Code: validation.CodeInvalid, Message: "must be a valid email address"},
+		{Field: "name", Code: validation.CodeTooLong, Message: "must be at most 100 characters"},
+	}, resp.Errors)
+}
+
+func TestUserHandler_GetUser(t *testing.T) {
+	userStorage := new(MockUserStorage)
+	r := newUserTestRouter(NewUserHandler(userStorage))
+
+	userStorage.On("GetUser", mock.Anything, "user-1").Return(&model.User{ID: "user-1", Email: "ana@example.com"}, nil)
+	userStorage.On("GetUser", mock.Anything, "user-2").Return(nil, storage.ErrUserNotFound)
+
+	assert.Equal(t, http.StatusOK, serveUserRequest(r, http.MethodGet, "/user-1", "user-1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, serveUserRequest(r, http.MethodGet, "/user-2", "user-2", nil).Code)
+	assert.Equal(t, http.StatusForbidden, serveUserRequest(r, http.MethodGet, "/user-1", "user-2", nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, serveUserRequest(r, http.MethodGet, "/user-1", "", nil).Code)
+}
+
+func TestUserHandler_UpdateUser(t *testing.T) {
+	userStorage := new(MockUserStorage)
+	r := newUserTestRouter(NewUserHandler(userStorage))
+
+	userStorage.On("GetUser", mock.Anything, "user-1").Return(&model.User{ID: "user-1", Name: "Ana", Email: "ana@example.com"}, nil)
+	userStorage.On("GetUserByEmail", mock.Anything, "ana@new.example.com").Return(nil, storage.ErrUserNotFound)
+	userStorage.On("GetUserByEmail", mock.Anything, "ben@example.com").Return(&model.User{ID: "user-2"}, nil)
+	userStorage.On("UpdateUser", mock.Anything, mock.MatchedBy(func(user *model.User) bool {
+		return user.ID == "user-1" && user.Email == "ana@new.example.com"
+	})).Return(nil)
+
+	w := serveUserRequest(r, http.MethodPut, "/user-1", "user-1", UpdateUserRequest{Name: "Ana", Email: "ana@new.example.com"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serveUserRequest(r, http.MethodPut, "/user-1", "user-1", UpdateUserRequest{Name: "Ana", Email: "ben@example.com"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	userStorage.AssertNumberOfCalls(t, "UpdateUser", 1)
+}
+
+func TestUserHandler_DeleteUser(t *testing.T) {
+	userStorage := new(MockUserStorage)
+	r := newUserTestRouter(NewUserHandler(userStorage))
+
+	userStorage.On("DeleteUser", mock.Anything, "user-1").Return(nil)
+	userStorage.On("DeleteUser", mock.Anything, "user-2").Return(storage.ErrUserNotFound)
+
+	assert.Equal(t, http.StatusOK, serveUserRequest(r, http.MethodDelete, "/user-1", "user-1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, serveUserRequest(r, http.MethodDelete, "/user-2", "user-2", nil).Code)
+	assert.Equal(t, http.StatusForbidden, serveUserRequest(r, http.MethodDelete, "/user-1", "user-2", nil).Code)
+
+	userStorage.AssertNumberOfCalls(t, "DeleteUser", 2)
+}
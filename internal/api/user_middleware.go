@@ -1,10 +1,22 @@
 package api
 
 import (
+	"errors"
 	"net/http"
+	"strings"
+
+	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
+)
+
+// Authentication modes for user routes
+const (
+	AuthModeToken  = "token"  // Per-user bearer tokens
+	AuthModeLegacy = "legacy" // Shared API keys with the user ID from ?user_id=
 )
 
-// UserIDMiddleware extracts user ID from query parameter and sets it in context
+// UserIDMiddleware extracts user ID from query parameter and sets it in context.
+// It trusts the caller, so it is only used in the legacy auth mode.
 func UserIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		userID := r.URL.Query().Get("user_id")
@@ -17,3 +29,43 @@ func UserIDMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// TokenAuth is a middleware that authenticates requests with a per-user
+// bearer token and sets the token's user ID in context
+func TokenAuth(tokenStorage storage.TokenStorage) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secret := bearerToken(r)
+			if secret == "" {
+				logger.Warn("Missing bearer token", "ip", r.RemoteAddr, "path", r.URL.Path)
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				respondWithError(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			token, err := tokenStorage.Authenticate(r.Context(), secret)
+			if errors.Is(err, storage.ErrTokenNotFound) {
+				logger.Warn("Invalid bearer token", "ip", r.RemoteAddr, "path", r.URL.Path)
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				respondWithError(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if err != nil {
+				logger.Error("Failed to authenticate token", err)
+				respondWithError(w, "Failed to authenticate request", http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithUserID(r.Context(), token.UserID)))
+		})
+	}
+}
+
+// bearerToken returns the token from an "Authorization: Bearer" header
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
@@ -7,18 +7,46 @@ import (
 // Config represents the application configuration
 type Config struct {
 	// HTTP server settings
-	Port     string   `env:"PORT" envDefault:"8080"`
-	APIKeys  []string `env:"API_KEYS" envSeparator:","`
-	LogLevel string   `env:"LOG_LEVEL" envDefault:"info"`
+	Port         string   `env:"PORT" envDefault:"8080"`
+	APIKeys      []string `env:"API_KEYS" envSeparator:","`       // User routes in the legacy auth mode
+	AdminAPIKeys []string `env:"ADMIN_API_KEYS" envSeparator:","` // Admin routes and metrics
+	LogLevel     string   `env:"LOG_LEVEL" envDefault:"info"`
+	AuthMode     string   `env:"AUTH_MODE" envDefault:"token"` // "token" for per-user tokens, "legacy" for API keys with ?user_id=
 
 	// Redis settings
 	RedisURL string `env:"REDIS_URL" envDefault:"redis://localhost:6379"`
 
 	// Scheduler configuration
-	CheckInterval int `env:"CHECK_INTERVAL" envDefault:"300"` // Seconds between checks
+	CheckInterval      int `env:"CHECK_INTERVAL" envDefault:"300"`       // Seconds between checks
+	SchedulerLease     int `env:"SCHEDULER_LEASE" envDefault:"120"`      // Seconds a replica owns a claimed rule without renewing it before others may reclaim it
+	SchedulerBatchSize int `env:"SCHEDULER_BATCH_SIZE" envDefault:"100"` // Maximum rules claimed per tick
+	WorkerCount        int `env:"WORKER_COUNT" envDefault:"10"`          // Rules processed concurrently
+	WorkerQueueSize    int `env:"WORKER_QUEUE_SIZE" envDefault:"100"`    // Rules waiting for a free worker
+	SchedulerMaxLag    int `env:"SCHEDULER_MAX_LAG" envDefault:"300"`    // Seconds a rule may stay overdue before readiness reports the schedule degraded
+
+	// Playtomic settings
+	PlaytomicFixturesDir string `env:"PLAYTOMIC_FIXTURES_DIR"` // Serve activities from JSON fixtures instead of the API
+
+	// Playtomic resilience
+	PlaytomicRateLimit      float64 `env:"PLAYTOMIC_RATE_LIMIT" envDefault:"5"`      // Outbound requests per second, 0 for no limit
+	PlaytomicRateBurst      int     `env:"PLAYTOMIC_RATE_BURST" envDefault:"10"`     // Requests allowed in a burst
+	CircuitFailureThreshold int     `env:"CIRCUIT_FAILURE_THRESHOLD" envDefault:"5"` // Consecutive failures that open an endpoint's circuit
+	CircuitCooldown         int     `env:"CIRCUIT_COOLDOWN" envDefault:"30"`         // Seconds a circuit stays open before a trial request
+
+	// Playtomic pagination
+	PlaytomicPageSize int `env:"PLAYTOMIC_PAGE_SIZE" envDefault:"100"` // Results requested per page
+	PlaytomicMaxPages int `env:"PLAYTOMIC_MAX_PAGES" envDefault:"10"`  // Maximum pages fetched per search
+	SnapshotTTL       int `env:"SNAPSHOT_TTL" envDefault:"60"`         // Seconds a club's fetched activities are reused
+
+	// Notification history
+	NotificationHistorySize int `env:"NOTIFICATION_HISTORY_SIZE" envDefault:"200"` // Entries kept per rule
 
 	// API Rate Limiting
-	APIRateLimit int `env:"API_RATE_LIMIT" envDefault:"10"` // Requests per minute
+	APIRateLimit   int      `env:"API_RATE_LIMIT" envDefault:"10"`    // Requests per minute per client IP on public routes, 0 for no limit
+	UserRateLimit  int      `env:"USER_RATE_LIMIT" envDefault:"60"`   // Requests per minute per user on user routes, 0 for no limit
+	AdminRateLimit int      `env:"ADMIN_RATE_LIMIT" envDefault:"120"` // Requests per minute per admin key on admin routes, 0 for no limit
+	AuthRateLimit  int      `env:"AUTH_RATE_LIMIT" envDefault:"300"`  // Requests per minute per client IP on user and admin routes, counted before authentication, 0 for no limit
+	TrustedProxies []string `env:"TRUSTED_PROXIES" envSeparator:","`  // Proxy addresses or CIDR ranges whose X-Forwarded-For is trusted
 
 	// Email settings
 	SMTPServer   string `env:"SMTP_SERVER"`
@@ -26,6 +54,17 @@ type Config struct {
 	SMTPUsername string `env:"SMTP_USERNAME"`
 	SMTPPassword string `env:"SMTP_PASSWORD"`
 	SMTPSender   string `env:"SMTP_SENDER"`
+
+	// Telegram settings
+	TelegramBotToken string `env:"TELEGRAM_BOT_TOKEN"`
+	TelegramAPIURL   string `env:"TELEGRAM_API_URL" envDefault:"https://api.telegram.org"`
+
+	// Webhook settings
+	WebhookSecret string `env:"WEBHOOK_SECRET"` // Signs webhook payloads when set
+
+	// Push (ntfy-style) settings
+	PushServerURL string `env:"PUSH_SERVER_URL" envDefault:"https://ntfy.sh"`
+	PushToken     string `env:"PUSH_TOKEN"`
 }
 
 // Load loads the configuration from environment variables
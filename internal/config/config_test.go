@@ -14,6 +14,9 @@ func TestLoadConfig(t *testing.T) {
 	if err := os.Setenv("API_KEYS", "test-key-1,test-key-2"); err != nil {
 		t.Fatalf("Failed to set env var: %v", err)
 	}
+	if err := os.Setenv("ADMIN_API_KEYS", "admin-key-1"); err != nil {
+		t.Fatalf("Failed to set env var: %v", err)
+	}
 	if err := os.Setenv("CHECK_INTERVAL", "300"); err != nil {
 		t.Fatalf("Failed to set env var: %v", err)
 	}
@@ -27,6 +30,7 @@ func TestLoadConfig(t *testing.T) {
 	assert.Equal(t, "redis://localhost:6379", config.RedisURL)
 	assert.Contains(t, config.APIKeys, "test-key-1")
 	assert.Contains(t, config.APIKeys, "test-key-2")
+	assert.Equal(t, []string{"admin-key-1"}, config.AdminAPIKeys)
 	assert.Equal(t, 300, config.CheckInterval)
 }
 
@@ -37,6 +41,9 @@ func TestDefaultValues(t *testing.T) {
 	if err := os.Unsetenv("API_KEYS"); err != nil {
 		t.Fatalf("Failed to unset env var: %v", err)
 	}
+	if err := os.Unsetenv("ADMIN_API_KEYS"); err != nil {
+		t.Fatalf("Failed to unset env var: %v", err)
+	}
 	if err := os.Unsetenv("CHECK_INTERVAL"); err != nil {
 		t.Fatalf("Failed to unset env var: %v", err)
 	}
@@ -50,4 +57,22 @@ func TestDefaultValues(t *testing.T) {
 	assert.Equal(t, "redis://localhost:6379", config.RedisURL)
 	assert.Equal(t, 300, config.CheckInterval)
 	assert.Equal(t, 10, config.APIRateLimit)
+	assert.Equal(t, 60, config.UserRateLimit)
+	assert.Equal(t, 120, config.AdminRateLimit)
+	assert.Equal(t, 300, config.AuthRateLimit)
+	assert.Empty(t, config.TrustedProxies)
+	assert.Equal(t, 100, config.PlaytomicPageSize)
+	assert.Equal(t, 10, config.PlaytomicMaxPages)
+	assert.Equal(t, 60, config.SnapshotTTL)
+	assert.Equal(t, float64(5), config.PlaytomicRateLimit)
+	assert.Equal(t, 10, config.PlaytomicRateBurst)
+	assert.Equal(t, 5, config.CircuitFailureThreshold)
+	assert.Equal(t, 30, config.CircuitCooldown)
+	assert.Equal(t, 120, config.SchedulerLease)
+	assert.Equal(t, 100, config.SchedulerBatchSize)
+	assert.Equal(t, 10, config.WorkerCount)
+	assert.Equal(t, 100, config.WorkerQueueSize)
+	assert.Equal(t, 300, config.SchedulerMaxLag)
+	assert.Equal(t, "token", config.AuthMode)
+	assert.Empty(t, config.AdminAPIKeys)
 }
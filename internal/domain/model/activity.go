@@ -23,6 +23,7 @@ type Activity struct {
 	AvailablePlaces   int       `json:"available_places"`
 	RegisteredPlayers []Player  `json:"registered_players"`
 	Link              string    `json:"link"`
+	ChangeType        string    `json:"change_type,omitempty"` // Set when re-alerting on a seen activity
 }
 
 // Club represents a padel club
@@ -39,6 +40,7 @@ type Address struct {
 	PostalCode string `json:"postal_code"`
 	City       string `json:"city"`
 	Country    string `json:"country"`
+	Timezone   string `json:"timezone,omitempty"`
 }
 
 // Player represents a registered player
@@ -0,0 +1,168 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Digest modes
+const (
+	DigestImmediate = "immediate"
+	DigestHourly    = "hourly"
+	DigestDaily     = "daily"
+	DigestWeekly    = "weekly"
+)
+
+// DigestModes lists every supported digest mode
+var DigestModes = []string{DigestImmediate, DigestHourly, DigestDaily, DigestWeekly}
+
+// DigestSchedule sets when a rule's matches are delivered as a summary
+// instead of as soon as they are found
+type DigestSchedule struct {
+	Mode     string `json:"mode"`
+	Time     string `json:"time,omitempty"`     // HH:MM, for daily and weekly digests
+	Day      string `json:"day,omitempty"`      // Day of week, for weekly digests
+	Timezone string `json:"timezone,omitempty"` // IANA name, UTC when empty
+}
+
+// Validate checks the digest mode and the settings it needs
+func (d DigestSchedule) Validate() error {
+	switch d.Mode {
+	case DigestImmediate, DigestHourly:
+		return nil
+	case DigestDaily, DigestWeekly:
+		if _, err := parseClock(d.Time); err != nil || d.Time == "24:00" {
+			return fmt.Errorf("invalid digest time: %q, use HH:MM", d.Time)
+		}
+		if d.Mode == DigestWeekly {
+			if _, err := ParseWeekday(d.Day); err != nil {
+				return fmt.Errorf("invalid digest day: %q", d.Day)
+			}
+		}
+		if _, err := loadTimezone(d.Timezone); err != nil {
+			return fmt.Errorf("invalid digest timezone: %q", d.Timezone)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid digest mode: %q, must be one of %s", d.Mode, strings.Join(DigestModes, ", "))
+	}
+}
+
+// NextDelivery returns the first delivery time after t
+func (d DigestSchedule) NextDelivery(t time.Time) time.Time {
+	switch d.Mode {
+	case DigestHourly:
+		return t.Truncate(time.Hour).Add(time.Hour)
+	case DigestDaily, DigestWeekly:
+		loc, err := loadTimezone(d.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		minute, err := parseClock(d.Time)
+		if err != nil {
+			minute = 0
+		}
+
+		local := t.In(loc)
+		next := time.Date(local.Year(), local.Month(), local.Day(), 0, minute, 0, 0, loc)
+		if d.Mode == DigestWeekly {
+			if day, err := ParseWeekday(d.Day); err == nil {
+				next = next.AddDate(0, 0, (int(day)-int(local.Weekday())+7)%7)
+			}
+		}
+
+		for !next.After(t) {
+			if d.Mode == DigestWeekly {
+				next = next.AddDate(0, 0, 7)
+			} else {
+				next = next.AddDate(0, 0, 1)
+			}
+		}
+		return next
+	default:
+		return t
+	}
+}
+
+// IsDigest checks if the rule's matches are delivered as a digest
+func (r *Rule) IsDigest() bool {
+	return r.Digest != nil && r.Digest.Mode != "" && r.Digest.Mode != DigestImmediate
+}
+
+// DigestEntry is an activity matched by a rule and waiting to be sent in a digest
+type DigestEntry struct {
+	RuleID    string    `json:"rule_id"`
+	RuleName  string    `json:"rule_name"`
+	Activity  Activity  `json:"activity"`
+	MatchedAt time.Time `json:"matched_at"`
+	DueAt     time.Time `json:"due_at"`
+}
+
+// DigestClub groups a day's digest entries at one club
+type DigestClub struct {
+	Club    Club
+	Entries []DigestEntry
+}
+
+// DigestDay groups digest entries starting on the same day
+type DigestDay struct {
+	Date  time.Time
+	Clubs []DigestClub
+}
+
+// Digest is a summary of activities sent to a user, grouped by day and club
+type Digest struct {
+	User  *User
+	Days  []DigestDay
+	Count int
+}
+
+// NewDigest groups entries by the day they start and then by club, in start order
+func NewDigest(user *User, entries []DigestEntry) *Digest {
+	sorted := make([]DigestEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Activity.StartDate.Before(sorted[j].Activity.StartDate)
+	})
+
+	digest := &Digest{User: user, Count: len(sorted)}
+	for _, entry := range sorted {
+		start := entry.Activity.StartDate
+		date := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+
+		if len(digest.Days) == 0 || !digest.Days[len(digest.Days)-1].Date.Equal(date) {
+			digest.Days = append(digest.Days, DigestDay{Date: date})
+		}
+		day := &digest.Days[len(digest.Days)-1]
+
+		club := -1
+		for i := range day.Clubs {
+			if day.Clubs[i].Club.ID == entry.Activity.Club.ID {
+				club = i
+				break
+			}
+		}
+		if club < 0 {
+			day.Clubs = append(day.Clubs, DigestClub{Club: entry.Activity.Club})
+			club = len(day.Clubs) - 1
+		}
+		day.Clubs[club].Entries = append(day.Clubs[club].Entries, entry)
+	}
+
+	return digest
+}
+
+// Activities returns every activity in the digest
+func (d *Digest) Activities() []Activity {
+	activities := make([]Activity, 0, d.Count)
+	for _, day := range d.Days {
+		for _, club := range day.Clubs {
+			for _, entry := range club.Entries {
+				activities = append(activities, entry.Activity)
+			}
+		}
+	}
+	return activities
+}
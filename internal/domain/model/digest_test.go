@@ -0,0 +1,115 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDigestSchedule_Validate(t *testing.T) {
+	assert.NoError(t, DigestSchedule{Mode: DigestImmediate}.Validate())
+	assert.NoError(t, DigestSchedule{Mode: DigestHourly}.Validate())
+	assert.NoError(t, DigestSchedule{Mode: DigestDaily, Time: "08:00", Timezone: "Europe/Madrid"}.Validate())
+	assert.NoError(t, DigestSchedule{Mode: DigestWeekly, Time: "08:00", Day: "mon"}.Validate())
+
+	assert.Error(t, DigestSchedule{Mode: "monthly"}.Validate())
+	assert.Error(t, DigestSchedule{Mode: DigestDaily}.Validate())
+	assert.Error(t, DigestSchedule{Mode: DigestDaily, Time: "24:00"}.Validate())
+	assert.Error(t, DigestSchedule{Mode: DigestWeekly, Time: "08:00"}.Validate())
+	assert.Error(t, DigestSchedule{Mode: DigestDaily, Time: "08:00", Timezone: "Mars/Olympus"}.Validate())
+}
+
+func TestDigestSchedule_NextDelivery(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	// Saturday 1 June 2030, 10:30 in Madrid
+	now := time.Date(2030, 6, 1, 10, 30, 0, 0, madrid)
+
+	tests := []struct {
+		name     string
+		schedule DigestSchedule
+		expected time.Time
+	}{
+		{
+			name:     "Hourly",
+			schedule: DigestSchedule{Mode: DigestHourly},
+			expected: time.Date(2030, 6, 1, 11, 0, 0, 0, madrid),
+		},
+		{
+			name:     "Daily later today",
+			schedule: DigestSchedule{Mode: DigestDaily, Time: "18:00", Timezone: "Europe/Madrid"},
+			expected: time.Date(2030, 6, 1, 18, 0, 0, 0, madrid),
+		},
+		{
+			name:     "Daily tomorrow",
+			schedule: DigestSchedule{Mode: DigestDaily, Time: "08:00", Timezone: "Europe/Madrid"},
+			expected: time.Date(2030, 6, 2, 8, 0, 0, 0, madrid),
+		},
+		{
+			name:     "Weekly next Monday",
+			schedule: DigestSchedule{Mode: DigestWeekly, Time: "08:00", Day: "monday", Timezone: "Europe/Madrid"},
+			expected: time.Date(2030, 6, 3, 8, 0, 0, 0, madrid),
+		},
+		{
+			name:     "Weekly same day already passed",
+			schedule: DigestSchedule{Mode: DigestWeekly, Time: "08:00", Day: "saturday", Timezone: "Europe/Madrid"},
+			expected: time.Date(2030, 6, 8, 8, 0, 0, 0, madrid),
+		},
+		{
+			name:     "Weekly same day later",
+			schedule: DigestSchedule{Mode: DigestWeekly, Time: "20:00", Day: "saturday", Timezone: "Europe/Madrid"},
+			expected: time.Date(2030, 6, 1, 20, 0, 0, 0, madrid),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := tt.schedule.NextDelivery(now)
+			assert.True(t, tt.expected.Equal(next), "next = %s", next)
+		})
+	}
+}
+
+func TestRule_IsDigest(t *testing.T) {
+	assert.False(t, (&Rule{}).IsDigest())
+	assert.False(t, (&Rule{Digest: &DigestSchedule{Mode: DigestImmediate}}).IsDigest())
+	assert.True(t, (&Rule{Digest: &DigestSchedule{Mode: DigestHourly}}).IsDigest())
+}
+
+func TestNewDigest_GroupsByDayAndClub(t *testing.T) {
+	day := time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC)
+	clubA := Club{ID: "club-a", Name: "Club A"}
+	clubB := Club{ID: "club-b", Name: "Club B"}
+
+	entries := []DigestEntry{
+		{Activity: Activity{ID: "4", Club: clubA, StartDate: day.AddDate(0, 0, 1).Add(9 * time.Hour)}},
+		{Activity: Activity{ID: "2", Club: clubB, StartDate: day.Add(12 * time.Hour)}},
+		{Activity: Activity{ID: "1", Club: clubA, StartDate: day.Add(10 * time.Hour)}},
+		{Activity: Activity{ID: "3", Club: clubA, StartDate: day.Add(19 * time.Hour)}},
+	}
+
+	digest := NewDigest(&User{ID: "user-1"}, entries)
+
+	assert.Equal(t, 4, digest.Count)
+	require.Len(t, digest.Days, 2)
+	assert.Equal(t, day, digest.Days[0].Date)
+
+	require.Len(t, digest.Days[0].Clubs, 2)
+	assert.Equal(t, "club-a", digest.Days[0].Clubs[0].Club.ID)
+	require.Len(t, digest.Days[0].Clubs[0].Entries, 2)
+	assert.Equal(t, "1", digest.Days[0].Clubs[0].Entries[0].Activity.ID)
+	assert.Equal(t, "3", digest.Days[0].Clubs[0].Entries[1].Activity.ID)
+	assert.Equal(t, "club-b", digest.Days[0].Clubs[1].Club.ID)
+
+	require.Len(t, digest.Days[1].Clubs, 1)
+	assert.Equal(t, "4", digest.Days[1].Clubs[0].Entries[0].Activity.ID)
+
+	ids := make([]string, 0, 4)
+	for _, activity := range digest.Activities() {
+		ids = append(ids, activity.ID)
+	}
+	assert.Equal(t, []string{"1", "3", "2", "4"}, ids)
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Activity change types a rule can opt into re-alerting on
+const (
+	ChangeSpotReopened = "spot_reopened"
+	ChangeTimeChanged  = "time_changed"
+	ChangePriceDropped = "price_dropped"
+	ChangeLevelChanged = "level_changed"
+)
+
+// ChangeTypes lists every supported change type in priority order
+var ChangeTypes = []string{ChangeSpotReopened, ChangeTimeChanged, ChangePriceDropped, ChangeLevelChanged}
+
+// changeLabels holds display labels for change types
+var changeLabels = map[string]string{
+	ChangeSpotReopened: "Spot reopened",
+	ChangeTimeChanged:  "Time changed",
+	ChangePriceDropped: "Price dropped",
+	ChangeLevelChanged: "Level changed",
+}
+
+// pricePattern matches the first number in a price such as "12.50 EUR"
+var pricePattern = regexp.MustCompile(`\d+(?:[.,]\d+)?`)
+
+// ActivityFingerprint captures the fields of a seen activity whose changes can trigger a re-alert
+type ActivityFingerprint struct {
+	AvailablePlaces int     `json:"available_places"`
+	StartDate       int64   `json:"start_date"` // Unix seconds
+	Price           string  `json:"price"`
+	MinLevel        float64 `json:"min_level"`
+	MaxLevel        float64 `json:"max_level"`
+}
+
+// Fingerprint returns the fingerprint of an activity
+func (a Activity) Fingerprint() ActivityFingerprint {
+	return ActivityFingerprint{
+		AvailablePlaces: a.AvailablePlaces,
+		StartDate:       a.StartDate.Unix(),
+		Price:           a.Price,
+		MinLevel:        a.MinLevel,
+		MaxLevel:        a.MaxLevel,
+	}
+}
+
+// Changes lists the change types from previous to f, in priority order
+func (f ActivityFingerprint) Changes(previous ActivityFingerprint) []string {
+	var changes []string
+
+	if previous.AvailablePlaces <= 0 && f.AvailablePlaces > 0 {
+		changes = append(changes, ChangeSpotReopened)
+	}
+
+	if previous.StartDate != f.StartDate {
+		changes = append(changes, ChangeTimeChanged)
+	}
+
+	oldPrice, oldOK := parsePrice(previous.Price)
+	newPrice, newOK := parsePrice(f.Price)
+	if oldOK && newOK && newPrice < oldPrice {
+		changes = append(changes, ChangePriceDropped)
+	}
+
+	if previous.MinLevel != f.MinLevel || previous.MaxLevel != f.MaxLevel {
+		changes = append(changes, ChangeLevelChanged)
+	}
+
+	return changes
+}
+
+// IsValidChangeType checks if a change type is supported
+func IsValidChangeType(changeType string) bool {
+	return slices.Contains(ChangeTypes, changeType)
+}
+
+// ChangeLabel returns a display label for a change type
+func ChangeLabel(changeType string) string {
+	if label, ok := changeLabels[changeType]; ok {
+		return label
+	}
+	return changeType
+}
+
+// ReAlertChange returns the highest priority change the rule opts into, or
+// an empty string if the rule ignores all of them
+func (r *Rule) ReAlertChange(changes []string) string {
+	for _, change := range changes {
+		if slices.Contains(r.ReAlertOn, change) {
+			return change
+		}
+	}
+	return ""
+}
+
+// parsePrice extracts the amount from a price string
+func parsePrice(price string) (float64, bool) {
+	match := pricePattern.FindString(price)
+	if match == "" {
+		return 0, false
+	}
+
+	amount, err := strconv.ParseFloat(strings.Replace(match, ",", ".", 1), 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return amount, true
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivityFingerprint_Changes(t *testing.T) {
+	start := time.Date(2030, 6, 1, 18, 0, 0, 0, time.UTC)
+	base := Activity{
+		StartDate:       start,
+		AvailablePlaces: 0,
+		Price:           "10 EUR",
+		MinLevel:        2.5,
+		MaxLevel:        3.5,
+	}
+
+	tests := []struct {
+		name     string
+		update   func(a *Activity)
+		expected []string
+	}{
+		{
+			name:     "Unchanged",
+			update:   func(a *Activity) {},
+			expected: nil,
+		},
+		{
+			name:     "Spot reopened",
+			update:   func(a *Activity) { a.AvailablePlaces = 1 },
+			expected: []string{ChangeSpotReopened},
+		},
+		{
+			name:     "Time changed",
+			update:   func(a *Activity) { a.StartDate = start.Add(time.Hour) },
+			expected: []string{ChangeTimeChanged},
+		},
+		{
+			name:     "Price dropped",
+			update:   func(a *Activity) { a.Price = "8,50 EUR" },
+			expected: []string{ChangePriceDropped},
+		},
+		{
+			name:     "Price increased",
+			update:   func(a *Activity) { a.Price = "12 EUR" },
+			expected: nil,
+		},
+		{
+			name:     "Level changed",
+			update:   func(a *Activity) { a.MaxLevel = 4.0 },
+			expected: []string{ChangeLevelChanged},
+		},
+		{
+			name: "Several changes in priority order",
+			update: func(a *Activity) {
+				a.MinLevel = 2.0
+				a.AvailablePlaces = 2
+				a.StartDate = start.Add(-time.Hour)
+			},
+			expected: []string{ChangeSpotReopened, ChangeTimeChanged, ChangeLevelChanged},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := base
+			tt.update(&updated)
+			assert.Equal(t, tt.expected, updated.Fingerprint().Changes(base.Fingerprint()))
+		})
+	}
+}
+
+func TestRule_ReAlertChange(t *testing.T) {
+	rule := &Rule{ReAlertOn: []string{ChangePriceDropped, ChangeSpotReopened}}
+
+	assert.Equal(t, ChangeSpotReopened, rule.ReAlertChange([]string{ChangeSpotReopened, ChangePriceDropped}))
+	assert.Equal(t, ChangePriceDropped, rule.ReAlertChange([]string{ChangeTimeChanged, ChangePriceDropped}))
+	assert.Empty(t, rule.ReAlertChange([]string{ChangeLevelChanged}))
+	assert.Empty(t, (&Rule{}).ReAlertChange([]string{ChangeSpotReopened}))
+}
+
+func TestIsValidChangeType(t *testing.T) {
+	for _, changeType := range ChangeTypes {
+		assert.True(t, IsValidChangeType(changeType))
+	}
+	assert.False(t, IsValidChangeType("weather_changed"))
+	assert.Equal(t, "Spot reopened", ChangeLabel(ChangeSpotReopened))
+}
@@ -4,12 +4,23 @@ import (
 	"time"
 )
 
-// Notification represents a sent or pending notification
+// Notification send statuses
+const (
+	NotificationStatusSent   = "sent"
+	NotificationStatusFailed = "failed"
+)
+
+// Notification represents a sent or failed notification attempt on a single channel
 type Notification struct {
-	ID        string     `json:"id"`
-	RuleID    string     `json:"rule_id"`
-	Message   string     `json:"message"`
-	Sent      bool       `json:"sent"`
-	SentAt    *time.Time `json:"sent_at,omitempty"`
-	CreatedAt time.Time  `json:"created_at"`
+	ID          string     `json:"id"`
+	RuleID      string     `json:"rule_id"`
+	UserID      string     `json:"user_id"`
+	Channel     string     `json:"channel"`
+	ActivityIDs []string   `json:"activity_ids"`
+	Subject     string     `json:"subject"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	Sent        bool       `json:"sent"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
 }
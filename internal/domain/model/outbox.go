@@ -0,0 +1,28 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// OutboxEntry is an activity waiting to be notified for a rule. It stays in
+// the rule's outbox until every channel has delivered it, and only then is
+// the activity marked as seen.
+type OutboxEntry struct {
+	Activity      Activity  `json:"activity"`
+	Delivered     []string  `json:"delivered,omitempty"`  // Channels that have delivered the activity
+	Attempts      int       `json:"attempts,omitempty"`   // Delivery attempts that left a channel undelivered
+	NextAttemptAt time.Time `json:"next_attempt_at"`      // When delivery is next attempted
+	LastError     string    `json:"last_error,omitempty"` // Why the last attempt failed
+	QueuedAt      time.Time `json:"queued_at"`
+}
+
+// DeliveredTo checks whether every one of the channels has delivered the activity
+func (e OutboxEntry) DeliveredTo(channels []string) bool {
+	for _, channel := range channels {
+		if !slices.Contains(e.Delivered, channel) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,15 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxEntry_DeliveredTo(t *testing.T) {
+	entry := OutboxEntry{Delivered: []string{ChannelTelegram}}
+
+	assert.True(t, entry.DeliveredTo([]string{ChannelTelegram}))
+	assert.False(t, entry.DeliveredTo([]string{ChannelEmail, ChannelTelegram}))
+	assert.False(t, OutboxEntry{}.DeliveredTo([]string{ChannelEmail}))
+}
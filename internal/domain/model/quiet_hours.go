@@ -0,0 +1,111 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Bounds for a rule's own check interval
+const (
+	MinCheckIntervalSeconds = 60
+	MaxCheckIntervalSeconds = 24 * 60 * 60
+)
+
+// QuietHours is a daily window during which a rule's notifications are held
+// back. Activities found inside the window are delivered once it ends.
+type QuietHours struct {
+	Start    string `json:"start"`              // HH:MM
+	End      string `json:"end"`                // HH:MM, before Start to wrap past midnight
+	Timezone string `json:"timezone,omitempty"` // IANA name, UTC when empty
+}
+
+// Validate checks the window's clock times and timezone
+func (q QuietHours) Validate() error {
+	if _, err := q.window(); err != nil {
+		return err
+	}
+	if _, err := q.location(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Contains checks if t falls within the quiet window, returning the time the
+// window ends when it does
+func (q QuietHours) Contains(t time.Time) (bool, time.Time) {
+	window, err := q.window()
+	if err != nil {
+		return false, time.Time{}
+	}
+
+	loc, err := q.location()
+	if err != nil {
+		return false, time.Time{}
+	}
+
+	local := t.In(loc)
+	if !window.Contains(local) {
+		return false, time.Time{}
+	}
+
+	end := time.Date(local.Year(), local.Month(), local.Day(), 0, window.End, 0, 0, loc)
+	if !end.After(local) {
+		end = end.AddDate(0, 0, 1)
+	}
+
+	return true, end
+}
+
+// window parses the start and end clock times
+func (q QuietHours) window() (TimeWindow, error) {
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid quiet hours start: %q", q.Start)
+	}
+
+	end, err := parseClock(q.End)
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid quiet hours end: %q", q.End)
+	}
+
+	if start == end {
+		return TimeWindow{}, fmt.Errorf("invalid quiet hours: %s-%s has an empty range", q.Start, q.End)
+	}
+
+	return TimeWindow{Start: start, End: end}, nil
+}
+
+// location loads the window's timezone
+func (q QuietHours) location() (*time.Location, error) {
+	loc, err := loadTimezone(q.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours timezone: %q", q.Timezone)
+	}
+	return loc, nil
+}
+
+// loadTimezone loads an IANA timezone, defaulting to UTC when name is empty
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// CheckInterval returns how often the rule is checked, falling back to the
+// given default when the rule does not set its own interval
+func (r *Rule) CheckInterval(fallback time.Duration) time.Duration {
+	if r.CheckIntervalSeconds <= 0 {
+		return fallback
+	}
+	return time.Duration(r.CheckIntervalSeconds) * time.Second
+}
+
+// InQuietHours checks if notifications for the rule are held back at t,
+// returning the time they may be delivered again
+func (r *Rule) InQuietHours(t time.Time) (bool, time.Time) {
+	if r.QuietHours == nil {
+		return false, time.Time{}
+	}
+	return r.QuietHours.Contains(t)
+}
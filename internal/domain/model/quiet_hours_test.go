@@ -0,0 +1,93 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuietHours_Validate(t *testing.T) {
+	assert.NoError(t, QuietHours{Start: "23:00", End: "08:00", Timezone: "Europe/Madrid"}.Validate())
+	assert.NoError(t, QuietHours{Start: "13:00", End: "15:00"}.Validate())
+
+	assert.Error(t, QuietHours{Start: "25:00", End: "08:00"}.Validate())
+	assert.Error(t, QuietHours{Start: "23:00", End: ""}.Validate())
+	assert.Error(t, QuietHours{Start: "08:00", End: "08:00"}.Validate())
+	assert.Error(t, QuietHours{Start: "23:00", End: "08:00", Timezone: "Mars/Olympus"}.Validate())
+}
+
+func TestQuietHours_Contains(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	quiet := QuietHours{Start: "23:00", End: "08:00", Timezone: "Europe/Madrid"}
+
+	tests := []struct {
+		name      string
+		at        time.Time
+		wantQuiet bool
+		wantUntil time.Time
+	}{
+		{
+			name:      "Before midnight",
+			at:        time.Date(2030, 6, 1, 23, 30, 0, 0, madrid),
+			wantQuiet: true,
+			wantUntil: time.Date(2030, 6, 2, 8, 0, 0, 0, madrid),
+		},
+		{
+			name:      "After midnight",
+			at:        time.Date(2030, 6, 2, 7, 59, 0, 0, madrid),
+			wantQuiet: true,
+			wantUntil: time.Date(2030, 6, 2, 8, 0, 0, 0, madrid),
+		},
+		{
+			name:      "Window end",
+			at:        time.Date(2030, 6, 2, 8, 0, 0, 0, madrid),
+			wantQuiet: false,
+		},
+		{
+			name:      "Daytime",
+			at:        time.Date(2030, 6, 2, 12, 0, 0, 0, madrid),
+			wantQuiet: false,
+		},
+		{
+			name:      "Converted from UTC",
+			at:        time.Date(2030, 6, 1, 21, 30, 0, 0, time.UTC), // 23:30 in Madrid
+			wantQuiet: true,
+			wantUntil: time.Date(2030, 6, 2, 8, 0, 0, 0, madrid),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inQuiet, until := quiet.Contains(tt.at)
+			assert.Equal(t, tt.wantQuiet, inQuiet)
+			if tt.wantQuiet {
+				assert.True(t, tt.wantUntil.Equal(until), "until = %s", until)
+			}
+		})
+	}
+}
+
+func TestRule_CheckInterval(t *testing.T) {
+	rule := &Rule{}
+	assert.Equal(t, 5*time.Minute, rule.CheckInterval(5*time.Minute))
+
+	rule.CheckIntervalSeconds = 60
+	assert.Equal(t, time.Minute, rule.CheckInterval(5*time.Minute))
+}
+
+func TestRule_InQuietHours(t *testing.T) {
+	at := time.Date(2030, 6, 1, 23, 30, 0, 0, time.UTC)
+
+	rule := &Rule{}
+	inQuiet, _ := rule.InQuietHours(at)
+	assert.False(t, inQuiet)
+
+	rule.QuietHours = &QuietHours{Start: "23:00", End: "08:00"}
+	inQuiet, until := rule.InQuietHours(at)
+	assert.True(t, inQuiet)
+	assert.Equal(t, time.Date(2030, 6, 2, 8, 0, 0, 0, time.UTC), until)
+}
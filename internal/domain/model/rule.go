@@ -4,19 +4,38 @@ import (
 	"time"
 )
 
+// Notification channel names
+const (
+	ChannelEmail    = "email"
+	ChannelTelegram = "telegram"
+	ChannelWebhook  = "webhook"
+	ChannelPush     = "push"
+)
+
+// Channels lists every supported notification channel
+var Channels = []string{ChannelEmail, ChannelTelegram, ChannelWebhook, ChannelPush}
+
+// RuleTypes lists every supported rule type
+var RuleTypes = []string{"match", "class", "lesson"}
+
 // Rule represents a notification rule that users create to be alerted about new padel activities.
 type Rule struct {
 	ID         string    `json:"id"`
+	Version    int64     `json:"version"` // Incremented whenever the rule's settings change
 	Type       string    `json:"rule_type"`
 	Name       string    `json:"name"`
 	ClubIDs    []string  `json:"club_ids"`
 	UserID     string    `json:"user_id"`
-	UserName   string    `json:"user_name"`
-	Email      string    `json:"email"`
+	UserName   string    `json:"user_name,omitempty"` // Kept for rules created before user accounts; the account takes precedence
+	Email      string    `json:"email,omitempty"`     // Kept for rules created before user accounts; the account takes precedence
 	TelegramID string    `json:"telegram_id,omitempty"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 
+	Channels   []string `json:"channels,omitempty"`
+	WebhookURL string   `json:"webhook_url,omitempty"`
+	PushTopic  string   `json:"push_topic,omitempty"`
+
 	MinRanking *float64   `json:"min_ranking,omitempty"`
 	MaxRanking *float64   `json:"max_ranking,omitempty"`
 	StartDate  *time.Time `json:"start_date,omitempty"`
@@ -27,9 +46,24 @@ type Rule struct {
 	TitleContains *string  `json:"title_contains,omitempty"`
 	ClassTypes    []string `json:"class_types,omitempty"`
 
-	LastChecked      time.Time `json:"last_checked,omitempty"`
-	LastNotification time.Time `json:"last_notification,omitempty"`
-	Active           bool      `json:"active"`
+	ReAlertOn []string `json:"re_alert_on,omitempty"` // Change types that re-alert on seen activities
+
+	CheckIntervalSeconds int         `json:"check_interval_seconds,omitempty"` // Overrides the global check interval
+	QuietHours           *QuietHours `json:"quiet_hours,omitempty"`
+
+	Digest *DigestSchedule `json:"digest,omitempty"` // Delivers matches as a summary instead of immediately
+
+	Active       bool       `json:"active"`
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"` // The rule is not checked before this time
+
+	// Runtime state recorded by the scheduler, stored apart from the settings above
+	LastChecked         time.Time  `json:"last_checked,omitempty"`
+	LastNotification    time.Time  `json:"last_notification,omitempty"`
+	LastMatchCount      int        `json:"last_match_count,omitempty"`     // Activities matched by the last successful check
+	ConsecutiveFailures int        `json:"consecutive_failures,omitempty"` // Checks failed in a row
+	NextRetryAt         *time.Time `json:"next_retry_at,omitempty"`        // When a failing rule is checked again
+	LastError           string     `json:"last_error,omitempty"`
+	NextDeliveryAt      *time.Time `json:"next_delivery_at,omitempty"` // When undelivered notifications are retried
 }
 
 // NewRule creates a new base rule with common fields set.
@@ -91,3 +125,46 @@ func (r *Rule) IsClass() bool {
 func (r *Rule) IsLesson() bool {
 	return r.Type == "lesson"
 }
+
+// RecordFailure records a failed check and when to retry it
+func (r *Rule) RecordFailure(err error, retryAt time.Time) {
+	r.ConsecutiveFailures++
+	r.NextRetryAt = &retryAt
+	r.LastError = err.Error()
+}
+
+// RecordSuccess clears the failure state after a successful check
+func (r *Rule) RecordSuccess() {
+	r.ConsecutiveFailures = 0
+	r.NextRetryAt = nil
+	r.LastError = ""
+}
+
+// IsSnoozed checks if the rule is snoozed at a given time
+func (r *Rule) IsSnoozed(t time.Time) bool {
+	return r.SnoozedUntil != nil && r.SnoozedUntil.After(t)
+}
+
+// NotificationChannels returns the channels the rule notifies through.
+// Rules without explicit channels use email, plus Telegram when a chat is set.
+func (r *Rule) NotificationChannels() []string {
+	if len(r.Channels) > 0 {
+		return r.Channels
+	}
+
+	channels := []string{ChannelEmail}
+	if r.TelegramID != "" {
+		channels = append(channels, ChannelTelegram)
+	}
+	return channels
+}
+
+// IsValidChannel checks if a channel name is supported
+func IsValidChannel(channel string) bool {
+	for _, c := range Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// RuleState is the runtime state the scheduler records as it checks a rule.
+// It is stored apart from the rule's settings, so that recording a check
+// never overwrites changes a user made to the rule in the meantime.
+type RuleState struct {
+	LastChecked         time.Time
+	LastNotification    time.Time
+	LastMatchCount      int
+	ConsecutiveFailures int
+	NextRetryAt         *time.Time
+	LastError           string
+	NextDeliveryAt      *time.Time
+}
+
+// State returns the rule's runtime state
+func (r *Rule) State() RuleState {
+	return RuleState{
+		LastChecked:         r.LastChecked,
+		LastNotification:    r.LastNotification,
+		LastMatchCount:      r.LastMatchCount,
+		ConsecutiveFailures: r.ConsecutiveFailures,
+		NextRetryAt:         r.NextRetryAt,
+		LastError:           r.LastError,
+		NextDeliveryAt:      r.NextDeliveryAt,
+	}
+}
+
+// SetState replaces the rule's runtime state
+func (r *Rule) SetState(state RuleState) {
+	r.LastChecked = state.LastChecked
+	r.LastNotification = state.LastNotification
+	r.LastMatchCount = state.LastMatchCount
+	r.ConsecutiveFailures = state.ConsecutiveFailures
+	r.NextRetryAt = state.NextRetryAt
+	r.LastError = state.LastError
+	r.NextDeliveryAt = state.NextDeliveryAt
+}
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeWindow represents a daily time range expressed in minutes since midnight.
+// A window whose end is before its start wraps around midnight.
+type TimeWindow struct {
+	Start int
+	End   int
+}
+
+// timeOfDayBuckets maps named buckets to their time windows
+var timeOfDayBuckets = map[string]TimeWindow{
+	"morning":   {Start: 6 * 60, End: 12 * 60},
+	"afternoon": {Start: 12 * 60, End: 18 * 60},
+	"evening":   {Start: 18 * 60, End: 24 * 60},
+	"night":     {Start: 0, End: 6 * 60},
+}
+
+// weekdayNames maps accepted day names to weekdays
+var weekdayNames = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"sun":       time.Sunday,
+	"monday":    time.Monday,
+	"mon":       time.Monday,
+	"tuesday":   time.Tuesday,
+	"tue":       time.Tuesday,
+	"wednesday": time.Wednesday,
+	"wed":       time.Wednesday,
+	"thursday":  time.Thursday,
+	"thu":       time.Thursday,
+	"friday":    time.Friday,
+	"fri":       time.Friday,
+	"saturday":  time.Saturday,
+	"sat":       time.Saturday,
+}
+
+// ParseWeekday parses a named weekday such as "monday" or "mon", ignoring case.
+func ParseWeekday(name string) (time.Weekday, error) {
+	day, ok := weekdayNames[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return 0, fmt.Errorf("invalid day of week: %q", name)
+	}
+	return day, nil
+}
+
+// NormalizeDaysOfWeek validates day names and returns them as lowercase full names.
+func NormalizeDaysOfWeek(days []string) ([]string, error) {
+	if len(days) == 0 {
+		return nil, nil
+	}
+
+	normalized := make([]string, 0, len(days))
+	for _, name := range days {
+		day, err := ParseWeekday(name)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, strings.ToLower(day.String()))
+	}
+
+	return normalized, nil
+}
+
+// ParseTimeOfDay parses a time range such as "18:00-21:30" or a named bucket
+// such as "morning", "afternoon", "evening" or "night".
+func ParseTimeOfDay(value string) (TimeWindow, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if window, ok := timeOfDayBuckets[value]; ok {
+		return window, nil
+	}
+
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return TimeWindow{}, fmt.Errorf("invalid time of day: %q", value)
+	}
+
+	start, err := parseClock(parts[0])
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid time of day: %q", value)
+	}
+
+	end, err := parseClock(parts[1])
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid time of day: %q", value)
+	}
+
+	if start == end {
+		return TimeWindow{}, fmt.Errorf("invalid time of day: %q has an empty range", value)
+	}
+
+	return TimeWindow{Start: start, End: end}, nil
+}
+
+// Contains checks if the clock time of t falls within the window
+func (w TimeWindow) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+
+	if w.Start <= w.End {
+		return minute >= w.Start && minute < w.End
+	}
+
+	// Window wraps around midnight
+	return minute >= w.Start || minute < w.End
+}
+
+// parseClock parses "HH:MM" into minutes since midnight, accepting "24:00" as end of day
+func parseClock(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "24:00" {
+		return 24 * 60, nil
+	}
+
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
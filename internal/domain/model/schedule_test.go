@@ -0,0 +1,99 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWeekday(t *testing.T) {
+	day, err := ParseWeekday("Monday")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Monday, day)
+
+	day, err = ParseWeekday("sat")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Saturday, day)
+
+	_, err = ParseWeekday("funday")
+	assert.Error(t, err)
+}
+
+func TestNormalizeDaysOfWeek(t *testing.T) {
+	days, err := NormalizeDaysOfWeek([]string{"MON", "wednesday", "Fri"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"monday", "wednesday", "friday"}, days)
+
+	_, err = NormalizeDaysOfWeek([]string{"monday", "someday"})
+	assert.Error(t, err)
+}
+
+func TestParseTimeOfDay(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected TimeWindow
+		wantErr  bool
+	}{
+		{
+			name:     "Range",
+			value:    "18:00-21:30",
+			expected: TimeWindow{Start: 18 * 60, End: 21*60 + 30},
+		},
+		{
+			name:     "Bucket",
+			value:    "Evening",
+			expected: TimeWindow{Start: 18 * 60, End: 24 * 60},
+		},
+		{
+			name:     "Overnight range",
+			value:    "22:00-02:00",
+			expected: TimeWindow{Start: 22 * 60, End: 2 * 60},
+		},
+		{
+			name:    "Empty range",
+			value:   "18:00-18:00",
+			wantErr: true,
+		},
+		{
+			name:    "Malformed range",
+			value:   "6pm-9pm",
+			wantErr: true,
+		},
+		{
+			name:    "Unknown bucket",
+			value:   "lunchtime",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, err := ParseTimeOfDay(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, window)
+		})
+	}
+}
+
+func TestTimeWindowContains(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2025, 6, 2, hour, minute, 0, 0, time.UTC)
+	}
+
+	evening := TimeWindow{Start: 18 * 60, End: 21*60 + 30}
+	assert.True(t, evening.Contains(at(18, 0)))
+	assert.True(t, evening.Contains(at(21, 29)))
+	assert.False(t, evening.Contains(at(21, 30)))
+	assert.False(t, evening.Contains(at(9, 0)))
+
+	overnight := TimeWindow{Start: 22 * 60, End: 2 * 60}
+	assert.True(t, overnight.Contains(at(23, 0)))
+	assert.True(t, overnight.Contains(at(1, 0)))
+	assert.False(t, overnight.Contains(at(12, 0)))
+}
@@ -0,0 +1,15 @@
+package model
+
+import (
+	"time"
+)
+
+// APIToken is a per-user token for authenticating API requests. The token's
+// secret is shown once when it is created; only a hash of it is stored.
+type APIToken struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	Prefix    string    `json:"prefix"` // Start of the secret, to tell tokens apart
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -25,6 +25,33 @@ var (
 		[]string{"method", "endpoint"},
 	)
 
+	// HttpRequestsInFlight tracks the number of HTTP requests being served
+	HttpRequestsInFlight = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "padel_alert_http_requests_in_flight",
+			Help: "The current number of HTTP requests being served",
+		},
+	)
+
+	// HttpResponseSize tracks the size of HTTP responses
+	HttpResponseSize = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "padel_alert_http_response_size_bytes",
+			Help:    "HTTP response size in bytes",
+			Buckets: prometheus.ExponentialBuckets(100, 4, 8),
+		},
+		[]string{"method", "endpoint"},
+	)
+
+	// HttpRateLimited counts requests rejected by the API rate limits
+	HttpRateLimited = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "padel_alert_http_rate_limited_total",
+			Help: "The total number of HTTP requests rejected by a rate limit",
+		},
+		[]string{"group"},
+	)
+
 	// PlaytomicApiRequests counts the number of requests to Playtomic API
 	PlaytomicApiRequests = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -44,6 +71,94 @@ var (
 		[]string{"endpoint"},
 	)
 
+	// PlaytomicCircuitState tracks each endpoint's circuit breaker state (0 closed, 1 half-open, 2 open)
+	PlaytomicCircuitState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "padel_alert_playtomic_circuit_state",
+			Help: "Circuit breaker state per Playtomic endpoint: 0 closed, 1 half-open, 2 open",
+		},
+		[]string{"endpoint"},
+	)
+
+	// PlaytomicCircuitRejections counts Playtomic calls refused by an open circuit
+	PlaytomicCircuitRejections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "padel_alert_playtomic_circuit_rejections_total",
+			Help: "The total number of Playtomic calls rejected by an open circuit breaker",
+		},
+		[]string{"endpoint"},
+	)
+
+	// PlaytomicRateLimitTokens tracks the tokens left in the outbound rate limiter
+	PlaytomicRateLimitTokens = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "padel_alert_playtomic_rate_limit_tokens",
+			Help: "The tokens currently available to outbound Playtomic calls",
+		},
+	)
+
+	// SnapshotCacheRequests counts activity snapshot lookups by result (hit, miss or shared)
+	SnapshotCacheRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "padel_alert_snapshot_cache_requests_total",
+			Help: "The total number of activity snapshot cache lookups",
+		},
+		[]string{"type", "result"},
+	)
+
+	// SeenActivities tracks the distribution of how many activities rules remember
+	// as seen after pruning. The admin rules API reports the count of each rule.
+	SeenActivities = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "padel_alert_seen_activities",
+			Help:    "The number of seen activities a rule tracks after each check",
+			Buckets: prometheus.ExponentialBuckets(1, 4, 8),
+		},
+	)
+
+	// SchedulerQueueDepth tracks the number of rules waiting for a free worker
+	SchedulerQueueDepth = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "padel_alert_scheduler_queue_depth",
+			Help: "The current number of rules queued for processing",
+		},
+	)
+
+	// SchedulerLag tracks how late rules are picked up after they become due
+	SchedulerLag = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "padel_alert_scheduler_lag_seconds",
+			Help:    "Time between a rule becoming due and being claimed, in seconds",
+			Buckets: []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800},
+		},
+	)
+
+	// SchedulerWorkerSaturation tracks the share of workers busy processing rules
+	SchedulerWorkerSaturation = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "padel_alert_scheduler_worker_saturation",
+			Help: "The fraction of scheduler workers currently busy, from 0 to 1",
+		},
+	)
+
+	// RulesProcessed counts rule checks by outcome
+	RulesProcessed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "padel_alert_rules_processed_total",
+			Help: "The total number of rule checks by outcome",
+		},
+		[]string{"outcome"},
+	)
+
+	// ActivitiesMatched counts activities reported by rule checks per rule type
+	ActivitiesMatched = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "padel_alert_activities_matched_total",
+			Help: "The total number of activities matched by rules",
+		},
+		[]string{"type"},
+	)
+
 	// NotificationsSent counts the number of notifications sent
 	NotificationsSent = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -72,6 +72,14 @@ func TestRulesCount(t *testing.T) {
 	assert.Equal(t, float64(5), value)
 }
 
+func TestSchedulerQueueDepth(t *testing.T) {
+	assert.NotNil(t, SchedulerQueueDepth)
+
+	SchedulerQueueDepth.Set(3)
+
+	assert.Equal(t, float64(3), testutil.ToFloat64(SchedulerQueueDepth))
+}
+
 // Helper to reset counters between tests
 func resetCounters() {
 	prometheus.DefaultRegisterer = prometheus.NewRegistry()
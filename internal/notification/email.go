@@ -27,6 +27,17 @@ func NewEmailNotifier(cfg *config.Config) *EmailNotifier {
 	}
 }
 
+// CheckConfig checks that the SMTP settings needed to send email are present
+func (n *EmailNotifier) CheckConfig() error {
+	if n.config.SMTPServer == "" || n.config.SMTPUsername == "" || n.config.SMTPPassword == "" {
+		return fmt.Errorf("SMTP server, username and password must be set")
+	}
+	if n.config.SMTPSender == "" {
+		return fmt.Errorf("SMTP sender must be set")
+	}
+	return nil
+}
+
 // NotifyNewActivities sends notifications about new activities
 func (n *EmailNotifier) NotifyNewActivities(ctx context.Context, user *model.User, rule *model.Rule, activities []model.Activity) error {
 	if len(activities) == 0 {
@@ -34,12 +45,11 @@ func (n *EmailNotifier) NotifyNewActivities(ctx context.Context, user *model.Use
 	}
 
 	if n.config.SMTPServer == "" || n.config.SMTPUsername == "" || n.config.SMTPPassword == "" {
-		logger.Warn("SMTP not configured, skipping email notification", "user_id", user.ID)
-		return nil
+		return fmt.Errorf("%w: SMTP settings missing", ErrNotConfigured)
 	}
 
-	subject := fmt.Sprintf("PadelAlert: %d new activities available", len(activities))
-	htmlBody, err := n.formatEmailHTML(rule, activities)
+	subject := Subject(activities)
+	htmlBody, err := n.formatEmailHTML(user, rule, activities)
 	if err != nil {
 		return fmt.Errorf("format email: %w", err)
 	}
@@ -53,6 +63,30 @@ func (n *EmailNotifier) NotifyNewActivities(ctx context.Context, user *model.Use
 	return nil
 }
 
+// NotifyDigest sends a digest of activities gathered since the last one
+func (n *EmailNotifier) NotifyDigest(ctx context.Context, user *model.User, digest *model.Digest) error {
+	if digest.Count == 0 {
+		return nil
+	}
+
+	if n.config.SMTPServer == "" || n.config.SMTPUsername == "" || n.config.SMTPPassword == "" {
+		return fmt.Errorf("%w: SMTP settings missing", ErrNotConfigured)
+	}
+
+	htmlBody, err := n.formatDigestHTML(digest)
+	if err != nil {
+		return fmt.Errorf("format digest: %w", err)
+	}
+
+	err = n.sendEmail(user.Email, DigestSubject(digest), htmlBody)
+	if err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+
+	logger.Info("Digest email sent", "user_id", user.ID, "email", user.Email, "activities", digest.Count)
+	return nil
+}
+
 // sendEmail sends an email via SMTP
 func (n *EmailNotifier) sendEmail(to, subject, htmlBody string) error {
 	auth := smtp.PlainAuth("", n.config.SMTPUsername, n.config.SMTPPassword, n.config.SMTPServer)
@@ -76,8 +110,48 @@ func (n *EmailNotifier) sendEmail(to, subject, htmlBody string) error {
 }
 
 // formatEmailHTML formats the email body as HTML using a template file
-func (n *EmailNotifier) formatEmailHTML(rule *model.Rule, activities []model.Activity) (string, error) {
-	funcMap := template.FuncMap{
+func (n *EmailNotifier) formatEmailHTML(user *model.User, rule *model.Rule, activities []model.Activity) (string, error) {
+	data := map[string]interface{}{
+		"Rule":       rule,
+		"Activities": activities,
+		"User":       user,
+	}
+
+	return renderTemplate("activity_notification.html", data)
+}
+
+// formatDigestHTML formats a digest email body as HTML using a template file
+func (n *EmailNotifier) formatDigestHTML(digest *model.Digest) (string, error) {
+	data := map[string]interface{}{
+		"Digest": digest,
+		"User":   digest.User,
+	}
+
+	return renderTemplate("digest_notification.html", data)
+}
+
+// renderTemplate renders an email template from assets/templates
+func renderTemplate(templateFile string, data map[string]interface{}) (string, error) {
+	cwd, _ := os.Getwd()
+	templatePath := filepath.Join(cwd, "assets", "templates", templateFile)
+
+	tmpl, err := template.New(templateFile).Funcs(templateFuncs()).ParseFiles(templatePath)
+	if err != nil {
+		return "", fmt.Errorf("parse template file: %w", err)
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.ExecuteTemplate(&buf, templateFile, data)
+	if err != nil {
+		return "", fmt.Errorf("execute template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// templateFuncs returns the helpers available to email templates
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
 		"len": func(items []model.Activity) int {
 			return len(items)
 		},
@@ -99,6 +173,12 @@ func (n *EmailNotifier) formatEmailHTML(rule *model.Rule, activities []model.Act
 			}
 			return fmt.Sprintf("%v", t)
 		},
+		"formatDate": func(t interface{}) string {
+			if timeVal, ok := t.(time.Time); ok {
+				return timeVal.Format("Monday, 2 January")
+			}
+			return fmt.Sprintf("%v", t)
+		},
 		"formatTime": func(t interface{}) string {
 			if timeVal, ok := t.(time.Time); ok {
 				return timeVal.Format("3:04pm")
@@ -122,6 +202,7 @@ func (n *EmailNotifier) formatEmailHTML(rule *model.Rule, activities []model.Act
 			}
 			return fmt.Sprintf("%dh %dm", hours, minutes)
 		},
+		"changeLabel": model.ChangeLabel,
 		"formatLevel": func(level interface{}) string {
 			if floatVal, ok := level.(float64); ok {
 				if floatVal == 0 {
@@ -132,36 +213,4 @@ func (n *EmailNotifier) formatEmailHTML(rule *model.Rule, activities []model.Act
 			return fmt.Sprintf("%v", level)
 		},
 	}
-
-	cwd, _ := os.Getwd()
-	templateFile := "activity_notification.html"
-	templatePath := filepath.Join(cwd, "assets", "templates", templateFile)
-
-	tmpl, err := template.New(templateFile).Funcs(funcMap).ParseFiles(templatePath)
-	if err != nil {
-		return "", fmt.Errorf("parse template file: %w", err)
-	}
-
-	var buf bytes.Buffer
-	userData := &model.User{
-		ID:    rule.UserID,
-		Email: rule.Email,
-	}
-
-	if rule.UserName != "" {
-		userData.Name = rule.UserName
-	}
-
-	data := map[string]interface{}{
-		"Rule":       rule,
-		"Activities": activities,
-		"User":       userData,
-	}
-
-	err = tmpl.ExecuteTemplate(&buf, templateFile, data)
-	if err != nil {
-		return "", fmt.Errorf("execute template: %w", err)
-	}
-
-	return buf.String(), nil
 }
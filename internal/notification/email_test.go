@@ -3,6 +3,7 @@ package notification
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -142,5 +143,59 @@ func TestEmailNotifier_NotifyNewActivities_NoSMTPConfig(t *testing.T) {
 	}
 
 	err := notifier.NotifyNewActivities(context.Background(), user, rule, activities)
+	assert.ErrorIs(t, err, ErrNotConfigured)
+}
+
+func TestEmailNotifier_FormatDigestHTML(t *testing.T) {
+	t.Chdir("../..") // Templates are loaded relative to the repository root
+
+	notifier := NewEmailNotifier(&config.Config{})
+
+	start := time.Date(2030, 6, 1, 18, 0, 0, 0, time.UTC)
+	entries := []model.DigestEntry{
+		{
+			RuleName: "Weekend matches",
+			Activity: model.Activity{
+				ID:              "activity-2",
+				Name:            "Sunday Match",
+				StartDate:       start.AddDate(0, 0, 1),
+				EndDate:         start.AddDate(0, 0, 1).Add(90 * time.Minute),
+				AvailablePlaces: 1,
+				Club:            model.Club{ID: "club-1", Name: "Club One"},
+			},
+		},
+		{
+			RuleName: "Weekend matches",
+			Activity: model.Activity{
+				ID:              "activity-1",
+				Name:            "Saturday Match",
+				StartDate:       start,
+				EndDate:         start.Add(90 * time.Minute),
+				AvailablePlaces: 2,
+				ChangeType:      model.ChangeSpotReopened,
+				Club:            model.Club{ID: "club-2", Name: "Club Two"},
+			},
+		},
+	}
+
+	digest := model.NewDigest(&model.User{ID: "user-1", Name: "Ana"}, entries)
+
+	html, err := notifier.formatDigestHTML(digest)
 	assert.NoError(t, err)
+	assert.Contains(t, html, "Vamos Ana!")
+	assert.Contains(t, html, "<strong>2</strong> activities")
+	assert.Contains(t, html, "Saturday, 1 June")
+	assert.Contains(t, html, "Sunday, 2 June")
+	assert.Contains(t, html, model.ChangeLabel(model.ChangeSpotReopened))
+	assert.Less(t, strings.Index(html, "Saturday Match"), strings.Index(html, "Sunday Match"))
+}
+
+func TestEmailNotifier_NotifyDigest_NoSMTPConfig(t *testing.T) {
+	notifier := NewEmailNotifier(&config.Config{})
+
+	user := &model.User{ID: "user-1", Email: "user@example.com"}
+	digest := model.NewDigest(user, []model.DigestEntry{{Activity: model.Activity{ID: "activity-1"}}})
+
+	err := notifier.NotifyDigest(context.Background(), user, digest)
+	assert.ErrorIs(t, err, ErrNotConfigured)
 }
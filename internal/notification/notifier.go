@@ -0,0 +1,109 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/rafa-garcia/padel-alert/internal/config"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+)
+
+// ErrNotConfigured is returned when a channel lacks the service-wide settings
+// it needs to deliver anything, so the notification is retried rather than
+// counted as sent
+var ErrNotConfigured = errors.New("channel not configured")
+
+// Notifier sends notifications about new activities through a single channel
+type Notifier interface {
+	NotifyNewActivities(ctx context.Context, user *model.User, rule *model.Rule, activities []model.Activity) error
+}
+
+// DigestNotifier sends a summary of activities gathered over a period
+type DigestNotifier interface {
+	NotifyDigest(ctx context.Context, user *model.User, digest *model.Digest) error
+}
+
+// ConfigChecker is implemented by notifiers that need service-wide settings,
+// such as SMTP credentials or a bot token, before they can deliver anything
+type ConfigChecker interface {
+	CheckConfig() error
+}
+
+// Subject renders the subject line used for a batch of new activities
+func Subject(activities []model.Activity) string {
+	return fmt.Sprintf("PadelAlert: %d new activities available", len(activities))
+}
+
+// DigestSubject renders the subject line used for a digest
+func DigestSubject(digest *model.Digest) string {
+	return fmt.Sprintf("PadelAlert: your digest with %d activities", digest.Count)
+}
+
+// Registry holds notifiers keyed by channel name
+type Registry struct {
+	mu        sync.RWMutex
+	notifiers map[string]Notifier
+}
+
+// NewRegistry creates an empty notifier registry
+func NewRegistry() *Registry {
+	return &Registry{
+		notifiers: make(map[string]Notifier),
+	}
+}
+
+// NewDefaultRegistry creates a registry with every built-in channel registered
+func NewDefaultRegistry(cfg *config.Config) *Registry {
+	registry := NewRegistry()
+	registry.Register(model.ChannelEmail, NewEmailNotifier(cfg))
+	registry.Register(model.ChannelTelegram, NewTelegramNotifier(cfg))
+	registry.Register(model.ChannelWebhook, NewWebhookNotifier(cfg))
+	registry.Register(model.ChannelPush, NewPushNotifier(cfg))
+	return registry
+}
+
+// Register adds or replaces the notifier for a channel
+func (r *Registry) Register(channel string, notifier Notifier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifiers[channel] = notifier
+}
+
+// Get returns the notifier for a channel
+func (r *Registry) Get(channel string) (Notifier, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	notifier, ok := r.notifiers[channel]
+	return notifier, ok
+}
+
+// Channels returns the registered channel names in sorted order
+func (r *Registry) Channels() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	channels := make([]string, 0, len(r.notifiers))
+	for channel := range r.notifiers {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+// CheckConfig checks the settings of every registered notifier that needs
+// them, returning each channel's result keyed by channel name
+func (r *Registry) CheckConfig() map[string]error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results := make(map[string]error)
+	for channel, notifier := range r.notifiers {
+		if checker, ok := notifier.(ConfigChecker); ok {
+			results[channel] = checker.CheckConfig()
+		}
+	}
+	return results
+}
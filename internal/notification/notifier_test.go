@@ -0,0 +1,39 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/rafa-garcia/padel-alert/internal/config"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewDefaultRegistry(&config.Config{})
+
+	assert.Equal(t, []string{"email", "push", "telegram", "webhook"}, registry.Channels())
+
+	notifier, ok := registry.Get(model.ChannelTelegram)
+	assert.True(t, ok)
+	assert.IsType(t, &TelegramNotifier{}, notifier)
+
+	_, ok = registry.Get("sms")
+	assert.False(t, ok)
+}
+
+func TestRegistry_CheckConfig(t *testing.T) {
+	registry := NewDefaultRegistry(&config.Config{
+		SMTPServer:    "smtp.example.com",
+		SMTPUsername:  "user",
+		SMTPPassword:  "password",
+		PushServerURL: "https://ntfy.sh",
+	})
+
+	results := registry.CheckConfig()
+
+	// Webhooks are configured per rule, so they have nothing to check
+	assert.Len(t, results, 3)
+	assert.EqualError(t, results[model.ChannelEmail], "SMTP sender must be set")
+	assert.EqualError(t, results[model.ChannelTelegram], "bot token must be set")
+	assert.NoError(t, results[model.ChannelPush])
+}
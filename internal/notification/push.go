@@ -0,0 +1,110 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/config"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/logger"
+)
+
+// pushMaxActivities caps the number of activities listed in a push body
+const pushMaxActivities = 5
+
+// PushNotifier publishes new activities to an ntfy-style push topic
+type PushNotifier struct {
+	config     *config.Config
+	httpClient *http.Client
+}
+
+// NewPushNotifier creates a new push notifier
+func NewPushNotifier(cfg *config.Config) *PushNotifier {
+	return &PushNotifier{
+		config: cfg,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// CheckConfig checks that a push server is configured
+func (n *PushNotifier) CheckConfig() error {
+	if n.config.PushServerURL == "" {
+		return fmt.Errorf("push server URL must be set")
+	}
+	return nil
+}
+
+// NotifyNewActivities publishes a summary of the activities to the rule's push topic
+func (n *PushNotifier) NotifyNewActivities(ctx context.Context, user *model.User, rule *model.Rule, activities []model.Activity) error {
+	if len(activities) == 0 {
+		return nil
+	}
+
+	if n.config.PushServerURL == "" {
+		return fmt.Errorf("%w: push server URL missing", ErrNotConfigured)
+	}
+
+	if rule.PushTopic == "" {
+		logger.Warn("Rule has no push topic, skipping push notification", "rule_id", rule.ID)
+		return nil
+	}
+
+	endpoint := strings.TrimRight(n.config.PushServerURL, "/") + "/" + url.PathEscape(rule.PushTopic)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(formatPushBody(activities)))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Title", fmt.Sprintf("PadelAlert: %d new activities for %s", len(activities), rule.Name))
+	req.Header.Set("Tags", "tennis")
+	if activities[0].Link != "" {
+		req.Header.Set("Click", activities[0].Link)
+	}
+	if n.config.PushToken != "" {
+		req.Header.Set("Authorization", "Bearer "+n.config.PushToken)
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send push: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("push server returned status %d", resp.StatusCode)
+	}
+
+	logger.Info("Push notification sent", "user_id", user.ID, "topic", rule.PushTopic, "activities", len(activities))
+	return nil
+}
+
+// formatPushBody renders one line per activity, summarizing any overflow
+func formatPushBody(activities []model.Activity) string {
+	var b strings.Builder
+
+	for i, activity := range activities {
+		if i == pushMaxActivities {
+			fmt.Fprintf(&b, "…and %d more", len(activities)-pushMaxActivities)
+			break
+		}
+		if activity.ChangeType != "" {
+			fmt.Fprintf(&b, "[%s] ", model.ChangeLabel(activity.ChangeType))
+		}
+		fmt.Fprintf(&b, "%s · %s · %s · %d left\n",
+			activity.StartDate.Format("Mon 2 Jan 3:04pm"),
+			activity.Club.Name,
+			activity.Name,
+			activity.AvailablePlaces,
+		)
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
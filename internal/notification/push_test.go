@@ -0,0 +1,60 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rafa-garcia/padel-alert/internal/config"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushNotifier_NotifyNewActivities(t *testing.T) {
+	var path, title, click, auth, body string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		path = r.URL.Path
+		title = r.Header.Get("Title")
+		click = r.Header.Get("Click")
+		auth = r.Header.Get("Authorization")
+		body = string(data)
+	}))
+	defer server.Close()
+
+	notifier := NewPushNotifier(&config.Config{PushServerURL: server.URL, PushToken: "tk_test"})
+	rule := &model.Rule{ID: "rule-1", Name: "Evenings", PushTopic: "padel-evenings"}
+
+	activities := make([]model.Activity, 0, 7)
+	for i := 0; i < 7; i++ {
+		activities = append(activities, model.Activity{
+			ID:   fmt.Sprintf("activity-%d", i),
+			Name: fmt.Sprintf("Match %d", i),
+			Link: fmt.Sprintf("https://example.com/%d", i),
+		})
+	}
+
+	err := notifier.NotifyNewActivities(context.Background(), &model.User{ID: "user-1"}, rule, activities)
+	require.NoError(t, err)
+
+	assert.Equal(t, "/padel-evenings", path)
+	assert.Equal(t, "PadelAlert: 7 new activities for Evenings", title)
+	assert.Equal(t, "https://example.com/0", click)
+	assert.Equal(t, "Bearer tk_test", auth)
+	assert.Contains(t, body, "Match 4")
+	assert.NotContains(t, body, "Match 5")
+	assert.Contains(t, body, "and 2 more")
+}
+
+func TestPushNotifier_NotConfigured(t *testing.T) {
+	notifier := NewPushNotifier(&config.Config{})
+	rule := &model.Rule{ID: "rule-1", Name: "Evenings", PushTopic: "padel-evenings"}
+
+	err := notifier.NotifyNewActivities(context.Background(), &model.User{ID: "user-1"}, rule, []model.Activity{{ID: "activity-1"}})
+	assert.ErrorIs(t, err, ErrNotConfigured)
+}
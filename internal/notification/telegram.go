@@ -0,0 +1,202 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/config"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/logger"
+)
+
+const (
+	// telegramMaxMessageLength is the Bot API limit for a single message text
+	telegramMaxMessageLength = 4096
+
+	// telegramMaxActivitiesPerMessage keeps each message short enough to scan
+	telegramMaxActivitiesPerMessage = 10
+)
+
+// TelegramNotifier handles Telegram notifications through the Bot API
+type TelegramNotifier struct {
+	config     *config.Config
+	httpClient *http.Client
+}
+
+// NewTelegramNotifier creates a new Telegram notifier
+func NewTelegramNotifier(cfg *config.Config) *TelegramNotifier {
+	return &TelegramNotifier{
+		config: cfg,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// CheckConfig checks that a bot token is configured
+func (n *TelegramNotifier) CheckConfig() error {
+	if n.config.TelegramBotToken == "" {
+		return fmt.Errorf("bot token must be set")
+	}
+	return nil
+}
+
+// NotifyNewActivities sends notifications about new activities to the rule's Telegram chat
+func (n *TelegramNotifier) NotifyNewActivities(ctx context.Context, user *model.User, rule *model.Rule, activities []model.Activity) error {
+	if len(activities) == 0 {
+		return nil
+	}
+
+	if n.config.TelegramBotToken == "" {
+		return fmt.Errorf("%w: Telegram bot token missing", ErrNotConfigured)
+	}
+
+	if rule.TelegramID == "" {
+		logger.Warn("Rule has no Telegram chat, skipping Telegram notification", "rule_id", rule.ID)
+		return nil
+	}
+
+	messages := n.formatMessages(rule, activities)
+	for i, text := range messages {
+		if err := n.sendMessage(ctx, rule.TelegramID, text); err != nil {
+			return fmt.Errorf("send telegram message %d of %d: %w", i+1, len(messages), err)
+		}
+	}
+
+	logger.Info("Telegram notification sent", "user_id", user.ID, "chat_id", rule.TelegramID, "activities", len(activities), "messages", len(messages))
+	return nil
+}
+
+// formatMessages renders the activities as one or more HTML-formatted
+// messages. When the activities don't fit in one message, each part is
+// numbered and continuation parts repeat a short header naming the rule, so
+// every part carries its context. A part always holds at least one activity.
+func (n *TelegramNotifier) formatMessages(rule *model.Rule, activities []model.Activity) []string {
+	name := html.EscapeString(rule.Name)
+	header := fmt.Sprintf("🎾 <b>PadelAlert</b>: %d new activities for \"%s\"", len(activities), name)
+	continuation := fmt.Sprintf("🎾 <b>PadelAlert</b>: \"%s\" continued", name)
+
+	// Room is kept for the longer header and a part number such as " (10/12)"
+	budget := telegramMaxMessageLength - max(len(header), len(continuation)) - len(" (00/00)\n")
+
+	var parts []string
+	var current strings.Builder
+	count := 0
+	for _, activity := range activities {
+		block := formatTelegramActivity(activity)
+
+		if count > 0 && (count >= telegramMaxActivitiesPerMessage || current.Len()+len(block) > budget) {
+			parts = append(parts, current.String())
+			current.Reset()
+			count = 0
+		}
+
+		current.WriteString(block)
+		count++
+	}
+	parts = append(parts, current.String())
+
+	if len(parts) == 1 {
+		return []string{header + "\n" + parts[0]}
+	}
+
+	messages := make([]string, len(parts))
+	for i, part := range parts {
+		title := header
+		if i > 0 {
+			title = continuation
+		}
+		messages[i] = fmt.Sprintf("%s (%d/%d)\n%s", title, i+1, len(parts), part)
+	}
+
+	return messages
+}
+
+// formatTelegramActivity renders a single activity as a compact message block
+func formatTelegramActivity(activity model.Activity) string {
+	spots := "spots"
+	if activity.AvailablePlaces == 1 {
+		spots = "spot"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n<b>%s</b>", html.EscapeString(activity.Name))
+	if activity.ChangeType != "" {
+		fmt.Fprintf(&b, " · 🔔 <i>%s</i>", model.ChangeLabel(activity.ChangeType))
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "📅 %s · 📍 %s\n", activity.StartDate.Format("Mon 2 Jan 3:04pm"), html.EscapeString(activity.Club.Name))
+	fmt.Fprintf(&b, "🌟 %s - %s · %d %s", formatLevelValue(activity.MinLevel), formatLevelValue(activity.MaxLevel), activity.AvailablePlaces, spots)
+	if activity.Price != "" {
+		fmt.Fprintf(&b, " · 💰 %s", html.EscapeString(activity.Price))
+	}
+	b.WriteString("\n")
+	if activity.Link != "" {
+		fmt.Fprintf(&b, "<a href=\"%s\">View &amp; Book</a>\n", html.EscapeString(activity.Link))
+	}
+
+	return b.String()
+}
+
+// formatLevelValue formats a level, showing "Any" when unset
+func formatLevelValue(level float64) string {
+	if level == 0 {
+		return "Any"
+	}
+	return fmt.Sprintf("%.1f", level)
+}
+
+// telegramResponse is the envelope returned by the Bot API
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+// sendMessage sends a single message through the Bot API
+func (n *TelegramNotifier) sendMessage(ctx context.Context, chatID, text string) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimRight(n.config.TelegramAPIURL, "/"), n.config.TelegramBotToken)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	var result telegramResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("decode response (status %d): %w", resp.StatusCode, err)
+	}
+
+	if !result.OK {
+		return fmt.Errorf("telegram API error (status %d): %s", resp.StatusCode, result.Description)
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/config"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// telegramTestServer records messages sent to a fake Bot API
+type telegramTestServer struct {
+	mu       sync.Mutex
+	paths    []string
+	messages []map[string]interface{}
+}
+
+func (s *telegramTestServer) handler(ok bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+
+		s.mu.Lock()
+		s.paths = append(s.paths, r.URL.Path)
+		s.messages = append(s.messages, payload)
+		s.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"ok":false,"description":"Bad Request: chat not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true,"result":{}}`))
+	}
+}
+
+func newTelegramTestActivities(n int) []model.Activity {
+	start := time.Date(2025, 6, 3, 18, 0, 0, 0, time.UTC)
+	activities := make([]model.Activity, 0, n)
+	for i := 0; i < n; i++ {
+		activities = append(activities, model.Activity{
+			ID:              fmt.Sprintf("activity-%d", i),
+			Name:            fmt.Sprintf("Match %d", i),
+			StartDate:       start,
+			EndDate:         start.Add(90 * time.Minute),
+			MinLevel:        3.0,
+			MaxLevel:        4.0,
+			Price:           "€10",
+			AvailablePlaces: 1,
+			Club:            model.Club{Name: "Test Club"},
+			Link:            fmt.Sprintf("https://app.playtomic.io/padel-match/activity-%d", i),
+		})
+	}
+	return activities
+}
+
+func TestTelegramNotifier_NotifyNewActivities(t *testing.T) {
+	srv := &telegramTestServer{}
+	server := httptest.NewServer(srv.handler(true))
+	defer server.Close()
+
+	notifier := NewTelegramNotifier(&config.Config{
+		TelegramBotToken: "test-token",
+		TelegramAPIURL:   server.URL,
+	})
+
+	user := &model.User{ID: "user-1"}
+	rule := &model.Rule{ID: "rule-1", Name: "Weekday evenings", TelegramID: "12345"}
+
+	err := notifier.NotifyNewActivities(context.Background(), user, rule, newTelegramTestActivities(2))
+	require.NoError(t, err)
+
+	require.Len(t, srv.messages, 1)
+	assert.Equal(t, "/bottest-token/sendMessage", srv.paths[0])
+	assert.Equal(t, "12345", srv.messages[0]["chat_id"])
+	assert.Equal(t, "HTML", srv.messages[0]["parse_mode"])
+
+	text := srv.messages[0]["text"].(string)
+	assert.Contains(t, text, "2 new activities")
+	assert.Contains(t, text, "Weekday evenings")
+	assert.Contains(t, text, `<a href="https://app.playtomic.io/padel-match/activity-1">View &amp; Book</a>`)
+}
+
+func TestFormatTelegramActivity_ChangeType(t *testing.T) {
+	activity := newTelegramTestActivities(1)[0]
+	assert.NotContains(t, formatTelegramActivity(activity), "🔔")
+
+	activity.ChangeType = model.ChangeSpotReopened
+	assert.Contains(t, formatTelegramActivity(activity), "🔔 <i>Spot reopened</i>")
+}
+
+func TestTelegramNotifier_SplitsLongLists(t *testing.T) {
+	srv := &telegramTestServer{}
+	server := httptest.NewServer(srv.handler(true))
+	defer server.Close()
+
+	notifier := NewTelegramNotifier(&config.Config{
+		TelegramBotToken: "test-token",
+		TelegramAPIURL:   server.URL,
+	})
+
+	rule := &model.Rule{ID: "rule-1", Name: "Busy club", TelegramID: "12345"}
+	activities := newTelegramTestActivities(25)
+
+	err := notifier.NotifyNewActivities(context.Background(), &model.User{ID: "user-1"}, rule, activities)
+	require.NoError(t, err)
+
+	require.Len(t, srv.messages, 3)
+	for i, message := range srv.messages {
+		text := message["text"].(string)
+		assert.LessOrEqual(t, len(text), telegramMaxMessageLength)
+		assert.Contains(t, text, "Busy club", "Every part should name the rule")
+		assert.Contains(t, text, fmt.Sprintf("(%d/3)", i+1))
+	}
+	assert.Contains(t, srv.messages[0]["text"], "25 new activities")
+}
+
+func TestTelegramNotifier_FormatMessages_NoHeaderOnlyPart(t *testing.T) {
+	notifier := NewTelegramNotifier(&config.Config{})
+	rule := &model.Rule{ID: "rule-1", Name: "Busy club"}
+
+	// An activity too long to share a message with the header still gets one
+	activities := newTelegramTestActivities(2)
+	activities[0].Name = strings.Repeat("a", telegramMaxMessageLength)
+
+	messages := notifier.formatMessages(rule, activities)
+	require.Len(t, messages, 2)
+	assert.Contains(t, messages[0], activities[0].Name)
+	assert.Contains(t, messages[0], "(1/2)")
+	assert.Contains(t, messages[1], "Match 1")
+	assert.Contains(t, messages[1], "(2/2)")
+
+	// A short list is a single message without a part number
+	messages = notifier.formatMessages(rule, activities[1:])
+	require.Len(t, messages, 1)
+	assert.NotContains(t, messages[0], "(1/1)")
+	assert.True(t, strings.HasPrefix(messages[0], "🎾 <b>PadelAlert</b>: 1 new activities"))
+}
+
+func TestTelegramNotifier_APIError(t *testing.T) {
+	srv := &telegramTestServer{}
+	server := httptest.NewServer(srv.handler(false))
+	defer server.Close()
+
+	notifier := NewTelegramNotifier(&config.Config{
+		TelegramBotToken: "test-token",
+		TelegramAPIURL:   server.URL,
+	})
+
+	rule := &model.Rule{ID: "rule-1", Name: "Test Rule", TelegramID: "12345"}
+
+	err := notifier.NotifyNewActivities(context.Background(), &model.User{ID: "user-1"}, rule, newTelegramTestActivities(1))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "chat not found")
+}
+
+func TestTelegramNotifier_NotConfigured(t *testing.T) {
+	notifier := NewTelegramNotifier(&config.Config{})
+
+	rule := &model.Rule{ID: "rule-1", Name: "Test Rule", TelegramID: "12345"}
+
+	err := notifier.NotifyNewActivities(context.Background(), &model.User{ID: "user-1"}, rule, newTelegramTestActivities(1))
+	assert.ErrorIs(t, err, ErrNotConfigured)
+}
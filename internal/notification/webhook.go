@@ -0,0 +1,144 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/config"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/validation"
+)
+
+// WebhookPayload is the JSON body posted to a rule's webhook URL
+type WebhookPayload struct {
+	RuleID     string           `json:"rule_id"`
+	RuleName   string           `json:"rule_name"`
+	UserID     string           `json:"user_id"`
+	Count      int              `json:"count"`
+	Activities []model.Activity `json:"activities"`
+	SentAt     time.Time        `json:"sent_at"`
+}
+
+// WebhookNotifier posts new activities as JSON to a rule's webhook URL
+type WebhookNotifier struct {
+	config     *config.Config
+	httpClient *http.Client
+}
+
+// ErrBlockedAddress is returned when a webhook resolves to an address the
+// server must not connect to, such as localhost or a private network
+var ErrBlockedAddress = errors.New("webhook address not allowed")
+
+// NewWebhookNotifier creates a new webhook notifier
+func NewWebhookNotifier(cfg *config.Config) *WebhookNotifier {
+	return &WebhookNotifier{
+		config:     cfg,
+		httpClient: newWebhookClient(checkWebhookAddress),
+	}
+}
+
+// newWebhookClient creates an HTTP client that only connects to addresses
+// check accepts. The check runs on the resolved address of every connection,
+// including those made to follow redirects, so a hostname that resolves or
+// redirects to an internal address is refused too. Proxies are not used, since
+// the check would only see the proxy's address.
+func newWebhookClient(check func(address string) error) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			return check(address)
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+	}
+}
+
+// checkWebhookAddress refuses loopback, private, link-local and unspecified addresses
+func checkWebhookAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !validation.IsPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+	return nil
+}
+
+// NotifyNewActivities posts the activities to the rule's webhook URL
+func (n *WebhookNotifier) NotifyNewActivities(ctx context.Context, user *model.User, rule *model.Rule, activities []model.Activity) error {
+	if len(activities) == 0 {
+		return nil
+	}
+
+	if rule.WebhookURL == "" {
+		logger.Warn("Rule has no webhook URL, skipping webhook notification", "rule_id", rule.ID)
+		return nil
+	}
+
+	body, err := json.Marshal(WebhookPayload{
+		RuleID:     rule.ID,
+		RuleName:   rule.Name,
+		UserID:     user.ID,
+		Count:      len(activities),
+		Activities: activities,
+		SentAt:     time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rule.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "PadelAlert-Webhook/1.0")
+
+	if n.config.WebhookSecret != "" {
+		req.Header.Set("X-PadelAlert-Signature", "sha256="+signPayload(n.config.WebhookSecret, body))
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send webhook: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	logger.Info("Webhook notification sent", "user_id", user.ID, "rule_id", rule.ID, "activities", len(activities))
+	return nil
+}
+
+// signPayload computes the hex-encoded HMAC-SHA256 of a payload
+func signPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,122 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rafa-garcia/padel-alert/internal/config"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookNotifier_NotifyNewActivities(t *testing.T) {
+	var payload WebhookPayload
+	var signature string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signature = r.Header.Get("X-PadelAlert-Signature")
+		_ = json.Unmarshal(body, &payload)
+		assert.Equal(t, "sha256="+signPayload("secret", body), signature)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier := newTestWebhookNotifier(&config.Config{WebhookSecret: "secret"})
+	rule := &model.Rule{ID: "rule-1", Name: "Test Rule", WebhookURL: server.URL}
+	activities := []model.Activity{{ID: "activity-1", Name: "Test Match"}}
+
+	err := notifier.NotifyNewActivities(context.Background(), &model.User{ID: "user-1"}, rule, activities)
+	require.NoError(t, err)
+
+	assert.Equal(t, "rule-1", payload.RuleID)
+	assert.Equal(t, "user-1", payload.UserID)
+	assert.Equal(t, 1, payload.Count)
+	assert.Equal(t, "activity-1", payload.Activities[0].ID)
+	assert.NotEmpty(t, signature)
+}
+
+func TestWebhookNotifier_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	notifier := newTestWebhookNotifier(&config.Config{})
+	rule := &model.Rule{ID: "rule-1", WebhookURL: server.URL}
+
+	err := notifier.NotifyNewActivities(context.Background(), &model.User{ID: "user-1"}, rule, []model.Activity{{ID: "activity-1"}})
+	assert.Error(t, err)
+}
+
+// newTestWebhookNotifier creates a webhook notifier that may reach the local
+// test servers the default notifier refuses
+func newTestWebhookNotifier(cfg *config.Config) *WebhookNotifier {
+	notifier := NewWebhookNotifier(cfg)
+	notifier.httpClient = newWebhookClient(func(string) error { return nil })
+	return notifier
+}
+
+func TestWebhookNotifier_BlocksInternalAddresses(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(&config.Config{})
+	activities := []model.Activity{{ID: "activity-1"}}
+
+	// localhost resolves to loopback, which is refused once resolved
+	localhost := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	for _, url := range []string{server.URL, localhost, "http://169.254.169.254/latest/meta-data", "http://10.0.0.1:6379"} {
+		rule := &model.Rule{ID: "rule-1", WebhookURL: url}
+		err := notifier.NotifyNewActivities(context.Background(), &model.User{ID: "user-1"}, rule, activities)
+		assert.ErrorIs(t, err, ErrBlockedAddress, url)
+	}
+	assert.False(t, called)
+}
+
+func TestWebhookNotifier_BlocksRedirectsToInternalAddresses(t *testing.T) {
+	internalCalled := false
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		internalCalled = true
+	}))
+	defer internal.Close()
+
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusTemporaryRedirect)
+	}))
+	defer public.Close()
+
+	// Only the redirecting server counts as public
+	publicAddress := strings.TrimPrefix(public.URL, "http://")
+	notifier := NewWebhookNotifier(&config.Config{})
+	notifier.httpClient = newWebhookClient(func(address string) error {
+		if address == publicAddress {
+			return nil
+		}
+		return checkWebhookAddress(address)
+	})
+
+	rule := &model.Rule{ID: "rule-1", WebhookURL: public.URL}
+	err := notifier.NotifyNewActivities(context.Background(), &model.User{ID: "user-1"}, rule, []model.Activity{{ID: "activity-1"}})
+	assert.ErrorIs(t, err, ErrBlockedAddress)
+	assert.False(t, internalCalled)
+}
+
+func TestCheckWebhookAddress(t *testing.T) {
+	assert.NoError(t, checkWebhookAddress("93.184.216.34:443"))
+	assert.NoError(t, checkWebhookAddress("[2606:4700::1111]:443"))
+
+	assert.ErrorIs(t, checkWebhookAddress("127.0.0.1:80"), ErrBlockedAddress)
+	assert.ErrorIs(t, checkWebhookAddress("[::1]:80"), ErrBlockedAddress)
+	assert.ErrorIs(t, checkWebhookAddress("192.168.0.10:8080"), ErrBlockedAddress)
+	assert.ErrorIs(t, checkWebhookAddress("0.0.0.0:80"), ErrBlockedAddress)
+}
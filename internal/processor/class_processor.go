@@ -3,30 +3,32 @@ package processor
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
-	playtomic "github.com/rafa-garcia/go-playtomic-api/client"
 	"github.com/rafa-garcia/go-playtomic-api/models"
 	"github.com/rafa-garcia/padel-alert/internal/domain/model"
-	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/source"
 	"github.com/rafa-garcia/padel-alert/internal/storage"
 	"github.com/rafa-garcia/padel-alert/internal/transformer"
 )
 
 // ClassProcessor processes class rules
 type ClassProcessor struct {
-	client    *playtomic.Client
-	ruleStore storage.RuleStorage
-	redis     *storage.RedisClient
+	client     source.ActivitySource
+	ruleStore  storage.RuleStorage
+	seen       storage.SeenStorage
+	pagination source.Pagination
+	snapshots  *source.SnapshotCache
 }
 
 // NewClassProcessor creates a new class processor
-func NewClassProcessor(client *playtomic.Client, ruleStore storage.RuleStorage, redis *storage.RedisClient) *ClassProcessor {
+func NewClassProcessor(client source.ActivitySource, ruleStore storage.RuleStorage, seen storage.SeenStorage, pagination source.Pagination, snapshots *source.SnapshotCache) *ClassProcessor {
 	return &ClassProcessor{
-		client:    client,
-		ruleStore: ruleStore,
-		redis:     redis,
+		client:     client,
+		ruleStore:  ruleStore,
+		seen:       seen,
+		pagination: pagination,
+		snapshots:  snapshots,
 	}
 }
 
@@ -36,80 +38,68 @@ func (p *ClassProcessor) Process(ctx context.Context, rule *model.Rule) ([]model
 		return nil, fmt.Errorf("not a class rule")
 	}
 
-	// Create search parameters
-	params := &models.SearchClassesParams{
-		Sort:             "start_date,created_at,ASC",
-		Status:           "PENDING,IN_PROGRESS", // Only active classes
-		TenantIDs:        rule.ClubIDs,
-		IncludeSummary:   true,
-		Size:             100,
-		Page:             0,
-		CourseVisibility: "PUBLIC",
-		FromStartDate:    time.Now().Format("2006-01-02") + "T00:00:00",
-	}
-
-	classes, err := p.client.GetClasses(ctx, params)
-	if err != nil {
-		return nil, fmt.Errorf("fetch classes: %w", err)
-	}
-
-	classActivities, err := transformer.ExternalClassesToActivities(classes)
-	if err != nil {
-		return nil, fmt.Errorf("transform classes: %w", err)
-	}
+	fromDate := time.Now().Format("2006-01-02")
 
 	var activities []model.Activity
 
-	// Filters
-	for _, activity := range classActivities {
-		// Skip classes without available spots
-		if activity.AvailablePlaces <= 0 {
-			continue
+	for _, clubID := range rule.ClubIDs {
+		// Fetch the club's classes, shared with other rules watching the same club
+		classActivities, err := p.snapshots.Get(ctx, source.SnapshotKey{Type: "class", ClubID: clubID, FromDate: fromDate}, func(ctx context.Context) ([]model.Activity, error) {
+			return p.fetchClasses(ctx, clubID, fromDate)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("fetch classes: %w", err)
 		}
 
-		// Apply title filter if specified
-		if rule.TitleContains != nil && *rule.TitleContains != "" {
-			if !containsIgnoreCase(activity.Name, *rule.TitleContains) {
+		// Filters
+		for _, activity := range classActivities {
+			// Apply title filter if specified
+			if !MatchesTitleFilter(activity, rule) {
 				continue
 			}
-		}
-
-		// Apply date filter
-		if !MatchesDateFilter(activity.StartDate, rule) {
-			continue
-		}
 
-		// Check if this class has been seen before
-		if seen, _ := p.checkSeen(ctx, rule.ID, activity.ID); seen {
-			continue
-		}
+			// Apply date filter
+			if !MatchesDateFilter(activity.StartDate, rule) {
+				continue
+			}
 
-		activities = append(activities, activity)
+			// Apply day of week and time of day filter
+			if !MatchesScheduleFilter(activity.StartDate, activity.Club.Address.Timezone, rule) {
+				continue
+			}
 
-		if err := p.markSeen(ctx, rule.ID, activity.ID); err != nil {
-			logger.Error("Failed to mark class as seen", err, "rule_id", rule.ID, "class_id", activity.ID)
+			// Report new activities and seen ones with changes the rule opts into
+			if reported, ok := evaluateSeen(ctx, p.seen, rule, activity); ok {
+				activities = append(activities, reported)
+			}
 		}
 	}
 
 	return activities, nil
 }
 
-// containsIgnoreCase checks if a string contains a substring, ignoring case
-func containsIgnoreCase(s, substr string) bool {
-	return strings.Contains(
-		strings.ToLower(s),
-		strings.ToLower(substr),
-	)
-}
+// fetchClasses fetches every page of a club's active classes as activities
+func (p *ClassProcessor) fetchClasses(ctx context.Context, clubID, fromDate string) ([]model.Activity, error) {
+	classes, _, err := source.FetchAll(ctx, "classes", p.pagination, func(ctx context.Context, page, size int) ([]models.Class, error) {
+		return p.client.GetClasses(ctx, &models.SearchClassesParams{
+			Sort:             "start_date,created_at,ASC",
+			Status:           "PENDING,IN_PROGRESS", // Only active classes
+			TenantIDs:        []string{clubID},
+			IncludeSummary:   true,
+			Size:             size,
+			Page:             page,
+			CourseVisibility: "PUBLIC",
+			FromStartDate:    fromDate + "T00:00:00",
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
 
-// checkSeen checks if a class has been seen before for a rule
-func (p *ClassProcessor) checkSeen(ctx context.Context, ruleID string, classID string) (bool, error) {
-	key := fmt.Sprintf("seen:%s", ruleID)
-	return p.redis.Client.SIsMember(ctx, key, classID).Result()
-}
+	activities, err := transformer.ExternalClassesToActivities(classes)
+	if err != nil {
+		return nil, fmt.Errorf("transform classes: %w", err)
+	}
 
-// markSeen marks a class as seen for a rule
-func (p *ClassProcessor) markSeen(ctx context.Context, ruleID string, classID string) error {
-	key := fmt.Sprintf("seen:%s", ruleID)
-	return p.redis.Client.SAdd(ctx, key, classID).Err()
+	return activities, nil
 }
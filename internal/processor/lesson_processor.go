@@ -3,30 +3,33 @@ package processor
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
-	playtomic "github.com/rafa-garcia/go-playtomic-api/client"
 	"github.com/rafa-garcia/go-playtomic-api/models"
 	"github.com/rafa-garcia/padel-alert/internal/domain/model"
 	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/source"
 	"github.com/rafa-garcia/padel-alert/internal/storage"
 	"github.com/rafa-garcia/padel-alert/internal/transformer"
 )
 
 // LessonProcessor processes tournament/lesson rules
 type LessonProcessor struct {
-	client    *playtomic.Client
-	ruleStore storage.RuleStorage
-	redis     *storage.RedisClient
+	client     source.ActivitySource
+	ruleStore  storage.RuleStorage
+	seen       storage.SeenStorage
+	pagination source.Pagination
+	snapshots  *source.SnapshotCache
 }
 
 // NewLessonProcessor creates a new lesson processor
-func NewLessonProcessor(client *playtomic.Client, ruleStore storage.RuleStorage, redis *storage.RedisClient) *LessonProcessor {
+func NewLessonProcessor(client source.ActivitySource, ruleStore storage.RuleStorage, seen storage.SeenStorage, pagination source.Pagination, snapshots *source.SnapshotCache) *LessonProcessor {
 	return &LessonProcessor{
-		client:    client,
-		ruleStore: ruleStore,
-		redis:     redis,
+		client:     client,
+		ruleStore:  ruleStore,
+		seen:       seen,
+		pagination: pagination,
+		snapshots:  snapshots,
 	}
 }
 
@@ -36,45 +39,26 @@ func (p *LessonProcessor) Process(ctx context.Context, rule *model.Rule) ([]mode
 		return nil, fmt.Errorf("not a lesson rule")
 	}
 
+	fromDate := time.Now().Format("2006-01-02")
+
 	var allActivities []model.Activity
 
-	// We need to make a separate request for each club ID
+	// The lessons endpoint only accepts a single club ID
 	for _, clubID := range rule.ClubIDs {
-		// Create search parameters for each club
-		params := &models.SearchLessonsParams{
-			Sort:                 "start_date,created_at,ASC",
-			TenantID:             clubID,
-			TournamentVisibility: "PUBLIC",
-			Status:               "REGISTRATION_OPEN,REGISTRATION_CLOSED,IN_PROGRESS",
-			Size:                 100,
-			Page:                 0,
-			FromStartDate:        time.Now().Format("2006-01-02") + "T00:00:00",
-		}
-
-		lessons, err := p.client.GetLessons(ctx, params)
+		// Fetch the club's lessons, shared with other rules watching the same club
+		lessonActivities, err := p.snapshots.Get(ctx, source.SnapshotKey{Type: "lesson", ClubID: clubID, FromDate: fromDate}, func(ctx context.Context) ([]model.Activity, error) {
+			return p.fetchLessons(ctx, clubID, fromDate)
+		})
 		if err != nil {
 			logger.Error("Error fetching lessons", err, "club_id", clubID)
 			continue // Skip this club and try the next one
 		}
 
-		lessonActivities, err := transformer.ExternalLessonsToActivities(lessons)
-		if err != nil {
-			logger.Error("Error transforming lessons", err, "club_id", clubID)
-			continue
-		}
-
 		// Filters
 		for _, activity := range lessonActivities {
-			// Skip lessons without available spots
-			if activity.AvailablePlaces <= 0 {
-				continue
-			}
-
 			// Apply title filter if specified
-			if rule.TitleContains != nil && *rule.TitleContains != "" {
-				if !lessonContainsTitle(activity.Name, *rule.TitleContains) {
-					continue
-				}
+			if !MatchesTitleFilter(activity, rule) {
+				continue
 			}
 
 			// Apply date filter
@@ -82,15 +66,14 @@ func (p *LessonProcessor) Process(ctx context.Context, rule *model.Rule) ([]mode
 				continue
 			}
 
-			// Check if this lesson has been seen before
-			if seen, _ := p.checkSeen(ctx, rule.ID, activity.ID); seen {
+			// Apply day of week and time of day filter
+			if !MatchesScheduleFilter(activity.StartDate, activity.Club.Address.Timezone, rule) {
 				continue
 			}
 
-			allActivities = append(allActivities, activity)
-
-			if err := p.markSeen(ctx, rule.ID, activity.ID); err != nil {
-				logger.Error("Failed to mark lesson as seen", err, "rule_id", rule.ID, "lesson_id", activity.ID)
+			// Report new activities and seen ones with changes the rule opts into
+			if reported, ok := evaluateSeen(ctx, p.seen, rule, activity); ok {
+				allActivities = append(allActivities, reported)
 			}
 		}
 	}
@@ -98,22 +81,27 @@ func (p *LessonProcessor) Process(ctx context.Context, rule *model.Rule) ([]mode
 	return allActivities, nil
 }
 
-// lessonContainsTitle checks if a lesson name contains a substring, ignoring case
-func lessonContainsTitle(name, substr string) bool {
-	return strings.Contains(
-		strings.ToLower(name),
-		strings.ToLower(substr),
-	)
-}
+// fetchLessons fetches every page of a club's open lessons as activities
+func (p *LessonProcessor) fetchLessons(ctx context.Context, clubID, fromDate string) ([]model.Activity, error) {
+	lessons, _, err := source.FetchAll(ctx, "lessons", p.pagination, func(ctx context.Context, page, size int) ([]models.Lesson, error) {
+		return p.client.GetLessons(ctx, &models.SearchLessonsParams{
+			Sort:                 "start_date,created_at,ASC",
+			TenantID:             clubID,
+			TournamentVisibility: "PUBLIC",
+			Status:               "REGISTRATION_OPEN,REGISTRATION_CLOSED,IN_PROGRESS",
+			Size:                 size,
+			Page:                 page,
+			FromStartDate:        fromDate + "T00:00:00",
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
 
-// checkSeen checks if a lesson has been seen before for a rule
-func (p *LessonProcessor) checkSeen(ctx context.Context, ruleID, lessonID string) (bool, error) {
-	key := fmt.Sprintf("seen:%s", ruleID)
-	return p.redis.Client.SIsMember(ctx, key, lessonID).Result()
-}
+	activities, err := transformer.ExternalLessonsToActivities(lessons)
+	if err != nil {
+		return nil, fmt.Errorf("transform lessons: %w", err)
+	}
 
-// markSeen marks a lesson as seen for a rule
-func (p *LessonProcessor) markSeen(ctx context.Context, ruleID, lessonID string) error {
-	key := fmt.Sprintf("seen:%s", ruleID)
-	return p.redis.Client.SAdd(ctx, key, lessonID).Err()
+	return activities, nil
 }
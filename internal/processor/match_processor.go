@@ -5,26 +5,29 @@ import (
 	"fmt"
 	"time"
 
-	playtomic "github.com/rafa-garcia/go-playtomic-api/client"
 	"github.com/rafa-garcia/go-playtomic-api/models"
 	"github.com/rafa-garcia/padel-alert/internal/domain/model"
-	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/source"
 	"github.com/rafa-garcia/padel-alert/internal/storage"
 )
 
 // MatchProcessor processes match rules
 type MatchProcessor struct {
-	client    *playtomic.Client
-	ruleStore storage.RuleStorage
-	redis     *storage.RedisClient
+	client     source.ActivitySource
+	ruleStore  storage.RuleStorage
+	seen       storage.SeenStorage
+	pagination source.Pagination
+	snapshots  *source.SnapshotCache
 }
 
 // NewMatchProcessor creates a new match processor
-func NewMatchProcessor(client *playtomic.Client, ruleStore storage.RuleStorage, redis *storage.RedisClient) *MatchProcessor {
+func NewMatchProcessor(client source.ActivitySource, ruleStore storage.RuleStorage, seen storage.SeenStorage, pagination source.Pagination, snapshots *source.SnapshotCache) *MatchProcessor {
 	return &MatchProcessor{
-		client:    client,
-		ruleStore: ruleStore,
-		redis:     redis,
+		client:     client,
+		ruleStore:  ruleStore,
+		seen:       seen,
+		pagination: pagination,
+		snapshots:  snapshots,
 	}
 }
 
@@ -34,113 +37,74 @@ func (p *MatchProcessor) Process(ctx context.Context, rule *model.Rule) ([]model
 		return nil, fmt.Errorf("not a match rule")
 	}
 
-	// Create search parameters
-	params := &models.SearchMatchesParams{
-		Sort:          "start_date,ASC",
-		HasPlayers:    true,
-		SportID:       "PADEL",
-		TenantIDs:     rule.ClubIDs,
-		Visibility:    "VISIBLE",
-		FromStartDate: time.Now().Format("2006-01-02") + "T00:00:00",
-		Size:          100,
-		Page:          0,
-	}
-
-	// Fetch matches
-	matches, err := p.client.GetMatches(ctx, params)
-	if err != nil {
-		return nil, fmt.Errorf("fetch matches: %w", err)
-	}
+	fromDate := time.Now().Format("2006-01-02")
 
 	var activities []model.Activity
 
-	// Process each match
-	for _, m := range matches {
-		// Skip cancelled matches
-		if m.Status == "CANCELED" {
-			continue
-		}
-
-		// Skip matches without available spots
-		if !hasAvailableSpots(m) {
-			continue
-		}
-
-		// Apply ranking filter
-		if !matchesRankingFilter(m, rule) {
-			continue
-		}
-
-		// Apply date filter
-		if !matchesDateFilter(m, rule) {
-			continue
-		}
-
-		// Check if this match has been seen before
-		if seen, _ := p.checkSeen(ctx, rule.ID, m.MatchID); seen {
-			continue
+	for _, clubID := range rule.ClubIDs {
+		// Fetch the club's matches, shared with other rules watching the same club
+		matches, err := p.snapshots.Get(ctx, source.SnapshotKey{Type: "match", ClubID: clubID, FromDate: fromDate}, func(ctx context.Context) ([]model.Activity, error) {
+			return p.fetchMatches(ctx, clubID, fromDate)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("fetch matches: %w", err)
 		}
 
-		// Add to activities
-		activity := convertMatchToActivity(m)
-		activities = append(activities, activity)
-
-		// Mark as seen
-		if err := p.markSeen(ctx, rule.ID, m.MatchID); err != nil {
-			logger.Error("Failed to mark match as seen", err, "rule_id", rule.ID, "match_id", m.MatchID)
+		for _, activity := range matches {
+			// Apply ranking filter
+			if !MatchesLevelFilter(activity, rule) {
+				continue
+			}
+
+			// Apply date filter
+			if !MatchesDateFilter(activity.StartDate, rule) {
+				continue
+			}
+
+			// Apply day of week and time of day filter
+			if !MatchesScheduleFilter(activity.StartDate, activity.Club.Address.Timezone, rule) {
+				continue
+			}
+
+			// Report new activities and seen ones with changes the rule opts into
+			if reported, ok := evaluateSeen(ctx, p.seen, rule, activity); ok {
+				activities = append(activities, reported)
+			}
 		}
 	}
 
 	return activities, nil
 }
 
-// hasAvailableSpots checks if the match has available spots
-func hasAvailableSpots(m models.Match) bool {
-	currentPlayers := 0
-	maxPlayers := 0
-
-	for _, team := range m.Teams {
-		currentPlayers += len(team.Players)
-		maxPlayers += m.MaxPlayersPerTeam
-	}
-
-	return HasAvailableSpots(currentPlayers, maxPlayers)
-}
-
-// matchesRankingFilter checks if the match matches the rule's ranking filter
-func matchesRankingFilter(m models.Match, rule *model.Rule) bool {
-	if rule.MinRanking != nil && m.MinLevel < *rule.MinRanking {
-		return false
-	}
-
-	if rule.MaxRanking != nil && m.MaxLevel > *rule.MaxRanking {
-		return false
-	}
-
-	return true
-}
-
-// matchesDateFilter checks if the match matches the rule's date filter
-func matchesDateFilter(m models.Match, rule *model.Rule) bool {
-	matchTime, err := time.Parse("2006-01-02T15:04:05", m.StartDate)
+// fetchMatches fetches every page of a club's matches and converts the ones
+// that are not cancelled to activities
+func (p *MatchProcessor) fetchMatches(ctx context.Context, clubID, fromDate string) ([]model.Activity, error) {
+	matches, _, err := source.FetchAll(ctx, "matches", p.pagination, func(ctx context.Context, page, size int) ([]models.Match, error) {
+		return p.client.GetMatches(ctx, &models.SearchMatchesParams{
+			Sort:          "start_date,ASC",
+			HasPlayers:    true,
+			SportID:       "PADEL",
+			TenantIDs:     []string{clubID},
+			Visibility:    "VISIBLE",
+			FromStartDate: fromDate + "T00:00:00",
+			Size:          size,
+			Page:          page,
+		})
+	})
 	if err != nil {
-		logger.Error("Failed to parse match date", err, "date", m.StartDate)
-		return false
+		return nil, err
 	}
 
-	return MatchesDateFilter(matchTime, rule)
-}
-
-// checkSeen checks if a match has been seen before for a rule
-func (p *MatchProcessor) checkSeen(ctx context.Context, ruleID string, matchID string) (bool, error) {
-	key := fmt.Sprintf("seen:%s", ruleID)
-	return p.redis.Client.SIsMember(ctx, key, matchID).Result()
-}
+	activities := make([]model.Activity, 0, len(matches))
+	for _, m := range matches {
+		// Skip cancelled matches
+		if m.Status == "CANCELED" {
+			continue
+		}
+		activities = append(activities, convertMatchToActivity(m))
+	}
 
-// markSeen marks a match as seen for a rule
-func (p *MatchProcessor) markSeen(ctx context.Context, ruleID string, matchID string) error {
-	key := fmt.Sprintf("seen:%s", ruleID)
-	return p.redis.Client.SAdd(ctx, key, matchID).Err()
+	return activities, nil
 }
 
 // convertMatchToActivity converts a Playtomic match to an Activity
@@ -205,6 +169,7 @@ func convertMatchToActivity(m models.Match) model.Activity {
 				PostalCode: m.Tenant.Address.PostalCode,
 				City:       m.Tenant.Address.City,
 				Country:    m.Tenant.Address.Country,
+				Timezone:   m.Tenant.Address.Timezone,
 			},
 			Link: fmt.Sprintf("https://playtomic.io/club/%s", m.Tenant.TenantID),
 		},
@@ -2,12 +2,13 @@ package processor
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
-	playtomic "github.com/rafa-garcia/go-playtomic-api/client"
 	"github.com/rafa-garcia/padel-alert/internal/domain/model"
 	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/source"
 	"github.com/rafa-garcia/padel-alert/internal/storage"
 )
 
@@ -34,6 +35,148 @@ func MatchesDateFilter(date time.Time, rule *model.Rule) bool {
 	return true
 }
 
+// evaluateSeen decides whether an activity that passed the rule's filters
+// should be reported. New activities are reported when they have available
+// spots. Seen activities are re-reported, tagged with the change type, when
+// they changed in a way the rule opts into. Reported activities are not marked
+// as seen here: the scheduler does that once the notification is delivered, so
+// they are reported again by every check until then. Fingerprints of seen
+// activities that are not reported are kept current, including while they are
+// full, so that a reopened spot can be detected.
+func evaluateSeen(ctx context.Context, seen storage.SeenStorage, rule *model.Rule, activity model.Activity) (model.Activity, bool) {
+	previous, wasSeen, err := seen.GetSeen(ctx, rule.ID, activity.ID)
+	if err != nil {
+		logger.Error("Failed to check seen activity", err, "rule_id", rule.ID, "activity_id", activity.ID)
+		return activity, false
+	}
+
+	if !wasSeen {
+		return activity, activity.AvailablePlaces > 0
+	}
+
+	current := activity.Fingerprint()
+
+	// Activities seen before fingerprints were recorded start tracking changes now
+	if previous == nil {
+		markSeen(ctx, seen, rule, activity)
+		return activity, false
+	}
+
+	if current == *previous {
+		return activity, false
+	}
+
+	change := rule.ReAlertChange(current.Changes(*previous))
+	if activity.AvailablePlaces <= 0 || change == "" {
+		markSeen(ctx, seen, rule, activity)
+		return activity, false
+	}
+
+	activity.ChangeType = change
+	return activity, true
+}
+
+// markSeen records an activity as seen for a rule, logging failures
+func markSeen(ctx context.Context, seen storage.SeenStorage, rule *model.Rule, activity model.Activity) {
+	if err := seen.MarkSeen(ctx, rule.ID, activity); err != nil {
+		logger.Error("Failed to mark activity as seen", err, "rule_id", rule.ID, "activity_id", activity.ID)
+	}
+}
+
+// MatchesLevelFilter checks if an activity's level range fits the rule's ranking range
+func MatchesLevelFilter(activity model.Activity, rule *model.Rule) bool {
+	if rule.MinRanking != nil && activity.MinLevel < *rule.MinRanking {
+		return false
+	}
+
+	if rule.MaxRanking != nil && activity.MaxLevel > *rule.MaxRanking {
+		return false
+	}
+
+	return true
+}
+
+// MatchesTitleFilter checks if an activity's name contains the rule's title filter, ignoring case
+func MatchesTitleFilter(activity model.Activity, rule *model.Rule) bool {
+	if rule.TitleContains == nil || *rule.TitleContains == "" {
+		return true
+	}
+
+	return strings.Contains(strings.ToLower(activity.Name), strings.ToLower(*rule.TitleContains))
+}
+
+// MatchesScheduleFilter checks if a start time falls on one of the rule's days
+// of the week and within one of its times of day, evaluated in the club's
+// local timezone
+func MatchesScheduleFilter(start time.Time, timezone string, rule *model.Rule) bool {
+	if len(rule.DaysOfWeek) == 0 && len(rule.TimeOfDay) == 0 {
+		return true
+	}
+
+	local := start.In(clubLocation(timezone))
+
+	if len(rule.DaysOfWeek) > 0 {
+		matched := false
+		for _, name := range rule.DaysOfWeek {
+			day, err := model.ParseWeekday(name)
+			if err != nil {
+				logger.Warn("Ignoring invalid day of week", "rule_id", rule.ID, "day", name)
+				continue
+			}
+			if local.Weekday() == day {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(rule.TimeOfDay) > 0 {
+		matched := false
+		for _, value := range rule.TimeOfDay {
+			window, err := model.ParseTimeOfDay(value)
+			if err != nil {
+				logger.Warn("Ignoring invalid time of day", "rule_id", rule.ID, "time_of_day", value)
+				continue
+			}
+			if window.Contains(local) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// locations caches loaded club timezones
+var locations sync.Map
+
+// clubLocation resolves a club's IANA timezone, falling back to UTC
+func clubLocation(timezone string) *time.Location {
+	if timezone == "" {
+		return time.UTC
+	}
+
+	if loc, ok := locations.Load(timezone); ok {
+		return loc.(*time.Location)
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		logger.Warn("Unknown club timezone, using UTC", "timezone", timezone)
+		loc = time.UTC
+	}
+
+	locations.Store(timezone, loc)
+	return loc
+}
+
 // Processor processes rules for all activity types
 type Processor struct {
 	matchProcessor  ProcessorInterface
@@ -42,11 +185,11 @@ type Processor struct {
 }
 
 // NewProcessor creates a new processor that handles all activity types
-func NewProcessor(client *playtomic.Client, ruleStore storage.RuleStorage, redis *storage.RedisClient) *Processor {
+func NewProcessor(client source.ActivitySource, ruleStore storage.RuleStorage, seen storage.SeenStorage, pagination source.Pagination, snapshots *source.SnapshotCache) *Processor {
 	return &Processor{
-		matchProcessor:  NewMatchProcessor(client, ruleStore, redis),
-		classProcessor:  NewClassProcessor(client, ruleStore, redis),
-		lessonProcessor: NewLessonProcessor(client, ruleStore, redis),
+		matchProcessor:  NewMatchProcessor(client, ruleStore, seen, pagination, snapshots),
+		classProcessor:  NewClassProcessor(client, ruleStore, seen, pagination, snapshots),
+		lessonProcessor: NewLessonProcessor(client, ruleStore, seen, pagination, snapshots),
 	}
 }
 
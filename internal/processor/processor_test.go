@@ -5,9 +5,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/source"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type mockMatchProcessor struct {
@@ -145,3 +150,189 @@ func TestCommonHelperFunctions(t *testing.T) {
 		assert.True(t, MatchesDateFilter(tomorrow, noConstraintRule))
 	})
 }
+
+func TestMatchesScheduleFilter(t *testing.T) {
+	// Saturday 7 June 2025, 08:00 UTC is 10:00 in Madrid
+	saturdayMorning := time.Date(2025, 6, 7, 8, 0, 0, 0, time.UTC)
+	// Tuesday 3 June 2025, 17:00 UTC is 19:00 in Madrid
+	tuesdayEvening := time.Date(2025, 6, 3, 17, 0, 0, 0, time.UTC)
+
+	rule := &model.Rule{
+		DaysOfWeek: []string{"monday", "tuesday", "wednesday", "thursday", "friday"},
+		TimeOfDay:  []string{"18:00-21:30"},
+	}
+
+	assert.True(t, MatchesScheduleFilter(tuesdayEvening, "Europe/Madrid", rule))
+	assert.False(t, MatchesScheduleFilter(saturdayMorning, "Europe/Madrid", rule))
+
+	// 17:00 is outside the range in UTC but inside it in Madrid
+	assert.False(t, MatchesScheduleFilter(tuesdayEvening, "", rule))
+
+	bucketRule := &model.Rule{TimeOfDay: []string{"morning"}}
+	assert.True(t, MatchesScheduleFilter(saturdayMorning, "Europe/Madrid", bucketRule))
+	assert.False(t, MatchesScheduleFilter(tuesdayEvening, "Europe/Madrid", bucketRule))
+
+	assert.True(t, MatchesScheduleFilter(saturdayMorning, "Europe/Madrid", &model.Rule{}))
+}
+
+func TestMatchesLevelFilter(t *testing.T) {
+	minRanking := 3.0
+	maxRanking := 4.5
+	rule := &model.Rule{MinRanking: &minRanking, MaxRanking: &maxRanking}
+
+	assert.True(t, MatchesLevelFilter(model.Activity{MinLevel: 3.0, MaxLevel: 4.0}, rule))
+	assert.False(t, MatchesLevelFilter(model.Activity{MinLevel: 2.0, MaxLevel: 4.0}, rule))
+	assert.False(t, MatchesLevelFilter(model.Activity{MinLevel: 3.5, MaxLevel: 5.0}, rule))
+	assert.True(t, MatchesLevelFilter(model.Activity{MinLevel: 0, MaxLevel: 7}, &model.Rule{}))
+}
+
+func TestMatchesTitleFilter(t *testing.T) {
+	title := "beginner"
+
+	assert.True(t, MatchesTitleFilter(model.Activity{Name: "Beginner Padel Class"}, &model.Rule{TitleContains: &title}))
+	assert.False(t, MatchesTitleFilter(model.Activity{Name: "Advanced Padel Class"}, &model.Rule{TitleContains: &title}))
+	assert.True(t, MatchesTitleFilter(model.Activity{Name: "Advanced Padel Class"}, &model.Rule{}))
+}
+
+// newFixtureProcessor creates a processor backed by the source fixtures and
+// miniredis, returning the seen storage it uses
+func newFixtureProcessor(t *testing.T) (*Processor, storage.SeenStorage) {
+	mini := miniredis.RunT(t)
+	redisClient := &storage.RedisClient{
+		Client: redis.NewClient(&redis.Options{Addr: mini.Addr()}),
+	}
+
+	activitySource, err := source.NewFileSource("../source/testdata")
+	require.NoError(t, err)
+
+	seen := storage.NewRedisSeenStorage(redisClient)
+	return NewProcessor(activitySource, nil, seen, source.DefaultPagination, source.NewSnapshotCache(time.Minute)), seen
+}
+
+func TestProcessor_Process_FileSource(t *testing.T) {
+	p, seen := newFixtureProcessor(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		rule     *model.Rule
+		expected []string
+	}{
+		{
+			name:     "Matches skip cancelled",
+			rule:     &model.Rule{ID: "rule-match", Type: "match", ClubIDs: []string{"club-1", "club-2"}},
+			expected: []string{"match-1", "match-3"},
+		},
+		{
+			name:     "Classes",
+			rule:     &model.Rule{ID: "rule-class", Type: "class", ClubIDs: []string{"club-1"}},
+			expected: []string{"class-1"},
+		},
+		{
+			name:     "Lessons",
+			rule:     &model.Rule{ID: "rule-lesson", Type: "lesson", ClubIDs: []string{"club-1"}},
+			expected: []string{"lesson-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			activities, err := p.Process(ctx, tt.rule)
+			require.NoError(t, err)
+
+			var ids []string
+			for _, activity := range activities {
+				ids = append(ids, activity.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+
+			// Activities are reported until they are delivered, then only once per rule
+			again, err := p.Process(ctx, tt.rule)
+			require.NoError(t, err)
+			assert.Equal(t, activities, again)
+
+			for _, activity := range activities {
+				require.NoError(t, seen.MarkSeen(ctx, tt.rule.ID, activity))
+			}
+			activities, err = p.Process(ctx, tt.rule)
+			require.NoError(t, err)
+			assert.Empty(t, activities)
+		})
+	}
+}
+
+func TestEvaluateSeen_ReAlerts(t *testing.T) {
+	mini := miniredis.RunT(t)
+	seen := storage.NewRedisSeenStorage(&storage.RedisClient{
+		Client: redis.NewClient(&redis.Options{Addr: mini.Addr()}),
+	})
+	ctx := context.Background()
+
+	rule := &model.Rule{ID: "rule-1", ReAlertOn: []string{model.ChangeSpotReopened}}
+	activity := model.Activity{
+		ID:              "match-1",
+		StartDate:       time.Now().Add(24 * time.Hour),
+		AvailablePlaces: 1,
+		Price:           "10 EUR",
+	}
+
+	// New activities with spots are reported, and again until delivered
+	reported, ok := evaluateSeen(ctx, seen, rule, activity)
+	assert.True(t, ok)
+	assert.Empty(t, reported.ChangeType)
+
+	_, ok = evaluateSeen(ctx, seen, rule, activity)
+	assert.True(t, ok)
+	require.NoError(t, seen.MarkSeen(ctx, rule.ID, reported))
+
+	// Unchanged activities are not reported again
+	_, ok = evaluateSeen(ctx, seen, rule, activity)
+	assert.False(t, ok)
+
+	// The activity fills up, which is recorded but not reported
+	full := activity
+	full.AvailablePlaces = 0
+	_, ok = evaluateSeen(ctx, seen, rule, full)
+	assert.False(t, ok)
+
+	// A reopened spot is reported with its change type
+	reported, ok = evaluateSeen(ctx, seen, rule, activity)
+	assert.True(t, ok)
+	assert.Equal(t, model.ChangeSpotReopened, reported.ChangeType)
+	require.NoError(t, seen.MarkSeen(ctx, rule.ID, reported))
+
+	// Changes the rule doesn't opt into are not reported
+	cheaper := activity
+	cheaper.Price = "8 EUR"
+	_, ok = evaluateSeen(ctx, seen, rule, cheaper)
+	assert.False(t, ok)
+
+	rule.ReAlertOn = []string{model.ChangePriceDropped}
+	cheaper.Price = "6 EUR"
+	reported, ok = evaluateSeen(ctx, seen, rule, cheaper)
+	assert.True(t, ok)
+	assert.Equal(t, model.ChangePriceDropped, reported.ChangeType)
+}
+
+func TestEvaluateSeen_LegacyEntries(t *testing.T) {
+	mini := miniredis.RunT(t)
+	seen := storage.NewRedisSeenStorage(&storage.RedisClient{
+		Client: redis.NewClient(&redis.Options{Addr: mini.Addr()}),
+	})
+	ctx := context.Background()
+
+	// Entries migrated from legacy sets have no fingerprint
+	_, err := mini.ZAdd("seen:rule-1", float64(time.Now().Unix()), "match-1")
+	require.NoError(t, err)
+
+	rule := &model.Rule{ID: "rule-1", ReAlertOn: model.ChangeTypes}
+	activity := model.Activity{ID: "match-1", StartDate: time.Now(), AvailablePlaces: 1}
+
+	_, ok := evaluateSeen(ctx, seen, rule, activity)
+	assert.False(t, ok)
+
+	fingerprint, wasSeen, err := seen.GetSeen(ctx, "rule-1", "match-1")
+	require.NoError(t, err)
+	assert.True(t, wasSeen)
+	assert.NotNil(t, fingerprint)
+}
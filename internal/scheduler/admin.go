@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
+)
+
+// runRuleTimeout bounds rules run on demand, so that the admin API can answer
+// before the server's 15 second write timeout
+const runRuleTimeout = 10 * time.Second
+
+// RunRule processes a rule now, however long until it is due, and schedules
+// its next check from now. It returns storage.ErrRuleLeased if this or another
+// replica is already processing the rule. Once started, processing is not
+// stopped by ctx being cancelled but gives up after runRuleTimeout, and the
+// rule is always rescheduled.
+func (s *Scheduler) RunRule(ctx context.Context, ruleID string) (*model.Rule, error) {
+	if !s.startRule(ruleID) {
+		return nil, storage.ErrRuleLeased
+	}
+	defer s.finishRule(ruleID)
+
+	if err := s.ruleStore.ClaimRule(ctx, ruleID, s.owner, s.lease()); err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	logger.Info("Running rule on demand", "rule_id", ruleID)
+
+	leaseCtx, release := s.holdLease(ctx, ruleID)
+	runCtx, cancel := context.WithTimeout(leaseCtx, runRuleTimeout)
+	defer cancel()
+
+	rule, err := s.processor.processRule(runCtx, ruleID)
+	release()
+	s.completeRule(ctx, ruleID, s.nextRun(rule, time.Now()))
+	if err != nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		// Failed calls may not say that they ran out of time
+		return nil, fmt.Errorf("run rule: %w: %w", context.DeadlineExceeded, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// Pause stops every replica from processing rules and digests until Resume
+// is called. Rules run on demand are still processed.
+func (s *Scheduler) Pause(ctx context.Context) error {
+	if s.state == nil {
+		return fmt.Errorf("scheduler state storage not available")
+	}
+
+	if err := s.state.Pause(ctx, time.Now()); err != nil {
+		return err
+	}
+
+	logger.Warn("Scheduler paused")
+	return nil
+}
+
+// Resume lets every replica process rules and digests again. Rules that came
+// due while paused are picked up on the next tick.
+func (s *Scheduler) Resume(ctx context.Context) error {
+	if s.state == nil {
+		return fmt.Errorf("scheduler state storage not available")
+	}
+
+	if err := s.state.Resume(ctx); err != nil {
+		return err
+	}
+
+	logger.Info("Scheduler resumed")
+	return nil
+}
+
+// PausedAt returns when the scheduler was paused, or nil if it is running
+func (s *Scheduler) PausedAt(ctx context.Context) (*time.Time, error) {
+	if s.state == nil {
+		return nil, nil
+	}
+	return s.state.PausedAt(ctx)
+}
+
+// paused reports whether the scheduler is paused. If the pause flag cannot be
+// read the scheduler keeps running, since claiming rules needs Redis anyway.
+func (s *Scheduler) paused(ctx context.Context) bool {
+	pausedAt, err := s.PausedAt(ctx)
+	if err != nil {
+		logger.Error("Failed to check whether the scheduler is paused", err)
+		return false
+	}
+
+	if pausedAt != nil {
+		logger.Debug("Scheduler paused, skipping tick", "paused_at", pausedAt.Format(time.RFC3339))
+		return true
+	}
+	return false
+}
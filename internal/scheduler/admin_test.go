@@ -0,0 +1,165 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rafa-garcia/padel-alert/internal/config"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
+	"github.com/rafa-garcia/padel-alert/internal/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_RunRule(t *testing.T) {
+	mockStorage := new(testutil.MockRuleStorage)
+	testProcessor := newTestRuleProcessor()
+	scheduler := &Scheduler{
+		config:    &config.Config{CheckInterval: 300},
+		ruleStore: mockStorage,
+		owner:     "replica-1",
+		processor: testProcessor,
+		inFlight:  make(map[string]struct{}),
+	}
+
+	mockStorage.On("ClaimRule", mock.Anything, "rule-1", "replica-1", defaultLease).Return(nil)
+	mockStorage.On("CompleteScheduledRule", mock.Anything, "rule-1", "replica-1", mock.MatchedBy(func(next time.Time) bool {
+		return next.After(time.Now().Add(299 * time.Second))
+	})).Return(true, nil)
+
+	// A cancelled request does not stop a run that has started
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rule, err := scheduler.RunRule(ctx, "rule-1")
+	require.NoError(t, err)
+	assert.Equal(t, "rule-1", rule.ID)
+	assert.Equal(t, []string{"rule-1"}, testProcessor.processedIDs)
+	assert.Empty(t, scheduler.inFlight)
+	mockStorage.AssertExpectations(t)
+}
+
+// deadlineProcessor records the deadline of the context each rule is processed with
+type deadlineProcessor struct {
+	deadline time.Time
+	err      error
+}
+
+func (p *deadlineProcessor) processRule(ctx context.Context, ruleID string) (*model.Rule, error) {
+	p.deadline, _ = ctx.Deadline()
+	return nil, p.err
+}
+
+func TestScheduler_RunRule_Timeout(t *testing.T) {
+	mockStorage := new(testutil.MockRuleStorage)
+	processor := &deadlineProcessor{}
+	scheduler := &Scheduler{
+		config:    &config.Config{CheckInterval: 300},
+		ruleStore: mockStorage,
+		owner:     "replica-1",
+		processor: processor,
+		inFlight:  make(map[string]struct{}),
+	}
+
+	mockStorage.On("ClaimRule", mock.Anything, "rule-1", "replica-1", defaultLease).Return(nil)
+	mockStorage.On("CompleteScheduledRule", mock.MatchedBy(func(ctx context.Context) bool {
+		// Rescheduling is not cut short by the run's timeout
+		_, ok := ctx.Deadline()
+		return !ok
+	}), "rule-1", "replica-1", mock.Anything).Return(true, nil)
+
+	start := time.Now()
+	_, err := scheduler.RunRule(context.Background(), "rule-1")
+	require.NoError(t, err)
+
+	// The run must end before the HTTP server gives up writing the response
+	assert.WithinDuration(t, start.Add(runRuleTimeout), processor.deadline, time.Second)
+	assert.Less(t, runRuleTimeout, 15*time.Second)
+	mockStorage.AssertExpectations(t)
+
+	// Errors from a run that failed before its deadline are passed on as they are
+	processor.err = errors.New("fetch activities: connection refused")
+	_, err = scheduler.RunRule(context.Background(), "rule-1")
+	assert.Equal(t, processor.err, err)
+}
+
+func TestScheduler_RunRule_Busy(t *testing.T) {
+	mockStorage := new(testutil.MockRuleStorage)
+	testProcessor := newTestRuleProcessor()
+	scheduler := &Scheduler{
+		config:    &config.Config{CheckInterval: 300},
+		ruleStore: mockStorage,
+		owner:     "replica-1",
+		processor: testProcessor,
+		inFlight:  make(map[string]struct{}),
+	}
+	ctx := context.Background()
+
+	// Being processed by this replica
+	require.True(t, scheduler.startRule("rule-1"))
+	_, err := scheduler.RunRule(ctx, "rule-1")
+	assert.ErrorIs(t, err, storage.ErrRuleLeased)
+	scheduler.finishRule("rule-1")
+
+	// Leased by another replica
+	mockStorage.On("ClaimRule", mock.Anything, "rule-1", "replica-1", defaultLease).Return(storage.ErrRuleLeased)
+	_, err = scheduler.RunRule(ctx, "rule-1")
+	assert.ErrorIs(t, err, storage.ErrRuleLeased)
+
+	assert.Empty(t, testProcessor.processedIDs)
+	mockStorage.AssertNotCalled(t, "CompleteScheduledRule", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestScheduler_Pause(t *testing.T) {
+	mini, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mini.Close()
+
+	redisClient := &storage.RedisClient{Client: redis.NewClient(&redis.Options{Addr: mini.Addr()})}
+	ruleStore := storage.NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+
+	require.NoError(t, ruleStore.ScheduleRule(ctx, "rule-1", time.Now().Add(-time.Minute)))
+
+	testProcessor := newTestRuleProcessor()
+	newReplica := func(owner string) *Scheduler {
+		return &Scheduler{
+			config:     &config.Config{CheckInterval: 300},
+			ruleStore:  ruleStore,
+			owner:      owner,
+			processor:  testProcessor,
+			workerPool: NewWorkerPool(1, 10),
+			stopCh:     make(chan struct{}),
+			inFlight:   make(map[string]struct{}),
+			state:      storage.NewRedisSchedulerStorage(redisClient),
+		}
+	}
+	replica1, replica2 := newReplica("replica-1"), newReplica("replica-2")
+	replica2.workerPool.Start()
+	defer replica2.workerPool.Stop()
+
+	// Pausing one replica pauses them all, but they keep ticking
+	require.NoError(t, replica1.Pause(ctx))
+	replica2.tick()
+	assert.Empty(t, testProcessor.processedIDs)
+
+	replica2.running = true
+	details, err := replica2.CheckHealth(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, details["paused_at"])
+
+	require.NoError(t, replica1.Resume(ctx))
+	replica2.tick()
+
+	assert.Eventually(t, func() bool {
+		testProcessor.mu.Lock()
+		defer testProcessor.mu.Unlock()
+		return len(testProcessor.processedIDs) == 1
+	}, time.Second, 10*time.Millisecond)
+}
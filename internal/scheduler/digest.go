@@ -0,0 +1,270 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/notification"
+)
+
+// digestRetryDelay is how long a digest that failed to send waits before the next attempt
+const digestRetryDelay = 5 * time.Minute
+
+// DigestProcessor sends the digests accumulated for a user
+type DigestProcessor interface {
+	sendDigest(ctx context.Context, userID string, now time.Time) error
+}
+
+// addToDigest adds activities to the rule owner's digest, due at the rule's
+// next delivery time. Once the rule has been checked, entries for activities
+// it no longer reports are dropped. Rules fall back to immediate delivery
+// without digest storage.
+func (p *ruleProcessor) addToDigest(ctx context.Context, rule *model.Rule, activities []model.Activity, checked bool) {
+	if p.digests != nil && checked {
+		p.pruneDigest(ctx, rule, activities)
+	}
+
+	if len(activities) == 0 {
+		logger.Info("No activities found for rule", "rule_id", rule.ID)
+		return
+	}
+
+	if p.digests == nil {
+		rule.NextDeliveryAt = p.deliver(ctx, rule, activities, checked)
+		return
+	}
+
+	now := time.Now()
+	due := rule.Digest.NextDelivery(now)
+
+	entries := make([]model.DigestEntry, 0, len(activities))
+	for _, activity := range activities {
+		entries = append(entries, model.DigestEntry{
+			RuleID:    rule.ID,
+			RuleName:  rule.Name,
+			Activity:  activity,
+			MatchedAt: now,
+			DueAt:     due,
+		})
+	}
+
+	if err := p.digests.AddDigestEntries(ctx, rule.UserID, entries); err != nil {
+		logger.Error("Failed to add activities to digest", err, "rule_id", rule.ID)
+		return
+	}
+
+	logger.Info("Activities added to digest", "rule_id", rule.ID, "activities", len(activities), "due", due.Format(time.RFC3339))
+}
+
+// pruneDigest drops the rule's digest entries for activities its latest check
+// no longer reports, because they filled up, stopped matching or were
+// cancelled. Activities are reported on every check until their digest is sent
+func (p *ruleProcessor) pruneDigest(ctx context.Context, rule *model.Rule, reported []model.Activity) {
+	entries, err := p.digests.GetDigestEntries(ctx, rule.UserID)
+	if err != nil {
+		logger.Error("Failed to get digest entries", err, "rule_id", rule.ID)
+		return
+	}
+
+	found := make(map[string]bool, len(reported))
+	for _, activity := range reported {
+		found[activity.ID] = true
+	}
+
+	var stale []model.DigestEntry
+	for _, entry := range entries {
+		if entry.RuleID != rule.ID || found[entry.Activity.ID] {
+			continue
+		}
+		stale = append(stale, entry)
+	}
+
+	if len(stale) == 0 {
+		return
+	}
+
+	if err := p.digests.RemoveDigestEntries(ctx, rule.UserID, stale); err != nil {
+		logger.Error("Failed to drop unavailable digest entries", err, "rule_id", rule.ID)
+		return
+	}
+
+	logger.Info("Dropped digest entries no longer available", "rule_id", rule.ID, "activities", len(stale))
+}
+
+// sendDigest emails a user the digest entries due by now, marking their
+// activities as seen once sent. Entries whose rule was deleted, or whose
+// activity has started or filled up since it was matched, are dropped.
+func (p *ruleProcessor) sendDigest(ctx context.Context, userID string, now time.Time) error {
+	if p.digests == nil {
+		return nil
+	}
+
+	entries, err := p.digests.TakeDueDigest(ctx, userID, now)
+	if err != nil {
+		return err
+	}
+
+	digestNotifier, err := p.digestNotifier()
+	if err != nil {
+		p.retryDigest(ctx, userID, entries, now)
+		return err
+	}
+
+	// Entries are grouped by recipient, since rules without a user account
+	// each carry their own email
+	rules := make(map[string]*model.Rule)
+	recipients := make(map[string]*model.User)
+	byEmail := make(map[string][]model.DigestEntry)
+	var order []string
+
+	for _, entry := range entries {
+		rule, ok := rules[entry.RuleID]
+		if !ok {
+			rule, err = p.ruleStore.GetRule(ctx, entry.RuleID)
+			if err != nil {
+				logger.Warn("Dropping digest entry for missing rule", "rule_id", entry.RuleID, "error", err.Error())
+				rule = nil
+			}
+			rules[entry.RuleID] = rule
+		}
+
+		if rule == nil || !p.stillAvailable(ctx, entry, now) {
+			continue
+		}
+
+		user, ok := recipients[rule.ID]
+		if !ok {
+			user = p.recipient(ctx, rule)
+			recipients[rule.ID] = user
+		}
+
+		if _, ok := byEmail[user.Email]; !ok {
+			order = append(order, user.Email)
+		}
+		byEmail[user.Email] = append(byEmail[user.Email], entry)
+	}
+
+	var sendErr error
+	for _, email := range order {
+		recipientEntries := byEmail[email]
+		user := recipients[recipientEntries[0].RuleID]
+		digest := model.NewDigest(user, recipientEntries)
+
+		err := digestNotifier.NotifyDigest(ctx, user, digest)
+		countNotification(model.ChannelEmail, err)
+		p.recordDigest(ctx, rules, recipientEntries, err)
+		if err != nil {
+			sendErr = err
+			p.retryDigest(ctx, userID, recipientEntries, now)
+			continue
+		}
+
+		for _, entry := range recipientEntries {
+			p.markDelivered(ctx, entry.RuleID, entry.Activity)
+		}
+		logger.Info("Digest sent", "user_id", userID, "activities", digest.Count)
+	}
+
+	return sendErr
+}
+
+// stillAvailable checks that a digest entry's activity has not started and,
+// as far as the latest check of its rule knows, still has free spots
+func (p *ruleProcessor) stillAvailable(ctx context.Context, entry model.DigestEntry, now time.Time) bool {
+	if entry.Activity.StartDate.Before(now) {
+		return false
+	}
+
+	if p.seen == nil {
+		return true
+	}
+
+	fingerprint, _, err := p.seen.GetSeen(ctx, entry.RuleID, entry.Activity.ID)
+	if err != nil || fingerprint == nil {
+		return true
+	}
+
+	return fingerprint.AvailablePlaces > 0
+}
+
+// recordDigest adds a digest to the notification history of each rule it covers
+func (p *ruleProcessor) recordDigest(ctx context.Context, rules map[string]*model.Rule, entries []model.DigestEntry, err error) {
+	byRule := make(map[string][]model.Activity)
+	for _, entry := range entries {
+		byRule[entry.RuleID] = append(byRule[entry.RuleID], entry.Activity)
+	}
+
+	for ruleID, activities := range byRule {
+		p.recordNotification(ctx, rules[ruleID], activities, channelResult{Channel: model.ChannelEmail, Err: err})
+	}
+}
+
+// digestNotifier returns the email notifier that sends digests
+func (p *ruleProcessor) digestNotifier() (notification.DigestNotifier, error) {
+	notifier, ok := p.notifiers.Get(model.ChannelEmail)
+	if !ok {
+		return nil, fmt.Errorf("no notifier registered for channel %q", model.ChannelEmail)
+	}
+	digestNotifier, ok := notifier.(notification.DigestNotifier)
+	if !ok {
+		return nil, fmt.Errorf("notifier for channel %q does not support digests", model.ChannelEmail)
+	}
+	return digestNotifier, nil
+}
+
+// retryDigest puts entries that failed to send back in the digest for a later attempt
+func (p *ruleProcessor) retryDigest(ctx context.Context, userID string, entries []model.DigestEntry, now time.Time) {
+	retry := make([]model.DigestEntry, len(entries))
+	for i, entry := range entries {
+		entry.DueAt = now.Add(digestRetryDelay)
+		retry[i] = entry
+	}
+
+	if err := p.digests.AddDigestEntries(ctx, userID, retry); err != nil {
+		logger.Error("Failed to requeue digest entries", err, "user_id", userID)
+	}
+}
+
+// processDigests sends the digests that are due, sharing the worker pool with rule checks
+func (s *Scheduler) processDigests() {
+	if s.digests == nil || s.digestStore == nil {
+		return
+	}
+
+	ctx := context.Background()
+
+	limit := min(s.batchSize(), s.workerPool.Free())
+	if limit <= 0 {
+		return
+	}
+
+	users, err := s.digestStore.DueDigestUsers(ctx, time.Now(), limit)
+	if err != nil {
+		logger.Error("Failed to get due digests", err)
+		return
+	}
+
+	for _, userID := range users {
+		userID := userID
+		key := "digest:" + userID
+
+		if !s.startRule(key) {
+			continue
+		}
+
+		submitted := s.workerPool.TrySubmit(func() {
+			defer s.finishRule(key)
+
+			if err := s.digests.sendDigest(ctx, userID, time.Now()); err != nil {
+				logger.Error("Failed to send digest", err, "user_id", userID)
+			}
+		})
+		if !submitted {
+			s.finishRule(key)
+			return
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rafa-garcia/padel-alert/internal/config"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/notification"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
+	"github.com/rafa-garcia/padel-alert/internal/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newDigestTestRedis(t *testing.T) (*miniredis.Miniredis, *storage.RedisClient) {
+	mini, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mini.Close)
+
+	return mini, &storage.RedisClient{Client: redis.NewClient(&redis.Options{Addr: mini.Addr()})}
+}
+
+func TestRuleProcessor_ProcessRule_Digest(t *testing.T) {
+	mini, redisClient := newDigestTestRedis(t)
+
+	mockRuleStorage := new(testutil.MockRuleStorage)
+	mockEmailNotifier := new(testutil.MockNotifier)
+	mockProcessor := new(testutil.MockProcessor)
+
+	rule := &model.Rule{
+		ID:      "test-rule-id",
+		UserID:  "test-user-id",
+		Email:   "test@example.com",
+		Type:    "match",
+		Name:    "Test Rule",
+		ClubIDs: []string{"club-1"},
+		Active:  true,
+		Digest:  &model.DigestSchedule{Mode: model.DigestDaily, Time: "08:00"},
+	}
+
+	activities := []model.Activity{{ID: "activity-1", StartDate: time.Now().Add(48 * time.Hour)}}
+
+	mockRuleStorage.On("GetRule", mock.Anything, "test-rule-id").Return(rule, nil)
+	mockRuleStorage.On("UpdateRuleState", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockProcessor.On("Process", mock.Anything, rule).Return(activities, nil)
+
+	processor := &ruleProcessor{
+		config:    &config.Config{},
+		ruleStore: mockRuleStorage,
+		digests:   storage.NewRedisDigestStorage(redisClient),
+		notifiers: newTestRegistry(map[string]notification.Notifier{model.ChannelEmail: mockEmailNotifier}),
+		processor: mockProcessor,
+	}
+
+	_, err := processor.processRule(context.Background(), "test-rule-id")
+	require.NoError(t, err)
+
+	mockEmailNotifier.AssertNotCalled(t, "NotifyNewActivities")
+	assert.True(t, mini.Exists("digest:test-user-id"))
+
+	score, err := mini.ZScore("digests:schedule", "test-user-id")
+	require.NoError(t, err)
+	assert.Equal(t, float64(rule.Digest.NextDelivery(time.Now()).Unix()), score)
+}
+
+func TestRuleProcessor_ProcessRule_DigestDropsFilledActivities(t *testing.T) {
+	_, redisClient := newDigestTestRedis(t)
+	ctx := context.Background()
+
+	digests := storage.NewRedisDigestStorage(redisClient)
+	seen := storage.NewRedisSeenStorage(redisClient)
+
+	rule := &model.Rule{
+		ID:      "rule-1",
+		UserID:  "user-1",
+		Email:   "ana@example.com",
+		Type:    "match",
+		Name:    "Weekend",
+		ClubIDs: []string{"club-1"},
+		Active:  true,
+		Digest:  &model.DigestSchedule{Mode: model.DigestDaily, Time: "08:00"},
+	}
+
+	start := time.Now().Add(48 * time.Hour)
+	filling := model.Activity{ID: "filling", AvailablePlaces: 1, StartDate: start}
+	open := model.Activity{ID: "open", AvailablePlaces: 2, StartDate: start}
+
+	mockRuleStorage := new(testutil.MockRuleStorage)
+	mockRuleStorage.On("GetRule", mock.Anything, "rule-1").Return(rule, nil)
+	mockRuleStorage.On("UpdateRuleState", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// The first check matches both activities. By the second, one has filled
+	// up, so like any full activity it is no longer reported.
+	mockProcessor := new(testutil.MockProcessor)
+	mockProcessor.On("Process", mock.Anything, rule).Return([]model.Activity{filling, open}, nil).Once()
+	mockProcessor.On("Process", mock.Anything, rule).Return([]model.Activity{open}, nil).Once()
+	mockProcessor.On("Process", mock.Anything, rule).Return(nil, errors.New("playtomic unavailable")).Once()
+
+	mockEmailNotifier := new(testutil.MockNotifier)
+	mockEmailNotifier.On("NotifyDigest", mock.Anything, mock.Anything, mock.MatchedBy(func(d *model.Digest) bool {
+		ids := make([]string, 0, d.Count)
+		for _, activity := range d.Activities() {
+			ids = append(ids, activity.ID)
+		}
+		return assert.ElementsMatch(t, []string{"open"}, ids)
+	})).Return(nil).Once()
+
+	processor := &ruleProcessor{
+		config:    &config.Config{},
+		ruleStore: mockRuleStorage,
+		seen:      seen,
+		digests:   digests,
+		notifiers: newTestRegistry(map[string]notification.Notifier{model.ChannelEmail: mockEmailNotifier}),
+		processor: mockProcessor,
+	}
+
+	_, err := processor.processRule(ctx, "rule-1")
+	require.NoError(t, err)
+	entries, err := digests.GetDigestEntries(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	_, err = processor.processRule(ctx, "rule-1")
+	require.NoError(t, err)
+	entries, err = digests.GetDigestEntries(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "The filled activity is dropped from the digest")
+
+	// A failed check tells nothing about the activities, so nothing is dropped
+	_, err = processor.processRule(ctx, "rule-1")
+	require.NoError(t, err)
+	entries, err = digests.GetDigestEntries(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	require.NoError(t, processor.sendDigest(ctx, "user-1", time.Now().Add(25*time.Hour)))
+	mockEmailNotifier.AssertExpectations(t)
+	mockProcessor.AssertExpectations(t)
+}
+
+func TestRuleProcessor_SendDigest(t *testing.T) {
+	_, redisClient := newDigestTestRedis(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	digests := storage.NewRedisDigestStorage(redisClient)
+	seen := storage.NewRedisSeenStorage(redisClient)
+
+	rule := &model.Rule{ID: "rule-1", UserID: "user-1", UserName: "Ana", Email: "ana@example.com", Name: "Weekend"}
+
+	open := model.Activity{ID: "open", AvailablePlaces: 2, StartDate: now.Add(24 * time.Hour)}
+	started := model.Activity{ID: "started", AvailablePlaces: 2, StartDate: now.Add(-time.Hour)}
+	filled := model.Activity{ID: "filled", AvailablePlaces: 2, StartDate: now.Add(24 * time.Hour)}
+
+	// A later check saw the activity fill up
+	filledNow := filled
+	filledNow.AvailablePlaces = 0
+	require.NoError(t, seen.MarkSeen(ctx, "rule-1", filledNow))
+
+	entries := []model.DigestEntry{
+		{RuleID: "rule-1", Activity: open, DueAt: now},
+		{RuleID: "rule-1", Activity: started, DueAt: now},
+		{RuleID: "rule-1", Activity: filled, DueAt: now},
+		{RuleID: "deleted-rule", Activity: open, DueAt: now},
+	}
+	require.NoError(t, digests.AddDigestEntries(ctx, "user-1", entries))
+
+	mockRuleStorage := new(testutil.MockRuleStorage)
+	mockRuleStorage.On("GetRule", mock.Anything, "rule-1").Return(rule, nil)
+	mockRuleStorage.On("GetRule", mock.Anything, "deleted-rule").Return(nil, errors.New("rule not found"))
+
+	mockEmailNotifier := new(testutil.MockNotifier)
+	mockEmailNotifier.On("NotifyDigest", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+		return u.Email == "ana@example.com" && u.Name == "Ana"
+	}), mock.MatchedBy(func(d *model.Digest) bool {
+		activities := d.Activities()
+		return d.Count == 1 && activities[0].ID == "open"
+	})).Return(nil).Once()
+
+	mockHistory := new(testutil.MockNotificationStorage)
+	mockHistory.On("AddNotification", mock.Anything, mock.MatchedBy(func(n *model.Notification) bool {
+		return n.RuleID == "rule-1" && n.Sent && len(n.ActivityIDs) == 1
+	})).Return(nil).Once()
+
+	processor := &ruleProcessor{
+		config:    &config.Config{},
+		ruleStore: mockRuleStorage,
+		history:   mockHistory,
+		seen:      seen,
+		digests:   digests,
+		notifiers: newTestRegistry(map[string]notification.Notifier{model.ChannelEmail: mockEmailNotifier}),
+	}
+
+	require.NoError(t, processor.sendDigest(ctx, "user-1", now))
+	mockEmailNotifier.AssertExpectations(t)
+	mockHistory.AssertExpectations(t)
+
+	users, err := digests.DueDigestUsers(ctx, now.Add(time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, users, "Sent entries should be removed")
+
+	// Only the activity that was sent is marked as seen
+	_, wasSeen, err := seen.GetSeen(ctx, "rule-1", "open")
+	require.NoError(t, err)
+	assert.True(t, wasSeen)
+	_, wasSeen, err = seen.GetSeen(ctx, "rule-1", "started")
+	require.NoError(t, err)
+	assert.False(t, wasSeen)
+}
+
+func TestRuleProcessor_SendDigest_RetriesOnFailure(t *testing.T) {
+	_, redisClient := newDigestTestRedis(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	digests := storage.NewRedisDigestStorage(redisClient)
+	seen := storage.NewRedisSeenStorage(redisClient)
+	rule := &model.Rule{ID: "rule-1", UserID: "user-1", Email: "ana@example.com"}
+
+	require.NoError(t, digests.AddDigestEntries(ctx, "user-1", []model.DigestEntry{
+		{RuleID: "rule-1", Activity: model.Activity{ID: "activity-1", StartDate: now.Add(24 * time.Hour)}, DueAt: now},
+	}))
+
+	mockRuleStorage := new(testutil.MockRuleStorage)
+	mockRuleStorage.On("GetRule", mock.Anything, "rule-1").Return(rule, nil)
+
+	mockEmailNotifier := new(testutil.MockNotifier)
+	mockEmailNotifier.On("NotifyDigest", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("smtp down"))
+
+	processor := &ruleProcessor{
+		config:    &config.Config{},
+		ruleStore: mockRuleStorage,
+		seen:      seen,
+		digests:   digests,
+		notifiers: newTestRegistry(map[string]notification.Notifier{model.ChannelEmail: mockEmailNotifier}),
+	}
+
+	assert.Error(t, processor.sendDigest(ctx, "user-1", now))
+
+	_, wasSeen, err := seen.GetSeen(ctx, "rule-1", "activity-1")
+	require.NoError(t, err)
+	assert.False(t, wasSeen, "Activities are not marked as seen until their digest is sent")
+
+	// The entry is kept for a later attempt
+	users, err := digests.DueDigestUsers(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+
+	users, err = digests.DueDigestUsers(ctx, now.Add(digestRetryDelay), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-1"}, users)
+}
+
+func TestRuleProcessor_SendDigest_KeepsEntriesWithoutEmailNotifier(t *testing.T) {
+	_, redisClient := newDigestTestRedis(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	digests := storage.NewRedisDigestStorage(redisClient)
+	require.NoError(t, digests.AddDigestEntries(ctx, "user-1", []model.DigestEntry{
+		{RuleID: "rule-1", Activity: model.Activity{ID: "activity-1", StartDate: now.Add(24 * time.Hour)}, DueAt: now},
+	}))
+
+	processor := &ruleProcessor{
+		config:    &config.Config{},
+		ruleStore: new(testutil.MockRuleStorage),
+		digests:   digests,
+		notifiers: newTestRegistry(map[string]notification.Notifier{}),
+	}
+
+	assert.Error(t, processor.sendDigest(ctx, "user-1", now))
+
+	// The entry is kept for a later attempt
+	entries, err := digests.GetDigestEntries(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "activity-1", entries[0].Activity.ID)
+
+	users, err := digests.DueDigestUsers(ctx, now.Add(digestRetryDelay), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-1"}, users)
+}
@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// staleTicks is how many tick intervals may pass without a completed tick
+// before the scheduler is reported as stalled
+const staleTicks = 3
+
+// defaultMaxLag is used when no maximum schedule lag is configured
+const defaultMaxLag = 5 * time.Minute
+
+// CheckHealth reports whether the scheduler is running and still ticking.
+// It returns an error when the scheduler is stopped or its last tick is
+// more than a few intervals old.
+func (s *Scheduler) CheckHealth(ctx context.Context) (map[string]interface{}, error) {
+	s.mu.Lock()
+	running := s.running
+	s.mu.Unlock()
+
+	details := map[string]interface{}{
+		"running": running,
+	}
+	if !running {
+		return details, fmt.Errorf("scheduler is not running")
+	}
+
+	lastTick := time.Unix(0, s.lastTick.Load())
+	details["last_tick"] = lastTick.UTC().Format(time.RFC3339)
+
+	// A paused scheduler still ticks, so it stays healthy
+	if pausedAt, err := s.PausedAt(ctx); err == nil && pausedAt != nil {
+		details["paused_at"] = pausedAt.UTC().Format(time.RFC3339)
+	}
+
+	if since := time.Since(lastTick); since > staleTicks*tickInterval {
+		return details, fmt.Errorf("scheduler has not ticked for %s", since.Round(time.Second))
+	}
+
+	return details, nil
+}
+
+// CheckBacklog reports how long the oldest unclaimed rule has been overdue.
+// It returns an error when that exceeds the configured maximum lag, which
+// means the scheduler replicas are not keeping up.
+func (s *Scheduler) CheckBacklog(ctx context.Context) (map[string]interface{}, error) {
+	oldest, err := s.ruleStore.OldestScheduledRule(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var overdue time.Duration
+	if oldest != nil && oldest.DueAt.Before(time.Now()) {
+		overdue = time.Since(oldest.DueAt)
+	}
+
+	details := map[string]interface{}{
+		"oldest_overdue_seconds": int(overdue.Seconds()),
+	}
+
+	if maxLag := s.maxLag(); overdue > maxLag {
+		return details, fmt.Errorf("rule %s has been overdue for %s, more than %s", oldest.RuleID, overdue.Round(time.Second), maxLag)
+	}
+
+	return details, nil
+}
+
+// maxLag returns how long a rule may stay overdue before the backlog is unhealthy
+func (s *Scheduler) maxLag() time.Duration {
+	if s.config.SchedulerMaxLag <= 0 {
+		return defaultMaxLag
+	}
+	return time.Duration(s.config.SchedulerMaxLag) * time.Second
+}
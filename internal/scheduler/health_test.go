@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/config"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
+	"github.com/rafa-garcia/padel-alert/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_CheckHealth(t *testing.T) {
+	scheduler := &Scheduler{config: &config.Config{}}
+	ctx := context.Background()
+
+	details, err := scheduler.CheckHealth(ctx)
+	assert.EqualError(t, err, "scheduler is not running")
+	assert.Equal(t, false, details["running"])
+
+	scheduler.running = true
+	scheduler.lastTick.Store(time.Now().Add(-tickInterval).UnixNano())
+
+	details, err = scheduler.CheckHealth(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, true, details["running"])
+	assert.NotEmpty(t, details["last_tick"])
+
+	// A scheduler that stopped ticking is reported as stalled
+	scheduler.lastTick.Store(time.Now().Add(-5 * tickInterval).UnixNano())
+
+	_, err = scheduler.CheckHealth(ctx)
+	assert.ErrorContains(t, err, "scheduler has not ticked for")
+}
+
+func TestScheduler_CheckBacklog(t *testing.T) {
+	mockStorage := new(testutil.MockRuleStorage)
+	scheduler := &Scheduler{
+		config:    &config.Config{SchedulerMaxLag: 60},
+		ruleStore: mockStorage,
+	}
+	ctx := context.Background()
+
+	// No rules scheduled
+	mockStorage.On("OldestScheduledRule", ctx).Return(nil, nil).Once()
+	details, err := scheduler.CheckBacklog(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, details["oldest_overdue_seconds"])
+
+	// Next rule not due yet
+	mockStorage.On("OldestScheduledRule", ctx).Return(&storage.ScheduledRule{RuleID: "rule-1", DueAt: time.Now().Add(time.Minute)}, nil).Once()
+	details, err = scheduler.CheckBacklog(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, details["oldest_overdue_seconds"])
+
+	// Overdue within the allowed lag
+	mockStorage.On("OldestScheduledRule", ctx).Return(&storage.ScheduledRule{RuleID: "rule-1", DueAt: time.Now().Add(-30 * time.Second)}, nil).Once()
+	details, err = scheduler.CheckBacklog(ctx)
+	require.NoError(t, err)
+	assert.InDelta(t, 30, details["oldest_overdue_seconds"], 1)
+
+	// Overdue past the allowed lag
+	mockStorage.On("OldestScheduledRule", ctx).Return(&storage.ScheduledRule{RuleID: "rule-1", DueAt: time.Now().Add(-10 * time.Minute)}, nil).Once()
+	details, err = scheduler.CheckBacklog(ctx)
+	assert.ErrorContains(t, err, "rule rule-1 has been overdue for 10m0s")
+	assert.InDelta(t, 600, details["oldest_overdue_seconds"], 1)
+
+	mockStorage.On("OldestScheduledRule", ctx).Return(nil, errors.New("connection refused")).Once()
+	_, err = scheduler.CheckBacklog(ctx)
+	assert.EqualError(t, err, "connection refused")
+
+	mockStorage.AssertExpectations(t)
+}
+
+func TestScheduler_Start_RecordsTick(t *testing.T) {
+	mockStorage := new(testutil.MockRuleStorage)
+	mockStorage.On("ClaimScheduledRules", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(scheduled(), nil).Maybe()
+	mockStorage.On("RenewRuleLease", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, nil).Maybe()
+
+	scheduler := &Scheduler{
+		config:     &config.Config{},
+		ruleStore:  mockStorage,
+		processor:  newTestRuleProcessor(),
+		workerPool: NewWorkerPool(1, 1),
+		stopCh:     make(chan struct{}),
+		inFlight:   make(map[string]struct{}),
+	}
+
+	require.NoError(t, scheduler.Start())
+	defer scheduler.Stop()
+
+	// A freshly started scheduler counts as healthy before its first tick
+	_, err := scheduler.CheckHealth(context.Background())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,159 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rafa-garcia/padel-alert/internal/config"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/metrics"
+	"github.com/rafa-garcia/padel-alert/internal/notification"
+	internaltestutil "github.com/rafa-garcia/padel-alert/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleProcessor_Metrics(t *testing.T) {
+	metrics.RulesProcessed.Reset()
+	metrics.ActivitiesMatched.Reset()
+	metrics.NotificationsSent.Reset()
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(metrics.RulesProcessed, metrics.ActivitiesMatched, metrics.NotificationsSent)
+
+	mockRuleStorage := new(internaltestutil.MockRuleStorage)
+	mockEmailNotifier := new(internaltestutil.MockNotifier)
+	mockTelegramNotifier := new(internaltestutil.MockNotifier)
+	mockProcessor := new(internaltestutil.MockProcessor)
+
+	matching := &model.Rule{ID: "matching", Type: "match", Active: true, TelegramID: "123"}
+	empty := &model.Rule{ID: "empty", Type: "class", Active: true}
+	failing := &model.Rule{ID: "failing", Type: "lesson", Active: true}
+	inactive := &model.Rule{ID: "inactive", Type: "match"}
+
+	for _, rule := range []*model.Rule{matching, empty, failing, inactive} {
+		mockRuleStorage.On("GetRule", mock.Anything, rule.ID).Return(rule, nil)
+	}
+	mockRuleStorage.On("GetRule", mock.Anything, "missing").Return(nil, nil)
+	mockRuleStorage.On("UpdateRuleState", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	start := time.Now().Add(24 * time.Hour)
+	activities := []model.Activity{{ID: "activity-1", StartDate: start}, {ID: "activity-2", StartDate: start}}
+	mockProcessor.On("Process", mock.Anything, matching).Return(activities, nil)
+	mockProcessor.On("Process", mock.Anything, empty).Return([]model.Activity{}, nil)
+	mockProcessor.On("Process", mock.Anything, failing).Return([]model.Activity(nil), errors.New("upstream down"))
+
+	mockEmailNotifier.On("NotifyNewActivities", mock.Anything, mock.Anything, matching, activities).Return(nil)
+	mockTelegramNotifier.On("NotifyNewActivities", mock.Anything, mock.Anything, matching, activities).Return(errors.New("blocked"))
+
+	processor := &ruleProcessor{
+		config:    &config.Config{CheckInterval: 300},
+		ruleStore: mockRuleStorage,
+		notifiers: newTestRegistry(map[string]notification.Notifier{
+			model.ChannelEmail:    mockEmailNotifier,
+			model.ChannelTelegram: mockTelegramNotifier,
+		}),
+		processor: mockProcessor,
+	}
+
+	for _, ruleID := range []string{"matching", "empty", "failing", "inactive", "missing"} {
+		_, err := processor.processRule(context.Background(), ruleID)
+		require.NoError(t, err)
+	}
+
+	expected := `
+# HELP padel_alert_activities_matched_total The total number of activities matched by rules
+# TYPE padel_alert_activities_matched_total counter
+padel_alert_activities_matched_total{type="class"} 0
+padel_alert_activities_matched_total{type="match"} 2
+# HELP padel_alert_notifications_sent_total The total number of notifications sent
+# TYPE padel_alert_notifications_sent_total counter
+padel_alert_notifications_sent_total{status="failure",type="telegram"} 1
+padel_alert_notifications_sent_total{status="success",type="email"} 1
+# HELP padel_alert_rules_processed_total The total number of rule checks by outcome
+# TYPE padel_alert_rules_processed_total counter
+padel_alert_rules_processed_total{outcome="failed"} 1
+padel_alert_rules_processed_total{outcome="inactive"} 1
+padel_alert_rules_processed_total{outcome="matched"} 1
+padel_alert_rules_processed_total{outcome="no_matches"} 1
+padel_alert_rules_processed_total{outcome="not_found"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"padel_alert_rules_processed_total", "padel_alert_activities_matched_total", "padel_alert_notifications_sent_total"))
+}
+
+func TestScheduler_LagMetric(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(metrics.SchedulerLag)
+
+	before := histogramCount(t, registry, "padel_alert_scheduler_lag_seconds")
+
+	mockStorage := new(internaltestutil.MockRuleStorage)
+	scheduler := &Scheduler{
+		config:     &config.Config{CheckInterval: 300},
+		ruleStore:  mockStorage,
+		owner:      "replica-1",
+		processor:  newTestRuleProcessor(),
+		workerPool: NewWorkerPool(1, 10),
+		stopCh:     make(chan struct{}),
+		inFlight:   make(map[string]struct{}),
+	}
+	scheduler.workerPool.Start()
+	defer scheduler.workerPool.Stop()
+
+	mockStorage.On("ClaimScheduledRules", mock.Anything, mock.Anything, "replica-1", defaultLease, mock.Anything).Return(scheduled("rule-1", "rule-2"), nil)
+	mockStorage.On("RenewRuleLease", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	mockStorage.On("CompleteScheduledRule", mock.Anything, mock.Anything, "replica-1", mock.Anything).Return(true, nil)
+
+	scheduler.processSchedule()
+
+	assert.Equal(t, before+2, histogramCount(t, registry, "padel_alert_scheduler_lag_seconds"))
+}
+
+func TestWorkerPool_SaturationMetric(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(metrics.SchedulerWorkerSaturation)
+
+	pool := NewWorkerPool(2, 10)
+	pool.Start()
+	defer pool.Stop()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	pool.Submit(func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	expected := `
+# HELP padel_alert_scheduler_worker_saturation The fraction of scheduler workers currently busy, from 0 to 1
+# TYPE padel_alert_scheduler_worker_saturation gauge
+padel_alert_scheduler_worker_saturation 0.5
+`
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "padel_alert_scheduler_worker_saturation"))
+
+	close(release)
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.SchedulerWorkerSaturation) == 0
+	}, time.Second, 5*time.Millisecond)
+}
+
+// histogramCount returns the number of observations of a histogram in the registry
+func histogramCount(t *testing.T, registry *prometheus.Registry, name string) uint64 {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()[0].GetHistogram().GetSampleCount()
+		}
+	}
+	return 0
+}
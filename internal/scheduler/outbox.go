@@ -0,0 +1,183 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/logger"
+)
+
+// deliver queues the activities found by a check in the rule's outbox, then
+// sends the queued activities that are due through each channel that has yet
+// to deliver them. An activity is marked as seen, and leaves the outbox, once
+// every channel has delivered it; failed channels are retried with backoff.
+// During quiet hours nothing is sent and new activities wait for the window
+// to end. When the check failed, the outbox is delivered as it stands. It
+// returns when delivery should next be attempted, or nil when nothing waits.
+func (p *ruleProcessor) deliver(ctx context.Context, rule *model.Rule, activities []model.Activity, checked bool) *time.Time {
+	now := time.Now()
+	quiet, until := rule.InQuietHours(now)
+
+	// An outbox that can't be read is not saved over, so its retries are kept
+	entries, loadErr := p.loadOutbox(ctx, rule)
+	if loadErr != nil {
+		logger.Error("Failed to load outbox", loadErr, "rule_id", rule.ID)
+	}
+
+	if checked {
+		if len(activities) == 0 {
+			logger.Info("No activities found for rule", "rule_id", rule.ID)
+		}
+		entries = requeue(entries, activities, now)
+	}
+
+	entries = slices.DeleteFunc(entries, func(entry model.OutboxEntry) bool {
+		if !entry.Activity.StartDate.Before(now) {
+			return false
+		}
+		logger.Warn("Dropping undelivered activity that has started", "rule_id", rule.ID, "activity_id", entry.Activity.ID,
+			"attempts", entry.Attempts, "last_error", entry.LastError)
+		return true
+	})
+
+	channels := rule.NotificationChannels()
+	if quiet {
+		if len(activities) > 0 {
+			logger.Info("Quiet hours, queued activities", "rule_id", rule.ID, "activities", len(activities), "until", until.Format(time.RFC3339))
+		}
+	} else {
+		p.sendOutbox(ctx, rule, channels, entries, now)
+	}
+
+	remaining := make([]model.OutboxEntry, 0, len(entries))
+	var next *time.Time
+	for _, entry := range entries {
+		if entry.DeliveredTo(channels) {
+			p.markDelivered(ctx, rule.ID, entry.Activity)
+			continue
+		}
+
+		remaining = append(remaining, entry)
+		if next == nil || entry.NextAttemptAt.Before(*next) {
+			attemptAt := entry.NextAttemptAt
+			next = &attemptAt
+		}
+	}
+
+	if p.outbox != nil && loadErr == nil {
+		if err := p.outbox.SaveOutbox(ctx, rule.ID, remaining); err != nil {
+			logger.Error("Failed to save outbox", err, "rule_id", rule.ID)
+		}
+	}
+
+	// Deliveries that fall due during quiet hours wait for them to end
+	if next != nil && quiet && next.Before(until) {
+		next = &until
+	}
+	return next
+}
+
+// loadOutbox returns the activities waiting in the rule's outbox. Without
+// outbox storage activities are sent as they are found, and those that fail
+// are found again by the next check.
+func (p *ruleProcessor) loadOutbox(ctx context.Context, rule *model.Rule) ([]model.OutboxEntry, error) {
+	if p.outbox == nil {
+		return nil, nil
+	}
+	return p.outbox.GetOutbox(ctx, rule.ID)
+}
+
+// requeue rebuilds a rule's outbox from the activities its latest check found.
+// Activities already queued keep their delivery progress and take the latest
+// details. Queued activities the check no longer found, because they filled
+// up or stopped matching the rule, are dropped.
+func requeue(queued []model.OutboxEntry, activities []model.Activity, now time.Time) []model.OutboxEntry {
+	byID := make(map[string]model.OutboxEntry, len(queued))
+	for _, entry := range queued {
+		byID[entry.Activity.ID] = entry
+	}
+
+	entries := make([]model.OutboxEntry, 0, len(activities))
+	for _, activity := range activities {
+		entry, ok := byID[activity.ID]
+		if !ok {
+			entry = model.OutboxEntry{NextAttemptAt: now, QueuedAt: now}
+		}
+		entry.Activity = activity
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// sendOutbox sends the entries that are due with one notification per
+// channel, recording on each entry the channels that delivered it and when
+// to try the others again
+func (p *ruleProcessor) sendOutbox(ctx context.Context, rule *model.Rule, channels []string, entries []model.OutboxEntry, now time.Time) {
+	var due []int
+	for i, entry := range entries {
+		if !entry.NextAttemptAt.After(now) && !entry.DeliveredTo(channels) {
+			entries[i].LastError = ""
+			due = append(due, i)
+		}
+	}
+	if len(due) == 0 {
+		return
+	}
+
+	user := p.recipient(ctx, rule)
+
+	for _, channel := range channels {
+		var batch []int
+		var activities []model.Activity
+		for _, i := range due {
+			if !slices.Contains(entries[i].Delivered, channel) {
+				batch = append(batch, i)
+				activities = append(activities, entries[i].Activity)
+			}
+		}
+		if len(batch) == 0 {
+			continue
+		}
+
+		logger.Info("Sending notification", "rule_id", rule.ID, "channel", channel, "activities", len(activities))
+		err := p.notify(ctx, user, rule, channel, activities)
+		p.recordNotification(ctx, rule, activities, channelResult{Channel: channel, Err: err})
+
+		if err != nil {
+			logger.Error("Failed to send notification", err, "rule_id", rule.ID, "channel", channel)
+			for _, i := range batch {
+				entries[i].LastError = fmt.Sprintf("%s: %v", channel, err)
+			}
+			continue
+		}
+
+		rule.LastNotification = time.Now()
+		logger.Info("Notification sent successfully", "rule_id", rule.ID, "channel", channel)
+		for _, i := range batch {
+			entries[i].Delivered = append(entries[i].Delivered, channel)
+		}
+	}
+
+	for _, i := range due {
+		if !entries[i].DeliveredTo(channels) {
+			entries[i].Attempts++
+			entries[i].NextAttemptAt = now.Add(retryDelay(entries[i].Attempts, 0))
+		}
+	}
+}
+
+// markDelivered records a delivered activity as seen, so later checks do not
+// report it again
+func (p *ruleProcessor) markDelivered(ctx context.Context, ruleID string, activity model.Activity) {
+	if p.seen == nil {
+		return
+	}
+
+	if err := p.seen.MarkSeen(ctx, ruleID, activity); err != nil {
+		logger.Error("Failed to mark activity as seen", err, "rule_id", ruleID, "activity_id", activity.ID)
+	}
+}
@@ -2,32 +2,26 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
 	"time"
 
 	"github.com/rafa-garcia/padel-alert/internal/config"
 	"github.com/rafa-garcia/padel-alert/internal/domain/model"
 	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/metrics"
 	"github.com/rafa-garcia/padel-alert/internal/notification"
 	"github.com/rafa-garcia/padel-alert/internal/processor"
+	"github.com/rafa-garcia/padel-alert/internal/source"
 	"github.com/rafa-garcia/padel-alert/internal/storage"
-
-	playtomic "github.com/rafa-garcia/go-playtomic-api/client"
+	"github.com/rafa-garcia/padel-alert/internal/util"
 )
 
-// EmailNotifier defines an interface for email notification
-type EmailNotifier interface {
-	NotifyNewActivities(ctx context.Context, user *model.User, rule *model.Rule, activities []model.Activity) error
-}
-
-// RuleProcessor defines the interface for processing rules
+// RuleProcessor defines the interface for processing rules. It returns the
+// processed rule, or nil if it no longer exists, so the scheduler can plan the next check.
 type RuleProcessor interface {
-	processRule(ctx context.Context, ruleID string) error
-}
-
-// PlaytomicClient interface for Playtomic API operations
-type PlaytomicClient interface {
-	SearchMatches(ctx context.Context, params interface{}) ([]model.Activity, error)
-	SearchClasses(ctx context.Context, params interface{}) ([]model.Activity, error)
+	processRule(ctx context.Context, ruleID string) (*model.Rule, error)
 }
 
 // RuleTypeProcessor interface for processing specific rule types
@@ -35,51 +29,114 @@ type RuleTypeProcessor interface {
 	Process(ctx context.Context, rule *model.Rule) ([]model.Activity, error)
 }
 
+// channelResult records the outcome of notifying through one channel
+type channelResult struct {
+	Channel string
+	Err     error
+}
+
+// Rule check outcomes recorded in the rules processed metric
+const (
+	outcomeMatched   = "matched"
+	outcomeNoMatches = "no_matches"
+	outcomeFailed    = "failed"
+	outcomeInactive  = "inactive"
+	outcomeSnoozed   = "snoozed"
+	outcomeNotFound  = "not_found"
+)
+
+// Retry backoff for rules whose checks fail
+const (
+	baseRetryDelay = time.Minute
+	maxRetryDelay  = time.Hour
+)
+
+// seenPruneGrace keeps seen activities for a while after they start, since
+// searches still return activities from earlier in the club's current day
+const seenPruneGrace = 48 * time.Hour
+
 // ruleProcessor processes rules and sends notifications
 type ruleProcessor struct {
-	config        *config.Config
-	ruleStore     storage.RuleStorage
-	emailNotifier EmailNotifier
-	processor     RuleTypeProcessor
+	config    *config.Config
+	ruleStore storage.RuleStorage
+	history   storage.NotificationStorage
+	users     storage.UserStorage
+	seen      storage.SeenStorage
+	outbox    storage.OutboxStorage
+	digests   storage.DigestStorage
+	state     storage.SchedulerStorage // Not used to process rules; shared with the Scheduler
+	notifiers *notification.Registry
+	processor RuleTypeProcessor
 }
 
 // newRuleProcessor creates a new rule processor
-func newRuleProcessor(cfg *config.Config, ruleStore storage.RuleStorage) *ruleProcessor {
+func newRuleProcessor(cfg *config.Config, ruleStore storage.RuleStorage, activitySource source.ActivitySource) *ruleProcessor {
 	redisClient, err := storage.NewRedisClient(cfg)
 	if err != nil {
 		logger.Error("Failed to create Redis client", err)
 		redisClient = nil
 	}
 
-	playtomicClient := playtomic.NewClient(
-		playtomic.WithTimeout(60*time.Second),
-		playtomic.WithRetries(3),
-	)
+	var history storage.NotificationStorage
+	var users storage.UserStorage
+	var seen storage.SeenStorage
+	var outbox storage.OutboxStorage
+	var digests storage.DigestStorage
+	var state storage.SchedulerStorage
+	if redisClient != nil {
+		history = storage.NewRedisNotificationStorage(redisClient, cfg.NotificationHistorySize)
+		users = storage.NewRedisUserStorage(redisClient)
+		seen = storage.NewRedisSeenStorage(redisClient)
+		outbox = storage.NewRedisOutboxStorage(redisClient)
+		digests = storage.NewRedisDigestStorage(redisClient)
+		state = storage.NewRedisSchedulerStorage(redisClient)
+	}
 
 	return &ruleProcessor{
-		config:        cfg,
-		ruleStore:     ruleStore,
-		emailNotifier: notification.NewEmailNotifier(cfg),
-		processor:     processor.NewProcessor(playtomicClient, ruleStore, redisClient),
+		config:    cfg,
+		ruleStore: ruleStore,
+		history:   history,
+		users:     users,
+		seen:      seen,
+		outbox:    outbox,
+		digests:   digests,
+		state:     state,
+		notifiers: notification.NewDefaultRegistry(cfg),
+		processor: processor.NewProcessor(activitySource, ruleStore, seen, source.Pagination{
+			PageSize: cfg.PlaytomicPageSize,
+			MaxPages: cfg.PlaytomicMaxPages,
+		}, source.NewSnapshotCache(time.Duration(cfg.SnapshotTTL)*time.Second)),
 	}
 }
 
-// processRule processes a rule and sends notifications if needed
-func (p *ruleProcessor) processRule(ctx context.Context, ruleID string) error {
+// processRule processes a rule and sends notifications if needed. Activities
+// go through the rule's outbox and are marked as seen only once delivered.
+// Digest rules add their activities to the user's next digest instead. During
+// the rule's quiet hours new activities are queued and sent once the window ends.
+func (p *ruleProcessor) processRule(ctx context.Context, ruleID string) (*model.Rule, error) {
 	rule, err := p.ruleStore.GetRule(ctx, ruleID)
 	if err != nil {
 		logger.Error("Failed to get rule", err, "rule_id", ruleID)
-		return err
+		metrics.RulesProcessed.WithLabelValues(outcomeFailed).Inc()
+		return nil, err
 	}
 
 	if rule == nil {
 		logger.Warn("Rule not found", "rule_id", ruleID)
-		return nil
+		metrics.RulesProcessed.WithLabelValues(outcomeNotFound).Inc()
+		return nil, nil
 	}
 
 	if !rule.Active {
 		logger.Debug("Skipping inactive rule", "rule_id", ruleID, "name", rule.Name)
-		return nil
+		metrics.RulesProcessed.WithLabelValues(outcomeInactive).Inc()
+		return rule, nil
+	}
+
+	if rule.IsSnoozed(time.Now()) {
+		logger.Debug("Skipping snoozed rule", "rule_id", ruleID, "snoozed_until", rule.SnoozedUntil.Format(time.RFC3339))
+		metrics.RulesProcessed.WithLabelValues(outcomeSnoozed).Inc()
+		return rule, nil
 	}
 
 	logger.Debug("Processing rule", "rule_id", ruleID, "name", rule.Name, "type", rule.Type)
@@ -88,31 +145,151 @@ func (p *ruleProcessor) processRule(ctx context.Context, ruleID string) error {
 
 	activities, err := p.processor.Process(ctx, rule)
 	if err != nil {
-		logger.Error("Failed to process rule", err, "rule_id", ruleID, "type", rule.Type)
+		retryAt := rule.LastChecked.Add(retryDelay(rule.ConsecutiveFailures+1, rule.CheckInterval(time.Duration(p.config.CheckInterval)*time.Second)))
+		rule.RecordFailure(err, retryAt)
+		logger.Error("Failed to process rule", err, "rule_id", ruleID, "type", rule.Type,
+			"consecutive_failures", rule.ConsecutiveFailures, "next_retry", retryAt.Format(time.RFC3339))
+		metrics.RulesProcessed.WithLabelValues(outcomeFailed).Inc()
+	} else {
+		rule.RecordSuccess()
+		rule.LastMatchCount = len(activities)
+		metrics.ActivitiesMatched.WithLabelValues(rule.Type).Add(float64(len(activities)))
+		if len(activities) > 0 {
+			metrics.RulesProcessed.WithLabelValues(outcomeMatched).Inc()
+		} else {
+			metrics.RulesProcessed.WithLabelValues(outcomeNoMatches).Inc()
+		}
 	}
 
-	if len(activities) > 0 {
-		user := &model.User{
-			ID:    rule.UserID,
-			Email: rule.Email,
-		}
+	if rule.IsDigest() {
+		rule.NextDeliveryAt = nil
+		p.addToDigest(ctx, rule, activities, err == nil)
+	} else {
+		rule.NextDeliveryAt = p.deliver(ctx, rule, activities, err == nil)
+	}
 
-		logger.Info("Sending notification", "rule_id", ruleID, "activities", len(activities))
-		err = p.emailNotifier.NotifyNewActivities(ctx, user, rule, activities)
-		if err != nil {
-			logger.Error("Failed to send notification", err, "rule_id", ruleID)
-		} else {
-			rule.LastNotification = time.Now()
-			logger.Info("Notification sent successfully", "rule_id", ruleID)
+	p.pruneSeen(ctx, ruleID)
+
+	// Only the runtime state is written back, so edits made while the rule
+	// was being checked are kept
+	err = p.ruleStore.UpdateRuleState(ctx, ruleID, rule.State())
+	switch {
+	case errors.Is(err, storage.ErrRuleNotFound):
+		logger.Debug("Rule deleted while being checked", "rule_id", ruleID)
+	case err != nil:
+		logger.Error("Failed to update rule state", err, "rule_id", ruleID)
+	}
+
+	return rule, nil
+}
+
+// recipient returns the user a rule notifies. Rules belong to a user account,
+// so contact changes apply to all of the user's rules; rules whose user has no
+// account fall back to the contact details stored on the rule.
+func (p *ruleProcessor) recipient(ctx context.Context, rule *model.Rule) *model.User {
+	if p.users != nil {
+		user, err := p.users.GetUser(ctx, rule.UserID)
+		if err == nil {
+			return user
+		}
+		if !errors.Is(err, storage.ErrUserNotFound) {
+			logger.Warn("Failed to load user, using the rule's contact details", "rule_id", rule.ID, "user_id", rule.UserID, "error", err.Error())
 		}
-	} else {
-		logger.Info("No activities found for rule", "rule_id", ruleID)
 	}
 
-	err = p.ruleStore.UpdateRule(ctx, rule)
+	return &model.User{
+		ID:    rule.UserID,
+		Name:  rule.UserName,
+		Email: rule.Email,
+	}
+}
+
+// retryDelay returns how long to wait before checking a rule again after
+// failures consecutive failures. The delay doubles with each failure up to
+// maxRetryDelay, is never shorter than the rule's interval, and is jittered
+// so rules failing together during an outage do not retry together.
+func retryDelay(failures int, interval time.Duration) time.Duration {
+	delay := maxRetryDelay
+	if failures <= 1 {
+		delay = baseRetryDelay
+	} else if failures < 8 {
+		delay = min(baseRetryDelay<<(failures-1), maxRetryDelay)
+	}
+
+	delay = max(delay, interval)
+	jitter := time.Duration(rand.Int64N(int64(delay)/10 + 1))
+	return delay + jitter
+}
+
+// pruneSeen forgets seen activities that started well in the past and
+// reports how many remain for the rule
+func (p *ruleProcessor) pruneSeen(ctx context.Context, ruleID string) {
+	if p.seen == nil {
+		return
+	}
+
+	remaining, err := p.seen.Prune(ctx, ruleID, time.Now().Add(-seenPruneGrace))
 	if err != nil {
-		logger.Error("Failed to update rule", err, "rule_id", ruleID)
+		logger.Error("Failed to prune seen activities", err, "rule_id", ruleID)
+		return
 	}
 
-	return nil
+	metrics.SeenActivities.Observe(float64(remaining))
+}
+
+// notify sends the activities through one of the rule's channels
+func (p *ruleProcessor) notify(ctx context.Context, user *model.User, rule *model.Rule, channel string, activities []model.Activity) error {
+	notifier, ok := p.notifiers.Get(channel)
+	if !ok {
+		return fmt.Errorf("no notifier registered for channel %q", channel)
+	}
+
+	err := notifier.NotifyNewActivities(ctx, user, rule, activities)
+	countNotification(channel, err)
+	return err
+}
+
+// countNotification records a send attempt in the notifications sent metric
+func countNotification(channel string, err error) {
+	status := "success"
+	if err != nil {
+		status = "failure"
+	}
+	metrics.NotificationsSent.WithLabelValues(channel, status).Inc()
+}
+
+// recordNotification persists the outcome of a send attempt to the notification history
+func (p *ruleProcessor) recordNotification(ctx context.Context, rule *model.Rule, activities []model.Activity, result channelResult) {
+	if p.history == nil {
+		return
+	}
+
+	activityIDs := make([]string, 0, len(activities))
+	for _, activity := range activities {
+		activityIDs = append(activityIDs, activity.ID)
+	}
+
+	now := time.Now()
+	record := &model.Notification{
+		ID:          util.GenerateID(),
+		RuleID:      rule.ID,
+		UserID:      rule.UserID,
+		Channel:     result.Channel,
+		ActivityIDs: activityIDs,
+		Subject:     notification.Subject(activities),
+		Status:      model.NotificationStatusSent,
+		CreatedAt:   now,
+	}
+
+	if result.Err != nil {
+		record.Status = model.NotificationStatusFailed
+		record.Error = result.Err.Error()
+	} else {
+		record.Sent = true
+		record.SentAt = &now
+	}
+
+	if err := p.history.AddNotification(ctx, record); err != nil {
+		logger.Error("Failed to record notification", err, "rule_id", rule.ID, "channel", result.Channel)
+	}
 }
@@ -2,19 +2,33 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/rafa-garcia/padel-alert/internal/config"
 	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/notification"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
 	"github.com/rafa-garcia/padel-alert/internal/testutil"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
+func newTestRegistry(notifiers map[string]notification.Notifier) *notification.Registry {
+	registry := notification.NewRegistry()
+	for channel, notifier := range notifiers {
+		registry.Register(channel, notifier)
+	}
+	return registry
+}
+
 func TestRuleProcessor_ProcessRule_NoMatches(t *testing.T) {
 	mockRuleStorage := new(testutil.MockRuleStorage)
-	mockEmailNotifier := new(testutil.MockEmailNotifier)
+	mockEmailNotifier := new(testutil.MockNotifier)
 	mockProcessor := new(testutil.MockProcessor)
 
 	now := time.Now()
@@ -30,20 +44,20 @@ func TestRuleProcessor_ProcessRule_NoMatches(t *testing.T) {
 	}
 
 	mockRuleStorage.On("GetRule", mock.Anything, "test-rule-id").Return(rule, nil)
-	mockRuleStorage.On("UpdateRule", mock.Anything, mock.MatchedBy(func(r *model.Rule) bool {
-		return r.ID == "test-rule-id" && !r.LastChecked.IsZero()
+	mockRuleStorage.On("UpdateRuleState", mock.Anything, "test-rule-id", mock.MatchedBy(func(state model.RuleState) bool {
+		return !state.LastChecked.IsZero() && state.LastMatchCount == 0
 	})).Return(nil)
 
 	mockProcessor.On("Process", mock.Anything, rule).Return([]model.Activity{}, nil)
 
 	processor := &ruleProcessor{
-		config:        &config.Config{},
-		ruleStore:     mockRuleStorage,
-		emailNotifier: mockEmailNotifier,
-		processor:     mockProcessor,
+		config:    &config.Config{},
+		ruleStore: mockRuleStorage,
+		notifiers: newTestRegistry(map[string]notification.Notifier{model.ChannelEmail: mockEmailNotifier}),
+		processor: mockProcessor,
 	}
 
-	err := processor.processRule(context.Background(), "test-rule-id")
+	_, err := processor.processRule(context.Background(), "test-rule-id")
 
 	assert.NoError(t, err)
 	mockRuleStorage.AssertExpectations(t)
@@ -53,7 +67,7 @@ func TestRuleProcessor_ProcessRule_NoMatches(t *testing.T) {
 
 func TestRuleProcessor_ProcessRule_WithMatches(t *testing.T) {
 	mockRuleStorage := new(testutil.MockRuleStorage)
-	mockEmailNotifier := new(testutil.MockEmailNotifier)
+	mockEmailNotifier := new(testutil.MockNotifier)
 	mockProcessor := new(testutil.MockProcessor)
 
 	now := time.Now()
@@ -70,8 +84,9 @@ func TestRuleProcessor_ProcessRule_WithMatches(t *testing.T) {
 
 	activities := []model.Activity{
 		{
-			ID:   "activity-1",
-			Name: "Test Match",
+			ID:        "activity-1",
+			Name:      "Test Match",
+			StartDate: now.Add(24 * time.Hour),
 			Club: model.Club{
 				ID:   "club-1",
 				Name: "Test Club",
@@ -80,8 +95,8 @@ func TestRuleProcessor_ProcessRule_WithMatches(t *testing.T) {
 	}
 
 	mockRuleStorage.On("GetRule", mock.Anything, "test-rule-id").Return(rule, nil)
-	mockRuleStorage.On("UpdateRule", mock.Anything, mock.MatchedBy(func(r *model.Rule) bool {
-		return r.ID == "test-rule-id" && !r.LastChecked.IsZero() && !r.LastNotification.IsZero()
+	mockRuleStorage.On("UpdateRuleState", mock.Anything, "test-rule-id", mock.MatchedBy(func(state model.RuleState) bool {
+		return !state.LastChecked.IsZero() && !state.LastNotification.IsZero() && state.LastMatchCount == len(activities)
 	})).Return(nil)
 
 	mockProcessor.On("Process", mock.Anything, rule).Return(activities, nil)
@@ -91,13 +106,13 @@ func TestRuleProcessor_ProcessRule_WithMatches(t *testing.T) {
 	}), rule, activities).Return(nil)
 
 	processor := &ruleProcessor{
-		config:        &config.Config{},
-		ruleStore:     mockRuleStorage,
-		emailNotifier: mockEmailNotifier,
-		processor:     mockProcessor,
+		config:    &config.Config{},
+		ruleStore: mockRuleStorage,
+		notifiers: newTestRegistry(map[string]notification.Notifier{model.ChannelEmail: mockEmailNotifier}),
+		processor: mockProcessor,
 	}
 
-	err := processor.processRule(context.Background(), "test-rule-id")
+	_, err := processor.processRule(context.Background(), "test-rule-id")
 
 	assert.NoError(t, err)
 	mockRuleStorage.AssertExpectations(t)
@@ -105,9 +120,60 @@ func TestRuleProcessor_ProcessRule_WithMatches(t *testing.T) {
 	mockEmailNotifier.AssertExpectations(t)
 }
 
+func TestRuleProcessor_ProcessRule_UsesUserAccount(t *testing.T) {
+	mockRuleStorage := new(testutil.MockRuleStorage)
+	mockUserStorage := new(testutil.MockUserStorage)
+	mockEmailNotifier := new(testutil.MockNotifier)
+	mockProcessor := new(testutil.MockProcessor)
+
+	// The rule still carries the email it was created with
+	rule := &model.Rule{
+		ID:       "test-rule-id",
+		UserID:   "test-user-id",
+		UserName: "Old Name",
+		Email:    "old@example.com",
+		Type:     "match",
+		Name:     "Test Rule",
+		ClubIDs:  []string{"club-1"},
+		Active:   true,
+	}
+	activities := []model.Activity{{ID: "activity-1", Name: "Test Match", StartDate: time.Now().Add(24 * time.Hour)}}
+	account := &model.User{ID: "test-user-id", Name: "Ana", Email: "new@example.com"}
+
+	mockRuleStorage.On("GetRule", mock.Anything, "test-rule-id").Return(rule, nil)
+	mockRuleStorage.On("UpdateRuleState", mock.Anything, rule.ID, mock.Anything).Return(nil)
+	mockUserStorage.On("GetUser", mock.Anything, "test-user-id").Return(account, nil)
+	mockProcessor.On("Process", mock.Anything, rule).Return(activities, nil)
+	mockEmailNotifier.On("NotifyNewActivities", mock.Anything, account, rule, activities).Return(nil)
+
+	processor := &ruleProcessor{
+		config:    &config.Config{},
+		ruleStore: mockRuleStorage,
+		users:     mockUserStorage,
+		notifiers: newTestRegistry(map[string]notification.Notifier{model.ChannelEmail: mockEmailNotifier}),
+		processor: mockProcessor,
+	}
+
+	_, err := processor.processRule(context.Background(), "test-rule-id")
+	require.NoError(t, err)
+	mockEmailNotifier.AssertExpectations(t)
+
+	// Without an account the rule's own contact details are used
+	mockUserStorage.ExpectedCalls = nil
+	mockUserStorage.On("GetUser", mock.Anything, "test-user-id").Return(nil, storage.ErrUserNotFound)
+	mockEmailNotifier.ExpectedCalls = nil
+	mockEmailNotifier.On("NotifyNewActivities", mock.Anything, mock.MatchedBy(func(u *model.User) bool {
+		return u.ID == "test-user-id" && u.Name == "Old Name" && u.Email == "old@example.com"
+	}), rule, activities).Return(nil)
+
+	_, err = processor.processRule(context.Background(), "test-rule-id")
+	require.NoError(t, err)
+	mockEmailNotifier.AssertExpectations(t)
+}
+
 func TestRuleProcessor_InactiveRule(t *testing.T) {
 	mockRuleStorage := new(testutil.MockRuleStorage)
-	mockEmailNotifier := new(testutil.MockEmailNotifier)
+	mockEmailNotifier := new(testutil.MockNotifier)
 	mockProcessor := new(testutil.MockProcessor)
 
 	rule := &model.Rule{
@@ -123,16 +189,361 @@ func TestRuleProcessor_InactiveRule(t *testing.T) {
 	mockRuleStorage.On("GetRule", mock.Anything, "test-rule-id").Return(rule, nil)
 
 	processor := &ruleProcessor{
-		config:        &config.Config{},
-		ruleStore:     mockRuleStorage,
-		emailNotifier: mockEmailNotifier,
-		processor:     mockProcessor,
+		config:    &config.Config{},
+		ruleStore: mockRuleStorage,
+		notifiers: newTestRegistry(map[string]notification.Notifier{model.ChannelEmail: mockEmailNotifier}),
+		processor: mockProcessor,
 	}
 
-	err := processor.processRule(context.Background(), "test-rule-id")
+	_, err := processor.processRule(context.Background(), "test-rule-id")
 
 	assert.NoError(t, err)
 	mockRuleStorage.AssertExpectations(t)
 	mockProcessor.AssertNotCalled(t, "Process")
 	mockEmailNotifier.AssertNotCalled(t, "NotifyNewActivities")
 }
+
+func TestRuleProcessor_SnoozedRule(t *testing.T) {
+	mockRuleStorage := new(testutil.MockRuleStorage)
+	mockEmailNotifier := new(testutil.MockNotifier)
+	mockProcessor := new(testutil.MockProcessor)
+
+	snoozedUntil := time.Now().Add(time.Hour)
+	snoozed := &model.Rule{ID: "snoozed", Type: "match", Active: true, SnoozedUntil: &snoozedUntil}
+
+	endedAt := time.Now().Add(-time.Minute)
+	ended := &model.Rule{ID: "ended", Type: "match", Active: true, SnoozedUntil: &endedAt}
+
+	mockRuleStorage.On("GetRule", mock.Anything, "snoozed").Return(snoozed, nil)
+	mockRuleStorage.On("GetRule", mock.Anything, "ended").Return(ended, nil)
+	mockRuleStorage.On("UpdateRuleState", mock.Anything, "ended", mock.Anything).Return(nil)
+	mockProcessor.On("Process", mock.Anything, ended).Return([]model.Activity{}, nil)
+
+	processor := &ruleProcessor{
+		config:    &config.Config{},
+		ruleStore: mockRuleStorage,
+		notifiers: newTestRegistry(map[string]notification.Notifier{model.ChannelEmail: mockEmailNotifier}),
+		processor: mockProcessor,
+	}
+
+	rule, err := processor.processRule(context.Background(), "snoozed")
+	require.NoError(t, err)
+	assert.Equal(t, &snoozedUntil, rule.SnoozedUntil)
+	mockProcessor.AssertNotCalled(t, "Process", mock.Anything, snoozed)
+	mockRuleStorage.AssertNotCalled(t, "UpdateRuleState", mock.Anything, "snoozed", mock.Anything)
+
+	// Once the snooze ends the rule is checked again
+	rule, err = processor.processRule(context.Background(), "ended")
+	require.NoError(t, err)
+	assert.False(t, rule.LastChecked.IsZero())
+	mockProcessor.AssertExpectations(t)
+	mockRuleStorage.AssertExpectations(t)
+}
+
+func TestRuleProcessor_ProcessRule_MultipleChannels(t *testing.T) {
+	mockRuleStorage := new(testutil.MockRuleStorage)
+	mockEmailNotifier := new(testutil.MockNotifier)
+	mockTelegramNotifier := new(testutil.MockNotifier)
+	mockWebhookNotifier := new(testutil.MockNotifier)
+	mockProcessor := new(testutil.MockProcessor)
+
+	rule := &model.Rule{
+		ID:         "test-rule-id",
+		UserID:     "test-user-id",
+		Email:      "test@example.com",
+		TelegramID: "12345",
+		WebhookURL: "https://example.com/hook",
+		Channels:   []string{model.ChannelEmail, model.ChannelTelegram, model.ChannelWebhook},
+		Type:       "match",
+		Name:       "Test Rule",
+		ClubIDs:    []string{"club-1"},
+		Active:     true,
+	}
+
+	activities := []model.Activity{{ID: "activity-1", Name: "Test Match", StartDate: time.Now().Add(24 * time.Hour)}}
+
+	mockRuleStorage.On("GetRule", mock.Anything, "test-rule-id").Return(rule, nil)
+	mockRuleStorage.On("UpdateRuleState", mock.Anything, "test-rule-id", mock.MatchedBy(func(state model.RuleState) bool {
+		return !state.LastNotification.IsZero()
+	})).Return(nil)
+	mockProcessor.On("Process", mock.Anything, rule).Return(activities, nil)
+
+	// The email channel fails, but the other channels still deliver
+	mockEmailNotifier.On("NotifyNewActivities", mock.Anything, mock.Anything, rule, activities).Return(errors.New("smtp down"))
+	mockTelegramNotifier.On("NotifyNewActivities", mock.Anything, mock.Anything, rule, activities).Return(nil)
+	mockWebhookNotifier.On("NotifyNewActivities", mock.Anything, mock.Anything, rule, activities).Return(nil)
+
+	mockHistory := new(testutil.MockNotificationStorage)
+	mockHistory.On("AddNotification", mock.Anything, mock.MatchedBy(func(n *model.Notification) bool {
+		return n.Channel == model.ChannelEmail && n.Status == model.NotificationStatusFailed && n.Error == "smtp down" && !n.Sent
+	})).Return(nil).Once()
+	mockHistory.On("AddNotification", mock.Anything, mock.MatchedBy(func(n *model.Notification) bool {
+		return n.Channel != model.ChannelEmail && n.Status == model.NotificationStatusSent && n.Sent &&
+			n.RuleID == "test-rule-id" && len(n.ActivityIDs) == 1 && n.ActivityIDs[0] == "activity-1"
+	})).Return(nil).Twice()
+
+	processor := &ruleProcessor{
+		config:    &config.Config{},
+		ruleStore: mockRuleStorage,
+		history:   mockHistory,
+		notifiers: newTestRegistry(map[string]notification.Notifier{
+			model.ChannelEmail:    mockEmailNotifier,
+			model.ChannelTelegram: mockTelegramNotifier,
+			model.ChannelWebhook:  mockWebhookNotifier,
+		}),
+		processor: mockProcessor,
+	}
+
+	processed, err := processor.processRule(context.Background(), "test-rule-id")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, processed.NextDeliveryAt, "The failed channel should be retried")
+	mockRuleStorage.AssertExpectations(t)
+	mockEmailNotifier.AssertExpectations(t)
+	mockTelegramNotifier.AssertExpectations(t)
+	mockWebhookNotifier.AssertExpectations(t)
+	mockHistory.AssertExpectations(t)
+}
+
+func TestRuleProcessor_Notify_UnknownChannel(t *testing.T) {
+	processor := &ruleProcessor{
+		config:    &config.Config{},
+		notifiers: notification.NewRegistry(),
+	}
+
+	rule := &model.Rule{ID: "test-rule-id", Channels: []string{"carrier-pigeon"}}
+	err := processor.notify(context.Background(), &model.User{}, rule, "carrier-pigeon", []model.Activity{{ID: "activity-1"}})
+
+	assert.Error(t, err)
+}
+
+// quietHoursAround returns a UTC quiet window that does or does not contain now
+func quietHoursAround(now time.Time, contains bool) *model.QuietHours {
+	now = now.UTC()
+	if contains {
+		return &model.QuietHours{Start: now.Add(-time.Hour).Format("15:04"), End: now.Add(time.Hour).Format("15:04")}
+	}
+	return &model.QuietHours{Start: now.Add(time.Hour).Format("15:04"), End: now.Add(2 * time.Hour).Format("15:04")}
+}
+
+func TestRuleProcessor_ProcessRule_QuietHours(t *testing.T) {
+	mini, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mini.Close()
+
+	redisClient := &storage.RedisClient{Client: redis.NewClient(&redis.Options{Addr: mini.Addr()})}
+
+	mockRuleStorage := new(testutil.MockRuleStorage)
+	mockEmailNotifier := new(testutil.MockNotifier)
+	mockProcessor := new(testutil.MockProcessor)
+
+	rule := &model.Rule{
+		ID:         "test-rule-id",
+		UserID:     "test-user-id",
+		Email:      "test@example.com",
+		Type:       "match",
+		Name:       "Test Rule",
+		ClubIDs:    []string{"club-1"},
+		Active:     true,
+		QuietHours: quietHoursAround(time.Now(), true),
+	}
+
+	start := time.Now().Add(24 * time.Hour)
+	queued := model.Activity{ID: "activity-1", StartDate: start}
+	fresh := model.Activity{ID: "activity-2", StartDate: start}
+
+	// Activities are reported by every check until they are delivered
+	mockRuleStorage.On("GetRule", mock.Anything, "test-rule-id").Return(rule, nil)
+	mockRuleStorage.On("UpdateRuleState", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockProcessor.On("Process", mock.Anything, rule).Return([]model.Activity{queued}, nil).Once()
+	mockProcessor.On("Process", mock.Anything, rule).Return([]model.Activity{queued, fresh}, nil).Once()
+
+	seen := storage.NewRedisSeenStorage(redisClient)
+	processor := &ruleProcessor{
+		config:    &config.Config{},
+		ruleStore: mockRuleStorage,
+		seen:      seen,
+		outbox:    storage.NewRedisOutboxStorage(redisClient),
+		notifiers: newTestRegistry(map[string]notification.Notifier{model.ChannelEmail: mockEmailNotifier}),
+		processor: mockProcessor,
+	}
+
+	// Inside quiet hours the activities are queued instead of sent
+	processed, err := processor.processRule(context.Background(), "test-rule-id")
+	require.NoError(t, err)
+	mockEmailNotifier.AssertNotCalled(t, "NotifyNewActivities")
+	assert.True(t, mini.Exists("outbox:test-rule-id"))
+	require.NotNil(t, processed.NextDeliveryAt)
+	_, until := rule.InQuietHours(time.Now())
+	assert.True(t, processed.NextDeliveryAt.Equal(until), "Delivery should wait for the window to end")
+
+	// Once the window ends, queued activities go out with the new ones
+	rule.QuietHours = quietHoursAround(time.Now(), false)
+	mockEmailNotifier.On("NotifyNewActivities", mock.Anything, mock.Anything, rule, mock.MatchedBy(func(activities []model.Activity) bool {
+		return len(activities) == 2 && activities[0].ID == "activity-1" && activities[1].ID == "activity-2"
+	})).Return(nil).Once()
+
+	processed, err = processor.processRule(context.Background(), "test-rule-id")
+	require.NoError(t, err)
+	mockEmailNotifier.AssertExpectations(t)
+	assert.False(t, mini.Exists("outbox:test-rule-id"))
+	assert.Nil(t, processed.NextDeliveryAt)
+
+	_, wasSeen, err := seen.GetSeen(context.Background(), "test-rule-id", "activity-2")
+	require.NoError(t, err)
+	assert.True(t, wasSeen)
+}
+
+func TestRuleProcessor_ProcessRule_RetriesUndelivered(t *testing.T) {
+	mini := miniredis.RunT(t)
+	redisClient := &storage.RedisClient{Client: redis.NewClient(&redis.Options{Addr: mini.Addr()})}
+	ctx := context.Background()
+
+	mockRuleStorage := new(testutil.MockRuleStorage)
+	mockEmailNotifier := new(testutil.MockNotifier)
+	mockTelegramNotifier := new(testutil.MockNotifier)
+	mockProcessor := new(testutil.MockProcessor)
+
+	rule := &model.Rule{
+		ID:         "test-rule-id",
+		UserID:     "test-user-id",
+		Email:      "test@example.com",
+		TelegramID: "12345",
+		Channels:   []string{model.ChannelEmail, model.ChannelTelegram},
+		Type:       "match",
+		ClubIDs:    []string{"club-1"},
+		Active:     true,
+	}
+	activities := []model.Activity{{ID: "activity-1", AvailablePlaces: 1, StartDate: time.Now().Add(24 * time.Hour)}}
+	queued := mock.MatchedBy(func(activities []model.Activity) bool {
+		return len(activities) == 1 && activities[0].ID == "activity-1"
+	})
+
+	mockRuleStorage.On("GetRule", mock.Anything, "test-rule-id").Return(rule, nil)
+	mockRuleStorage.On("UpdateRuleState", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockProcessor.On("Process", mock.Anything, rule).Return(activities, nil).Twice()
+	mockProcessor.On("Process", mock.Anything, rule).Return([]model.Activity(nil), errors.New("fetch matches: circuit breaker open")).Once()
+
+	// SMTP is down at first, while Telegram delivers right away
+	mockEmailNotifier.On("NotifyNewActivities", mock.Anything, mock.Anything, rule, queued).Return(notification.ErrNotConfigured).Once()
+	mockTelegramNotifier.On("NotifyNewActivities", mock.Anything, mock.Anything, rule, queued).Return(nil).Once()
+
+	seen := storage.NewRedisSeenStorage(redisClient)
+	outbox := storage.NewRedisOutboxStorage(redisClient)
+	processor := &ruleProcessor{
+		config:    &config.Config{},
+		ruleStore: mockRuleStorage,
+		seen:      seen,
+		outbox:    outbox,
+		notifiers: newTestRegistry(map[string]notification.Notifier{
+			model.ChannelEmail:    mockEmailNotifier,
+			model.ChannelTelegram: mockTelegramNotifier,
+		}),
+		processor: mockProcessor,
+	}
+
+	processed, err := processor.processRule(ctx, "test-rule-id")
+	require.NoError(t, err)
+
+	_, wasSeen, err := seen.GetSeen(ctx, "test-rule-id", "activity-1")
+	require.NoError(t, err)
+	assert.False(t, wasSeen, "Activities are not marked as seen until every channel delivers them")
+
+	entries, err := outbox.GetOutbox(ctx, "test-rule-id")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, []string{model.ChannelTelegram}, entries[0].Delivered)
+	assert.Equal(t, 1, entries[0].Attempts)
+	assert.Contains(t, entries[0].LastError, "email")
+	require.NotNil(t, processed.NextDeliveryAt)
+	assert.True(t, processed.NextDeliveryAt.Equal(entries[0].NextAttemptAt))
+	assert.GreaterOrEqual(t, time.Until(entries[0].NextAttemptAt), baseRetryDelay-time.Second)
+
+	// The next check finds the activity again, but the retry is not due yet
+	_, err = processor.processRule(ctx, "test-rule-id")
+	require.NoError(t, err)
+	mockEmailNotifier.AssertNumberOfCalls(t, "NotifyNewActivities", 1)
+
+	// Once due, only the failed channel is retried, even if the check fails
+	entries[0].NextAttemptAt = time.Now().Add(-time.Second)
+	require.NoError(t, outbox.SaveOutbox(ctx, "test-rule-id", entries))
+	mockEmailNotifier.On("NotifyNewActivities", mock.Anything, mock.Anything, rule, queued).Return(nil).Once()
+
+	processed, err = processor.processRule(ctx, "test-rule-id")
+	require.NoError(t, err)
+	mockEmailNotifier.AssertExpectations(t)
+	mockTelegramNotifier.AssertExpectations(t)
+	assert.Nil(t, processed.NextDeliveryAt)
+	assert.False(t, mini.Exists("outbox:test-rule-id"))
+
+	_, wasSeen, err = seen.GetSeen(ctx, "test-rule-id", "activity-1")
+	require.NoError(t, err)
+	assert.True(t, wasSeen)
+}
+
+func TestRuleProcessor_ProcessRule_RecordsFailures(t *testing.T) {
+	mockRuleStorage := new(testutil.MockRuleStorage)
+	mockProcessor := new(testutil.MockProcessor)
+
+	rule := &model.Rule{
+		ID:      "test-rule-id",
+		UserID:  "test-user-id",
+		Email:   "test@example.com",
+		Type:    "match",
+		ClubIDs: []string{"club-1"},
+		Active:  true,
+	}
+
+	mockRuleStorage.On("GetRule", mock.Anything, "test-rule-id").Return(rule, nil)
+	mockRuleStorage.On("UpdateRuleState", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockProcessor.On("Process", mock.Anything, rule).Return([]model.Activity(nil), errors.New("fetch matches: circuit breaker open")).Twice()
+	mockProcessor.On("Process", mock.Anything, rule).Return([]model.Activity{}, nil).Once()
+
+	processor := &ruleProcessor{
+		config:    &config.Config{CheckInterval: 30},
+		ruleStore: mockRuleStorage,
+		notifiers: notification.NewRegistry(),
+		processor: mockProcessor,
+	}
+
+	_, err := processor.processRule(context.Background(), "test-rule-id")
+	require.NoError(t, err)
+	assert.Equal(t, 1, rule.ConsecutiveFailures)
+	assert.Equal(t, "fetch matches: circuit breaker open", rule.LastError)
+	require.NotNil(t, rule.NextRetryAt)
+	firstRetry := rule.NextRetryAt.Sub(rule.LastChecked)
+	assert.GreaterOrEqual(t, firstRetry, baseRetryDelay)
+
+	_, err = processor.processRule(context.Background(), "test-rule-id")
+	require.NoError(t, err)
+	assert.Equal(t, 2, rule.ConsecutiveFailures)
+	assert.GreaterOrEqual(t, rule.NextRetryAt.Sub(rule.LastChecked), 2*baseRetryDelay)
+
+	// A successful check clears the failure state
+	_, err = processor.processRule(context.Background(), "test-rule-id")
+	require.NoError(t, err)
+	assert.Zero(t, rule.ConsecutiveFailures)
+	assert.Nil(t, rule.NextRetryAt)
+	assert.Empty(t, rule.LastError)
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		interval time.Duration
+		expected time.Duration
+	}{
+		{failures: 1, interval: 0, expected: time.Minute},
+		{failures: 2, interval: 0, expected: 2 * time.Minute},
+		{failures: 4, interval: 0, expected: 8 * time.Minute},
+		{failures: 7, interval: 0, expected: time.Hour},
+		{failures: 40, interval: 0, expected: time.Hour},
+		{failures: 1, interval: 5 * time.Minute, expected: 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		delay := retryDelay(tt.failures, tt.interval)
+		assert.GreaterOrEqual(t, delay, tt.expected, "failures=%d", tt.failures)
+		assert.LessOrEqual(t, delay, tt.expected+tt.expected/10, "failures=%d", tt.failures)
+	}
+}
@@ -3,36 +3,76 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rafa-garcia/padel-alert/internal/config"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
 	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/metrics"
+	"github.com/rafa-garcia/padel-alert/internal/source"
 	"github.com/rafa-garcia/padel-alert/internal/storage"
+	"github.com/rafa-garcia/padel-alert/internal/util"
 )
 
-// Scheduler manages periodic rule processing
+// defaultLease is used when no scheduler lease is configured
+const defaultLease = 2 * time.Minute
+
+// tickInterval is how often the scheduler looks for due rules and digests
+const tickInterval = 10 * time.Second
+
+// Defaults used when the scheduler settings are not configured
+const (
+	defaultBatchSize   = 100
+	defaultWorkerCount = 10
+	defaultQueueSize   = 100
+)
+
+// Scheduler manages periodic rule processing. Several schedulers can share a
+// Redis instance: due rules are claimed atomically under a lease, so each
+// rule is processed by one scheduler at a time.
 type Scheduler struct {
 	config     *config.Config
 	ruleStore  storage.RuleStorage
+	owner      string
 	processor  RuleProcessor
 	workerPool *WorkerPool
 	stopCh     chan struct{}
 	wg         sync.WaitGroup
 	running    bool
 	mu         sync.Mutex
+
+	// lastTick holds the Unix nanoseconds at which the last tick finished
+	lastTick atomic.Int64
+
+	digests     DigestProcessor
+	digestStore storage.DigestStorage
+
+	// state holds the pause flag shared by every replica
+	state storage.SchedulerStorage
+
+	// inFlight holds the rules and digests submitted to the worker pool and not yet finished
+	inFlight   map[string]struct{}
+	inFlightMu sync.Mutex
 }
 
 // NewScheduler creates a new scheduler
-func NewScheduler(cfg *config.Config, ruleStore storage.RuleStorage) *Scheduler {
-	processor := newRuleProcessor(cfg, ruleStore)
+func NewScheduler(cfg *config.Config, ruleStore storage.RuleStorage, activitySource source.ActivitySource) *Scheduler {
+	processor := newRuleProcessor(cfg, ruleStore, activitySource)
 
 	return &Scheduler{
-		config:     cfg,
-		ruleStore:  ruleStore,
-		processor:  processor,
-		workerPool: NewWorkerPool(10), // 10 workers
-		stopCh:     make(chan struct{}),
+		config:      cfg,
+		ruleStore:   ruleStore,
+		owner:       newOwnerID(),
+		processor:   processor,
+		digests:     processor,
+		digestStore: processor.digests,
+		state:       processor.state,
+		workerPool:  NewWorkerPool(positiveOr(cfg.WorkerCount, defaultWorkerCount), positiveOr(cfg.WorkerQueueSize, defaultQueueSize)),
+		stopCh:      make(chan struct{}),
+		inFlight:    make(map[string]struct{}),
 	}
 }
 
@@ -47,6 +87,7 @@ func (s *Scheduler) Start() error {
 
 	s.workerPool.Start()
 	s.running = true
+	s.lastTick.Store(time.Now().UnixNano())
 
 	s.wg.Add(1)
 	go s.run()
@@ -76,63 +117,259 @@ func (s *Scheduler) Stop() {
 func (s *Scheduler) run() {
 	defer s.wg.Done()
 
-	ticker := time.NewTicker(10 * time.Second)
+	ticker := time.NewTicker(tickInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			s.processSchedule()
+			s.tick()
 		case <-s.stopCh:
 			return
 		}
 	}
 }
 
-// processSchedule checks and processes scheduled rules
+// tick processes due rules and digests, unless an admin has paused the scheduler
+func (s *Scheduler) tick() {
+	if !s.paused(context.Background()) {
+		s.processSchedule()
+		s.processDigests()
+	}
+	s.lastTick.Store(time.Now().UnixNano())
+}
+
+// processSchedule claims due rules and processes them. It claims no more
+// rules than the worker queue can take, and skips rules still being processed.
+// A rule's lease restarts when a worker picks it up and is renewed until the
+// check finishes; rules whose lease ran out while queued are left to
+// whichever scheduler claims them next.
 func (s *Scheduler) processSchedule() {
 	ctx := context.Background()
-	now := time.Now()
 
-	rules, err := s.ruleStore.GetScheduledRules(ctx, now)
+	limit := min(s.batchSize(), s.workerPool.Free())
+	if limit <= 0 {
+		logger.Warn("Worker queue full, skipping tick", "queued", s.workerPool.Queued())
+		return
+	}
+
+	rules, err := s.ruleStore.ClaimScheduledRules(ctx, time.Now(), s.owner, s.lease(), limit)
 	if err != nil {
-		logger.Error("Failed to get scheduled rules", err)
+		logger.Error("Failed to claim scheduled rules", err)
 		return
 	}
 
-	for _, ruleID := range rules {
-		ruleID := ruleID
+	now := time.Now()
+	for _, claimed := range rules {
+		ruleID := claimed.RuleID
+		metrics.SchedulerLag.Observe(now.Sub(claimed.DueAt).Seconds())
+
+		// The lease ran out while a worker is still on this rule; that worker
+		// reschedules it when it finishes, since it now holds the new lease.
+		if !s.startRule(ruleID) {
+			logger.Debug("Rule still in flight, skipping", "rule_id", ruleID)
+			continue
+		}
+
+		submitted := s.workerPool.TrySubmit(func() {
+			defer s.finishRule(ruleID)
+
+			// The rule may have waited in the queue, so its lease starts now
+			if !s.renewLease(ctx, ruleID) {
+				return
+			}
+			leaseCtx, release := s.holdLease(ctx, ruleID)
 
-		s.workerPool.Submit(func() {
-			// Process the rule
-			err := s.processor.processRule(ctx, ruleID)
+			rule, err := s.processor.processRule(leaseCtx, ruleID)
+			release()
 			if err != nil {
 				logger.Error("Failed to process rule", err, "rule_id", ruleID)
 			}
 
-			next := time.Now().Add(time.Duration(s.config.CheckInterval) * time.Second)
-			if err := s.ruleStore.ScheduleRule(ctx, ruleID, next); err != nil {
-				logger.Error("Failed to reschedule rule", err, "rule_id", ruleID)
-			} else {
-				logger.Debug("Rule scheduled for next check", "rule_id", ruleID, "next_check", next.Format(time.RFC3339))
-			}
+			s.completeRule(ctx, ruleID, s.nextRun(rule, time.Now()))
 		})
+		if !submitted {
+			// Hand the rule back so it is picked up on a later tick
+			s.finishRule(ruleID)
+			s.completeRule(ctx, ruleID, time.Now())
+		}
 	}
 }
 
+// holdLease keeps renewing this scheduler's lease on a claimed rule until
+// release is called, so that no other scheduler claims the rule however long
+// it takes to process. The returned context is cancelled if the lease is lost.
+func (s *Scheduler) holdLease(ctx context.Context, ruleID string) (leaseCtx context.Context, release func()) {
+	lease := s.lease()
+	leaseCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		interval := lease / 3
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		renewed := time.Now()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			ok, err := s.ruleStore.RenewRuleLease(ctx, ruleID, s.owner, lease)
+			switch {
+			case err == nil && ok:
+				renewed = time.Now()
+				continue
+			case err == nil:
+				logger.Warn("Rule lease lost while processing, stopping", "rule_id", ruleID, "owner", s.owner)
+			case time.Since(renewed)+interval < lease:
+				logger.Error("Failed to renew rule lease, retrying", err, "rule_id", ruleID)
+				continue
+			default:
+				logger.Error("Failed to renew rule lease before it expires, stopping", err, "rule_id", ruleID)
+			}
+
+			cancel()
+			return
+		}
+	}()
+
+	release = func() {
+		close(done)
+		<-stopped
+		cancel()
+	}
+	return leaseCtx, release
+}
+
+// renewLease restarts this scheduler's lease on a rule from now, reporting whether it still holds it
+func (s *Scheduler) renewLease(ctx context.Context, ruleID string) bool {
+	renewed, err := s.ruleStore.RenewRuleLease(ctx, ruleID, s.owner, s.lease())
+	switch {
+	case err != nil:
+		logger.Error("Failed to renew rule lease", err, "rule_id", ruleID)
+		return false
+	case !renewed:
+		logger.Warn("Rule lease expired before processing started", "rule_id", ruleID, "owner", s.owner)
+		return false
+	}
+	return true
+}
+
+// completeRule reschedules a claimed rule and releases its lease
+func (s *Scheduler) completeRule(ctx context.Context, ruleID string, next time.Time) {
+	completed, err := s.ruleStore.CompleteScheduledRule(ctx, ruleID, s.owner, next)
+	switch {
+	case err != nil:
+		logger.Error("Failed to reschedule rule", err, "rule_id", ruleID)
+	case !completed:
+		logger.Warn("Rule lease expired before processing finished", "rule_id", ruleID, "owner", s.owner)
+	default:
+		logger.Debug("Rule scheduled for next check", "rule_id", ruleID, "next_check", next.Format(time.RFC3339))
+	}
+}
+
+// nextRun returns when a rule should next be checked. Snoozed rules stay in
+// the schedule and are checked when the snooze ends. Failing rules wait for
+// their backoff. Other rules use their own check interval when set, and are
+// checked as soon as their quiet hours end so that activities queued during
+// the window go out promptly. Rules with undelivered notifications are
+// checked early when the next delivery attempt is due first.
+func (s *Scheduler) nextRun(rule *model.Rule, now time.Time) time.Time {
+	interval := time.Duration(s.config.CheckInterval) * time.Second
+	if rule == nil {
+		return now.Add(interval)
+	}
+
+	if rule.IsSnoozed(now) {
+		return *rule.SnoozedUntil
+	}
+
+	var next time.Time
+	if rule.ConsecutiveFailures > 0 && rule.NextRetryAt != nil && rule.NextRetryAt.After(now) {
+		next = *rule.NextRetryAt
+	} else {
+		next = now.Add(rule.CheckInterval(interval))
+		if quiet, until := rule.InQuietHours(now); quiet && until.Before(next) {
+			next = until
+		}
+	}
+
+	if rule.NextDeliveryAt != nil && rule.NextDeliveryAt.After(now) && rule.NextDeliveryAt.Before(next) {
+		next = *rule.NextDeliveryAt
+	}
+	return next
+}
+
+// startRule marks a rule as in flight, returning false if it already is
+func (s *Scheduler) startRule(ruleID string) bool {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+
+	if _, ok := s.inFlight[ruleID]; ok {
+		return false
+	}
+	s.inFlight[ruleID] = struct{}{}
+	return true
+}
+
+// finishRule clears a rule's in-flight mark
+func (s *Scheduler) finishRule(ruleID string) {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+
+	delete(s.inFlight, ruleID)
+}
+
+// batchSize returns the maximum number of rules claimed per tick
+func (s *Scheduler) batchSize() int {
+	return positiveOr(s.config.SchedulerBatchSize, defaultBatchSize)
+}
+
+// lease returns how long a claimed rule is owned by this scheduler
+func (s *Scheduler) lease() time.Duration {
+	if s.config.SchedulerLease <= 0 {
+		return defaultLease
+	}
+	return time.Duration(s.config.SchedulerLease) * time.Second
+}
+
+// positiveOr returns value, or fallback when value is not positive
+func positiveOr(value, fallback int) int {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// newOwnerID returns an identifier for this scheduler instance, used to own rule leases
+func newOwnerID() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "scheduler"
+	}
+	return hostname + "-" + util.GenerateID()
+}
+
 // WorkerPool handles concurrent task processing
 type WorkerPool struct {
 	numWorkers int
+	busy       atomic.Int32
 	tasks      chan func()
 	stopCh     chan struct{}
 	wg         sync.WaitGroup
 }
 
-// NewWorkerPool creates a new worker pool
-func NewWorkerPool(numWorkers int) *WorkerPool {
+// NewWorkerPool creates a new worker pool with room for queueSize waiting tasks
+func NewWorkerPool(numWorkers, queueSize int) *WorkerPool {
 	return &WorkerPool{
 		numWorkers: numWorkers,
-		tasks:      make(chan func(), 100),
+		tasks:      make(chan func(), queueSize),
 		stopCh:     make(chan struct{}),
 	}
 }
@@ -151,14 +388,43 @@ func (p *WorkerPool) Stop() {
 	p.wg.Wait()
 }
 
-// Submit adds a task to the pool
+// Submit adds a task to the pool, blocking while the queue is full
 func (p *WorkerPool) Submit(task func()) {
 	select {
 	case p.tasks <- task:
+		metrics.SchedulerQueueDepth.Set(float64(len(p.tasks)))
 	case <-p.stopCh:
 	}
 }
 
+// TrySubmit adds a task to the pool without blocking, returning false if the queue is full
+func (p *WorkerPool) TrySubmit(task func()) bool {
+	select {
+	case p.tasks <- task:
+		metrics.SchedulerQueueDepth.Set(float64(len(p.tasks)))
+		return true
+	default:
+		return false
+	}
+}
+
+// Queued returns the number of tasks waiting for a worker
+func (p *WorkerPool) Queued() int {
+	return len(p.tasks)
+}
+
+// Free returns the number of tasks that can be queued without blocking
+func (p *WorkerPool) Free() int {
+	return cap(p.tasks) - len(p.tasks)
+}
+
+// setBusy reports the share of busy workers
+func (p *WorkerPool) setBusy(busy int32) {
+	if p.numWorkers > 0 {
+		metrics.SchedulerWorkerSaturation.Set(float64(busy) / float64(p.numWorkers))
+	}
+}
+
 // worker processes tasks
 func (p *WorkerPool) worker() {
 	defer p.wg.Done()
@@ -166,6 +432,8 @@ func (p *WorkerPool) worker() {
 	for {
 		select {
 		case task := <-p.tasks:
+			metrics.SchedulerQueueDepth.Set(float64(len(p.tasks)))
+			p.setBusy(p.busy.Add(1))
 			func() {
 				defer func() {
 					if r := recover(); r != nil {
@@ -174,6 +442,7 @@ func (p *WorkerPool) worker() {
 				}()
 				task()
 			}()
+			p.setBusy(p.busy.Add(-1))
 		case <-p.stopCh:
 			return
 		}
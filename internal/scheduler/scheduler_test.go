@@ -2,14 +2,21 @@ package scheduler
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/rafa-garcia/padel-alert/internal/config"
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/source"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
 	"github.com/rafa-garcia/padel-alert/internal/testutil"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // testRuleProcessor is a simple test implementation of RuleProcessor
@@ -28,16 +35,25 @@ func newTestRuleProcessor() *testRuleProcessor {
 	}
 }
 
-func (p *testRuleProcessor) processRule(ctx context.Context, ruleID string) error {
+func (p *testRuleProcessor) processRule(ctx context.Context, ruleID string) (*model.Rule, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	p.processCalled = true
 	p.processedIDs = append(p.processedIDs, ruleID)
-	return p.processError
+	return &model.Rule{ID: ruleID, Active: true}, p.processError
+}
+
+// scheduled returns the rules as claimed from the schedule, due now
+func scheduled(ruleIDs ...string) []storage.ScheduledRule {
+	rules := make([]storage.ScheduledRule, 0, len(ruleIDs))
+	for _, id := range ruleIDs {
+		rules = append(rules, storage.ScheduledRule{RuleID: id, DueAt: time.Now()})
+	}
+	return rules
 }
 
 func TestWorkerPool(t *testing.T) {
-	pool := NewWorkerPool(3)
+	pool := NewWorkerPool(3, 10)
 
 	taskCalled := false
 	done := make(chan struct{})
@@ -61,10 +77,12 @@ func TestWorkerPool(t *testing.T) {
 func TestScheduler_Start_Stop(t *testing.T) {
 	mockStorage := new(testutil.MockRuleStorage)
 	cfg := &config.Config{CheckInterval: 300}
+	activitySource, err := source.NewFileSource(t.TempDir())
+	require.NoError(t, err)
 
-	scheduler := NewScheduler(cfg, mockStorage)
+	scheduler := NewScheduler(cfg, mockStorage, activitySource)
 
-	err := scheduler.Start()
+	err = scheduler.Start()
 	assert.NoError(t, err)
 	assert.True(t, scheduler.running)
 
@@ -84,17 +102,20 @@ func TestScheduler_ProcessSchedule(t *testing.T) {
 		config:     cfg,
 		ruleStore:  mockStorage,
 		processor:  testProcessor,
-		workerPool: NewWorkerPool(1),
+		workerPool: NewWorkerPool(1, 10),
 		stopCh:     make(chan struct{}),
+		inFlight:   make(map[string]struct{}),
 	}
 
 	scheduler.workerPool.Start()
 	defer scheduler.workerPool.Stop()
-	ruleIDs := []string{"rule-1", "rule-2"}
+	due := time.Now().Add(-time.Minute)
+	claimed := []storage.ScheduledRule{{RuleID: "rule-1", DueAt: due}, {RuleID: "rule-2", DueAt: due}}
 
-	mockStorage.On("GetScheduledRules", mock.Anything, mock.Anything).Return(ruleIDs, nil)
+	mockStorage.On("ClaimScheduledRules", mock.Anything, mock.Anything, mock.Anything, defaultLease, 10).Return(claimed, nil)
+	mockStorage.On("RenewRuleLease", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
 
-	mockStorage.On("ScheduleRule", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockStorage.On("CompleteScheduledRule", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
 
 	scheduler.processSchedule()
 
@@ -112,3 +133,316 @@ func TestScheduler_ProcessSchedule(t *testing.T) {
 
 	mockStorage.AssertExpectations(t)
 }
+
+func TestScheduler_ConcurrentReplicas(t *testing.T) {
+	mini, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mini.Close()
+
+	redisClient := &storage.RedisClient{Client: redis.NewClient(&redis.Options{Addr: mini.Addr()})}
+	ruleStore := storage.NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+
+	const ruleCount = 50
+	for i := 0; i < ruleCount; i++ {
+		require.NoError(t, ruleStore.ScheduleRule(ctx, fmt.Sprintf("rule-%d", i), time.Now().Add(-time.Minute)))
+	}
+
+	testProcessor := newTestRuleProcessor()
+	cfg := &config.Config{CheckInterval: 300}
+
+	replicas := make([]*Scheduler, 4)
+	for i := range replicas {
+		replicas[i] = &Scheduler{
+			config:     cfg,
+			ruleStore:  ruleStore,
+			owner:      fmt.Sprintf("replica-%d", i),
+			processor:  testProcessor,
+			workerPool: NewWorkerPool(4, 100),
+			stopCh:     make(chan struct{}),
+			inFlight:   make(map[string]struct{}),
+		}
+		replicas[i].workerPool.Start()
+		defer replicas[i].workerPool.Stop()
+	}
+
+	var wg sync.WaitGroup
+	for round := 0; round < 3; round++ {
+		for _, replica := range replicas {
+			wg.Add(1)
+			go func(s *Scheduler) {
+				defer wg.Done()
+				s.processSchedule()
+			}(replica)
+		}
+	}
+	wg.Wait()
+
+	require.Eventually(t, func() bool {
+		testProcessor.mu.Lock()
+		defer testProcessor.mu.Unlock()
+		return len(testProcessor.processedIDs) >= ruleCount && len(mini.Keys()) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	testProcessor.mu.Lock()
+	counts := make(map[string]int)
+	for _, id := range testProcessor.processedIDs {
+		counts[id]++
+	}
+	testProcessor.mu.Unlock()
+
+	assert.Len(t, counts, ruleCount)
+	for id, count := range counts {
+		assert.Equal(t, 1, count, "rule %s processed more than once", id)
+	}
+
+	// Every rule is rescheduled into the future and no leases are left behind
+	due, err := ruleStore.GetScheduledRules(ctx, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, due)
+	assert.Equal(t, []string{"rules:schedule"}, mini.Keys())
+}
+
+// blockingRuleProcessor holds every rule until release is closed
+type blockingRuleProcessor struct {
+	started chan string
+	release chan struct{}
+}
+
+func (p *blockingRuleProcessor) processRule(ctx context.Context, ruleID string) (*model.Rule, error) {
+	p.started <- ruleID
+	<-p.release
+	return nil, nil
+}
+
+func TestScheduler_ProcessSchedule_SkipsInFlightRules(t *testing.T) {
+	mockStorage := new(testutil.MockRuleStorage)
+	processor := &blockingRuleProcessor{started: make(chan string, 10), release: make(chan struct{})}
+	cfg := &config.Config{CheckInterval: 300}
+
+	scheduler := &Scheduler{
+		config:     cfg,
+		ruleStore:  mockStorage,
+		owner:      "replica-1",
+		processor:  processor,
+		workerPool: NewWorkerPool(2, 10),
+		stopCh:     make(chan struct{}),
+		inFlight:   make(map[string]struct{}),
+	}
+
+	scheduler.workerPool.Start()
+	defer scheduler.workerPool.Stop()
+
+	mockStorage.On("ClaimScheduledRules", mock.Anything, mock.Anything, "replica-1", defaultLease, mock.Anything).Return(scheduled("rule-1"), nil)
+	mockStorage.On("RenewRuleLease", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	mockStorage.On("CompleteScheduledRule", mock.Anything, "rule-1", "replica-1", mock.Anything).Return(true, nil)
+
+	scheduler.processSchedule()
+	assert.Equal(t, "rule-1", <-processor.started)
+
+	// The rule is claimed again while still being processed
+	scheduler.processSchedule()
+
+	close(processor.release)
+	require.Eventually(t, func() bool {
+		scheduler.inFlightMu.Lock()
+		defer scheduler.inFlightMu.Unlock()
+		return len(scheduler.inFlight) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Empty(t, processor.started, "rule should only be submitted once")
+	mockStorage.AssertNumberOfCalls(t, "CompleteScheduledRule", 1)
+}
+
+func TestScheduler_ProcessSchedule_LongCheckKeepsLease(t *testing.T) {
+	mini, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mini.Close()
+
+	// Lease TTLs only run down in miniredis when its clock is moved, so keep
+	// it in step with the real one
+	stopClock := make(chan struct{})
+	defer close(stopClock)
+	go func() {
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				mini.FastForward(20 * time.Millisecond)
+			case <-stopClock:
+				return
+			}
+		}
+	}()
+
+	redisClient := &storage.RedisClient{Client: redis.NewClient(&redis.Options{Addr: mini.Addr()})}
+	ruleStore := storage.NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+	require.NoError(t, ruleStore.ScheduleRule(ctx, "rule-1", time.Now().Add(-time.Minute)))
+
+	processor := &blockingRuleProcessor{started: make(chan string, 10), release: make(chan struct{})}
+	cfg := &config.Config{CheckInterval: 300, SchedulerLease: 1}
+
+	replicas := make([]*Scheduler, 2)
+	for i := range replicas {
+		replicas[i] = &Scheduler{
+			config:     cfg,
+			ruleStore:  ruleStore,
+			owner:      fmt.Sprintf("replica-%d", i),
+			processor:  processor,
+			workerPool: NewWorkerPool(1, 10),
+			stopCh:     make(chan struct{}),
+			inFlight:   make(map[string]struct{}),
+		}
+		replicas[i].workerPool.Start()
+		defer replicas[i].workerPool.Stop()
+	}
+
+	replicas[0].processSchedule()
+	assert.Equal(t, "rule-1", <-processor.started)
+
+	// The check runs for well over the lease while the other replica keeps
+	// looking for due rules
+	deadline := time.Now().Add(2500 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		replicas[1].processSchedule()
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Empty(t, processor.started, "rule should not be claimed by another replica mid-check")
+
+	owner, err := mini.Get("lease:rule:rule-1")
+	require.NoError(t, err)
+	assert.Equal(t, "replica-0", owner)
+
+	close(processor.release)
+	require.Eventually(t, func() bool {
+		return !mini.Exists("lease:rule:rule-1")
+	}, time.Second, 10*time.Millisecond)
+
+	due, err := ruleStore.GetScheduledRules(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, due, "rule should be rescheduled by its check interval")
+}
+
+func TestScheduler_ProcessSchedule_SkipsRulesLeaseLostInQueue(t *testing.T) {
+	mockStorage := new(testutil.MockRuleStorage)
+	testProcessor := newTestRuleProcessor()
+
+	scheduler := &Scheduler{
+		config:     &config.Config{CheckInterval: 300},
+		ruleStore:  mockStorage,
+		owner:      "replica-1",
+		processor:  testProcessor,
+		workerPool: NewWorkerPool(1, 10),
+		stopCh:     make(chan struct{}),
+		inFlight:   make(map[string]struct{}),
+	}
+
+	scheduler.workerPool.Start()
+	defer scheduler.workerPool.Stop()
+
+	// The lease ran out while the rule waited for a worker
+	mockStorage.On("ClaimScheduledRules", mock.Anything, mock.Anything, "replica-1", defaultLease, mock.Anything).Return(scheduled("rule-1"), nil)
+	mockStorage.On("RenewRuleLease", mock.Anything, "rule-1", "replica-1", defaultLease).Return(false, nil)
+
+	scheduler.processSchedule()
+	require.Eventually(t, func() bool {
+		scheduler.inFlightMu.Lock()
+		defer scheduler.inFlightMu.Unlock()
+		return len(scheduler.inFlight) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Empty(t, testProcessor.processedIDs)
+	mockStorage.AssertNotCalled(t, "CompleteScheduledRule", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestScheduler_ProcessSchedule_Backpressure(t *testing.T) {
+	mockStorage := new(testutil.MockRuleStorage)
+	processor := &blockingRuleProcessor{started: make(chan string, 10), release: make(chan struct{})}
+	cfg := &config.Config{CheckInterval: 300, SchedulerBatchSize: 50}
+
+	scheduler := &Scheduler{
+		config:     cfg,
+		ruleStore:  mockStorage,
+		owner:      "replica-1",
+		processor:  processor,
+		workerPool: NewWorkerPool(1, 2),
+		stopCh:     make(chan struct{}),
+		inFlight:   make(map[string]struct{}),
+	}
+
+	scheduler.workerPool.Start()
+	defer scheduler.workerPool.Stop()
+	defer close(processor.release)
+
+	// Only as many rules as the queue can hold are claimed
+	mockStorage.On("ClaimScheduledRules", mock.Anything, mock.Anything, "replica-1", defaultLease, 2).Return(scheduled("rule-1", "rule-2"), nil).Once()
+	mockStorage.On("ClaimScheduledRules", mock.Anything, mock.Anything, "replica-1", defaultLease, 1).Return(scheduled("rule-3"), nil).Once()
+	mockStorage.On("RenewRuleLease", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	mockStorage.On("CompleteScheduledRule", mock.Anything, mock.Anything, "replica-1", mock.Anything).Return(true, nil)
+
+	scheduler.processSchedule()
+	assert.Equal(t, "rule-1", <-processor.started)
+
+	// One worker is busy and rule-2 is queued, leaving a single free slot
+	scheduler.processSchedule()
+	assert.Equal(t, 0, scheduler.workerPool.Free())
+
+	// The queue is full, so nothing is claimed
+	scheduler.processSchedule()
+
+	mockStorage.AssertNumberOfCalls(t, "ClaimScheduledRules", 2)
+}
+
+func TestWorkerPool_TrySubmit(t *testing.T) {
+	pool := NewWorkerPool(1, 1)
+
+	assert.Equal(t, 1, pool.Free())
+	assert.True(t, pool.TrySubmit(func() {}))
+	assert.Equal(t, 1, pool.Queued())
+	assert.False(t, pool.TrySubmit(func() {}))
+}
+
+func TestScheduler_NextRun(t *testing.T) {
+	scheduler := &Scheduler{config: &config.Config{CheckInterval: 300}}
+	now := time.Date(2030, 6, 1, 22, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, now.Add(5*time.Minute), scheduler.nextRun(nil, now), "Missing rules use the global interval")
+	assert.Equal(t, now.Add(5*time.Minute), scheduler.nextRun(&model.Rule{}, now))
+	assert.Equal(t, now.Add(time.Minute), scheduler.nextRun(&model.Rule{CheckIntervalSeconds: 60}, now))
+
+	// An hourly rule is checked as soon as its quiet hours end
+	rule := &model.Rule{
+		CheckIntervalSeconds: 3600,
+		QuietHours:           &model.QuietHours{Start: "21:00", End: "22:30"},
+	}
+	assert.Equal(t, time.Date(2030, 6, 1, 22, 30, 0, 0, time.UTC), scheduler.nextRun(rule, now))
+
+	rule.QuietHours = &model.QuietHours{Start: "21:00", End: "23:30"}
+	assert.Equal(t, now.Add(time.Hour), scheduler.nextRun(rule, now))
+
+	// Failing rules wait for their backoff
+	retryAt := now.Add(3 * time.Hour)
+	failing := &model.Rule{CheckIntervalSeconds: 60, ConsecutiveFailures: 3, NextRetryAt: &retryAt}
+	assert.Equal(t, retryAt, scheduler.nextRun(failing, now))
+
+	// Undelivered notifications are retried before the next check if due sooner
+	deliveryAt := now.Add(2 * time.Minute)
+	failing.NextDeliveryAt = &deliveryAt
+	assert.Equal(t, deliveryAt, scheduler.nextRun(failing, now))
+
+	undelivered := &model.Rule{NextDeliveryAt: &deliveryAt}
+	assert.Equal(t, deliveryAt, scheduler.nextRun(undelivered, now))
+	undelivered.CheckIntervalSeconds = 60
+	assert.Equal(t, now.Add(time.Minute), scheduler.nextRun(undelivered, now))
+
+	// Snoozed rules stay scheduled for when the snooze ends
+	snoozedUntil := now.Add(48 * time.Hour)
+	snoozed := &model.Rule{CheckIntervalSeconds: 60, SnoozedUntil: &snoozedUntil}
+	assert.Equal(t, snoozedUntil, scheduler.nextRun(snoozed, now))
+
+	ended := now.Add(-time.Hour)
+	snoozed.SnoozedUntil = &ended
+	assert.Equal(t, now.Add(time.Minute), scheduler.nextRun(snoozed, now))
+}
@@ -0,0 +1,143 @@
+package source
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling an endpoint whose circuit is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Circuit breaker states, also exported as the circuit state metric value
+const (
+	CircuitClosed   = 0
+	CircuitHalfOpen = 1
+	CircuitOpen     = 2
+)
+
+// Circuit breaker defaults
+const (
+	DefaultFailureThreshold = 5
+	DefaultCircuitCooldown  = 30 * time.Second
+)
+
+// BreakerSettings configures a circuit breaker
+type BreakerSettings struct {
+	FailureThreshold int           // Consecutive failures that open the circuit
+	Cooldown         time.Duration // Time the circuit stays open before a trial call
+}
+
+// withDefaults fills unset fields with the default breaker settings
+func (s BreakerSettings) withDefaults() BreakerSettings {
+	if s.FailureThreshold <= 0 {
+		s.FailureThreshold = DefaultFailureThreshold
+	}
+	if s.Cooldown <= 0 {
+		s.Cooldown = DefaultCircuitCooldown
+	}
+	return s
+}
+
+// CircuitBreaker stops calls to a failing endpoint. After FailureThreshold
+// consecutive failures the circuit opens and calls fail fast. Once the
+// cooldown passes a single trial call is let through: success closes the
+// circuit, failure opens it again.
+type CircuitBreaker struct {
+	settings BreakerSettings
+	now      func() time.Time
+	onChange func(state int)
+
+	mu       sync.Mutex
+	state    int
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// NewCircuitBreaker creates a closed circuit breaker. onChange, when set, is
+// called with the new state whenever it changes.
+func NewCircuitBreaker(settings BreakerSettings, onChange func(state int)) *CircuitBreaker {
+	return &CircuitBreaker{
+		settings: settings.withDefaults(),
+		now:      time.Now,
+		onChange: onChange,
+	}
+}
+
+// Allow reports whether a call may proceed. A caller that is allowed must
+// report the outcome with Success or Failure.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.settings.Cooldown {
+			return false
+		}
+		b.setState(CircuitHalfOpen)
+		b.trial = true
+		return true
+	case CircuitHalfOpen:
+		// Only the trial call is let through until it reports back
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success records a successful call, closing the circuit
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.trial = false
+	b.setState(CircuitClosed)
+}
+
+// Failure records a failed call, opening the circuit once the threshold is
+// reached or when the trial call fails
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trial = false
+	if b.state == CircuitHalfOpen || b.failures >= b.settings.FailureThreshold {
+		b.openedAt = b.now()
+		b.setState(CircuitOpen)
+	}
+}
+
+// Release gives up an allowed call without an outcome, such as when the
+// caller's context ends, so that a trial call can be made by someone else
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
+
+// State returns the current circuit state
+func (b *CircuitBreaker) State() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// setState changes the state and notifies the listener; callers hold mu
+func (b *CircuitBreaker) setState(state int) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	if b.onChange != nil {
+		b.onChange(state)
+	}
+}
@@ -0,0 +1,80 @@
+package source
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	var states []int
+	breaker := NewCircuitBreaker(BreakerSettings{FailureThreshold: 3, Cooldown: time.Minute}, func(state int) {
+		states = append(states, state)
+	})
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		assert.True(t, breaker.Allow())
+		breaker.Failure()
+	}
+	assert.Equal(t, CircuitClosed, breaker.State())
+
+	// A success resets the failure count
+	assert.True(t, breaker.Allow())
+	breaker.Success()
+	for i := 0; i < 2; i++ {
+		assert.True(t, breaker.Allow())
+		breaker.Failure()
+	}
+	assert.Equal(t, CircuitClosed, breaker.State())
+
+	assert.True(t, breaker.Allow())
+	breaker.Failure()
+	assert.Equal(t, CircuitOpen, breaker.State())
+	assert.False(t, breaker.Allow())
+	assert.Equal(t, []int{CircuitOpen}, states)
+}
+
+func TestCircuitBreaker_HalfOpenTrial(t *testing.T) {
+	breaker := NewCircuitBreaker(BreakerSettings{FailureThreshold: 1, Cooldown: time.Minute}, nil)
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	assert.True(t, breaker.Allow())
+	breaker.Failure()
+	assert.False(t, breaker.Allow())
+
+	// After the cooldown a single trial call goes through
+	now = now.Add(time.Minute)
+	assert.True(t, breaker.Allow())
+	assert.Equal(t, CircuitHalfOpen, breaker.State())
+	assert.False(t, breaker.Allow())
+
+	// A failed trial opens the circuit for another cooldown
+	breaker.Failure()
+	assert.Equal(t, CircuitOpen, breaker.State())
+	assert.False(t, breaker.Allow())
+
+	now = now.Add(time.Minute)
+	assert.True(t, breaker.Allow())
+	breaker.Success()
+	assert.Equal(t, CircuitClosed, breaker.State())
+	assert.True(t, breaker.Allow())
+	assert.True(t, breaker.Allow())
+}
+
+func TestCircuitBreaker_ReleaseFreesTrial(t *testing.T) {
+	breaker := NewCircuitBreaker(BreakerSettings{FailureThreshold: 1, Cooldown: time.Minute}, nil)
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	breaker.Allow()
+	breaker.Failure()
+
+	now = now.Add(time.Minute)
+	assert.True(t, breaker.Allow())
+	breaker.Release()
+	assert.True(t, breaker.Allow(), "A released trial should let another call try")
+}
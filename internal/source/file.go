@@ -0,0 +1,142 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/rafa-garcia/go-playtomic-api/models"
+)
+
+// Fixture file names read by FileSource
+const (
+	matchesFixture = "matches.json"
+	classesFixture = "classes.json"
+	lessonsFixture = "lessons.json"
+)
+
+// FileSource serves activities from JSON fixture files so the service can run
+// without network access. Each file holds an array of the corresponding
+// Playtomic model; a missing file serves no results.
+type FileSource struct {
+	matches []models.Match
+	classes []models.Class
+	lessons []models.Lesson
+}
+
+// NewFileSource loads matches.json, classes.json and lessons.json from dir
+func NewFileSource(dir string) (*FileSource, error) {
+	s := &FileSource{}
+
+	if err := loadFixture(filepath.Join(dir, matchesFixture), &s.matches); err != nil {
+		return nil, err
+	}
+	if err := loadFixture(filepath.Join(dir, classesFixture), &s.classes); err != nil {
+		return nil, err
+	}
+	if err := loadFixture(filepath.Join(dir, lessonsFixture), &s.lessons); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// GetMatches returns the page of fixture matches at the requested clubs
+func (s *FileSource) GetMatches(ctx context.Context, params *models.SearchMatchesParams) ([]models.Match, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var matches []models.Match
+	for _, m := range s.matches {
+		if matchesTenant(params.TenantIDs, m.Tenant.TenantID) && startsFrom(params.FromStartDate, m.StartDate) {
+			matches = append(matches, m)
+		}
+	}
+
+	return page(matches, params.Page, params.Size), nil
+}
+
+// GetClasses returns the page of fixture classes at the requested clubs
+func (s *FileSource) GetClasses(ctx context.Context, params *models.SearchClassesParams) ([]models.Class, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var classes []models.Class
+	for _, c := range s.classes {
+		if matchesTenant(params.TenantIDs, c.Tenant.TenantID) && startsFrom(params.FromStartDate, c.StartDate) {
+			classes = append(classes, c)
+		}
+	}
+
+	return page(classes, params.Page, params.Size), nil
+}
+
+// GetLessons returns the page of fixture lessons at the requested club
+func (s *FileSource) GetLessons(ctx context.Context, params *models.SearchLessonsParams) ([]models.Lesson, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var tenantIDs []string
+	if params.TenantID != "" {
+		tenantIDs = []string{params.TenantID}
+	}
+
+	var lessons []models.Lesson
+	for _, l := range s.lessons {
+		if matchesTenant(tenantIDs, l.Tenant.TenantID) && startsFrom(params.FromStartDate, l.StartDate) {
+			lessons = append(lessons, l)
+		}
+	}
+
+	return page(lessons, params.Page, params.Size), nil
+}
+
+// loadFixture decodes a JSON fixture file into v, ignoring missing files
+func loadFixture(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read fixture %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decode fixture %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// matchesTenant checks if tenantID is one of the requested tenants; no tenants matches all
+func matchesTenant(tenantIDs []string, tenantID string) bool {
+	return len(tenantIDs) == 0 || slices.Contains(tenantIDs, tenantID)
+}
+
+// startsFrom checks if a Playtomic start date is not before fromStartDate.
+// Both use Playtomic's fixed-width date format, so they compare as strings.
+func startsFrom(fromStartDate, startDate string) bool {
+	return fromStartDate == "" || startDate >= fromStartDate
+}
+
+// page returns the zero-based page of items, or all items when size is not set
+func page[T any](items []T, page, size int) []T {
+	if size <= 0 {
+		return items
+	}
+
+	start := page * size
+	if start >= len(items) {
+		return nil
+	}
+
+	end := min(start+size, len(items))
+	return items[start:end]
+}
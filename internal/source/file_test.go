@@ -0,0 +1,89 @@
+package source
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rafa-garcia/go-playtomic-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSource_GetMatches(t *testing.T) {
+	src, err := NewFileSource("testdata")
+	require.NoError(t, err)
+
+	matches, err := src.GetMatches(context.Background(), &models.SearchMatchesParams{
+		TenantIDs: []string{"club-1"},
+		Size:      10,
+	})
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.Equal(t, "match-1", matches[0].MatchID)
+
+	// From date filter
+	matches, err = src.GetMatches(context.Background(), &models.SearchMatchesParams{
+		FromStartDate: "2030-06-02T00:00:00",
+	})
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.Equal(t, "match-2", matches[0].MatchID)
+
+	// Pages past the end are empty
+	matches, err = src.GetMatches(context.Background(), &models.SearchMatchesParams{Size: 2, Page: 1})
+	require.NoError(t, err)
+	assert.Len(t, matches, 1)
+
+	matches, err = src.GetMatches(context.Background(), &models.SearchMatchesParams{Size: 2, Page: 2})
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestFileSource_GetClassesAndLessons(t *testing.T) {
+	src, err := NewFileSource("testdata")
+	require.NoError(t, err)
+
+	classes, err := src.GetClasses(context.Background(), &models.SearchClassesParams{TenantIDs: []string{"club-1"}})
+	require.NoError(t, err)
+	require.Len(t, classes, 1)
+	assert.Equal(t, "class-1", classes[0].AcademyClassID)
+
+	lessons, err := src.GetLessons(context.Background(), &models.SearchLessonsParams{TenantID: "club-1"})
+	require.NoError(t, err)
+	require.Len(t, lessons, 1)
+	assert.Equal(t, "lesson-1", lessons[0].TournamentID)
+
+	lessons, err = src.GetLessons(context.Background(), &models.SearchLessonsParams{TenantID: "club-2"})
+	require.NoError(t, err)
+	assert.Empty(t, lessons)
+}
+
+func TestFileSource_MissingAndInvalidFixtures(t *testing.T) {
+	dir := t.TempDir()
+
+	src, err := NewFileSource(dir)
+	require.NoError(t, err)
+
+	matches, err := src.GetMatches(context.Background(), &models.SearchMatchesParams{})
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "classes.json"), []byte("not json"), 0o600))
+
+	_, err = NewFileSource(dir)
+	assert.Error(t, err)
+}
+
+func TestFetchAll_FileSource(t *testing.T) {
+	src, err := NewFileSource("testdata")
+	require.NoError(t, err)
+
+	matches, truncated, err := FetchAll(context.Background(), "matches", Pagination{PageSize: 1, MaxPages: 10}, func(ctx context.Context, page, size int) ([]models.Match, error) {
+		return src.GetMatches(ctx, &models.SearchMatchesParams{Page: page, Size: size})
+	})
+	require.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Len(t, matches, 3)
+}
@@ -0,0 +1,116 @@
+package source
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rafa-garcia/go-playtomic-api/models"
+	"github.com/rafa-garcia/padel-alert/internal/logger"
+	"github.com/rafa-garcia/padel-alert/internal/metrics"
+)
+
+// Upstream endpoints, each guarded by its own circuit breaker
+const (
+	EndpointMatches = "matches"
+	EndpointClasses = "classes"
+	EndpointLessons = "lessons"
+)
+
+// GuardedSource protects an upstream activity source during outages. Each
+// endpoint has a circuit breaker, and every call first takes a token from a
+// rate limiter shared by all endpoints.
+type GuardedSource struct {
+	next     ActivitySource
+	limiter  *TokenBucket
+	breakers map[string]*CircuitBreaker
+}
+
+// NewGuardedSource wraps next with per-endpoint circuit breakers and an
+// optional shared rate limiter
+func NewGuardedSource(next ActivitySource, limiter *TokenBucket, settings BreakerSettings) *GuardedSource {
+	breakers := make(map[string]*CircuitBreaker)
+	for _, endpoint := range []string{EndpointMatches, EndpointClasses, EndpointLessons} {
+		endpoint := endpoint
+		breakers[endpoint] = NewCircuitBreaker(settings, func(state int) {
+			metrics.PlaytomicCircuitState.WithLabelValues(endpoint).Set(float64(state))
+			logger.Warn("Playtomic circuit breaker changed state", "endpoint", endpoint, "state", circuitStateName(state))
+		})
+		metrics.PlaytomicCircuitState.WithLabelValues(endpoint).Set(CircuitClosed)
+	}
+
+	return &GuardedSource{
+		next:     next,
+		limiter:  limiter,
+		breakers: breakers,
+	}
+}
+
+// GetMatches fetches matches through the matches circuit breaker
+func (s *GuardedSource) GetMatches(ctx context.Context, params *models.SearchMatchesParams) ([]models.Match, error) {
+	return guard(ctx, s, EndpointMatches, func(ctx context.Context) ([]models.Match, error) {
+		return s.next.GetMatches(ctx, params)
+	})
+}
+
+// GetClasses fetches classes through the classes circuit breaker
+func (s *GuardedSource) GetClasses(ctx context.Context, params *models.SearchClassesParams) ([]models.Class, error) {
+	return guard(ctx, s, EndpointClasses, func(ctx context.Context) ([]models.Class, error) {
+		return s.next.GetClasses(ctx, params)
+	})
+}
+
+// GetLessons fetches lessons through the lessons circuit breaker
+func (s *GuardedSource) GetLessons(ctx context.Context, params *models.SearchLessonsParams) ([]models.Lesson, error) {
+	return guard(ctx, s, EndpointLessons, func(ctx context.Context) ([]models.Lesson, error) {
+		return s.next.GetLessons(ctx, params)
+	})
+}
+
+// Breaker returns the circuit breaker guarding an endpoint
+func (s *GuardedSource) Breaker(endpoint string) *CircuitBreaker {
+	return s.breakers[endpoint]
+}
+
+// guard runs call once the endpoint's circuit and the rate limiter allow it,
+// and reports the outcome to the circuit breaker
+func guard[T any](ctx context.Context, s *GuardedSource, endpoint string, call func(ctx context.Context) ([]T, error)) ([]T, error) {
+	breaker := s.breakers[endpoint]
+	if !breaker.Allow() {
+		metrics.PlaytomicCircuitRejections.WithLabelValues(endpoint).Inc()
+		return nil, fmt.Errorf("%s: %w", endpoint, ErrCircuitOpen)
+	}
+
+	if s.limiter != nil {
+		if err := s.limiter.Wait(ctx); err != nil {
+			// Not an upstream failure, so let another call try the circuit
+			breaker.Release()
+			return nil, fmt.Errorf("wait for rate limit: %w", err)
+		}
+		metrics.PlaytomicRateLimitTokens.Set(s.limiter.Tokens())
+	}
+
+	result, err := call(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			breaker.Release()
+			return nil, err
+		}
+		breaker.Failure()
+		return nil, err
+	}
+
+	breaker.Success()
+	return result, nil
+}
+
+// circuitStateName returns a readable name for a circuit state
+func circuitStateName(state int) string {
+	switch state {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
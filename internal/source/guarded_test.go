@@ -0,0 +1,76 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rafa-garcia/go-playtomic-api/models"
+	"github.com/rafa-garcia/padel-alert/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingSource fails match searches and counts the calls that reach it
+type failingSource struct {
+	matchCalls int
+	classCalls int
+}
+
+func (s *failingSource) GetMatches(ctx context.Context, params *models.SearchMatchesParams) ([]models.Match, error) {
+	s.matchCalls++
+	return nil, errors.New("upstream unavailable")
+}
+
+func (s *failingSource) GetClasses(ctx context.Context, params *models.SearchClassesParams) ([]models.Class, error) {
+	s.classCalls++
+	return []models.Class{{AcademyClassID: "class-1"}}, nil
+}
+
+func (s *failingSource) GetLessons(ctx context.Context, params *models.SearchLessonsParams) ([]models.Lesson, error) {
+	return nil, nil
+}
+
+func TestGuardedSource_OpensCircuitPerEndpoint(t *testing.T) {
+	upstream := &failingSource{}
+	guarded := NewGuardedSource(upstream, nil, BreakerSettings{FailureThreshold: 2, Cooldown: time.Minute})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := guarded.GetMatches(ctx, &models.SearchMatchesParams{})
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrCircuitOpen)
+	}
+
+	// The circuit is open, so matches fail fast without reaching upstream
+	_, err := guarded.GetMatches(ctx, &models.SearchMatchesParams{})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 2, upstream.matchCalls)
+	assert.Equal(t, float64(CircuitOpen), testutil.ToFloat64(metrics.PlaytomicCircuitState.WithLabelValues(EndpointMatches)))
+
+	// Other endpoints are unaffected
+	classes, err := guarded.GetClasses(ctx, &models.SearchClassesParams{})
+	require.NoError(t, err)
+	assert.Len(t, classes, 1)
+	assert.Equal(t, CircuitClosed, guarded.Breaker(EndpointClasses).State())
+}
+
+func TestGuardedSource_RateLimit(t *testing.T) {
+	upstream := &failingSource{}
+	limiter := NewTokenBucket(0.001, 1)
+	guarded := NewGuardedSource(upstream, limiter, BreakerSettings{})
+
+	_, err := guarded.GetClasses(context.Background(), &models.SearchClassesParams{})
+	require.NoError(t, err)
+
+	// The next call waits for a token, and a cancelled wait is not an upstream failure
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = guarded.GetClasses(ctx, &models.SearchClassesParams{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, upstream.classCalls)
+	assert.Equal(t, CircuitClosed, guarded.Breaker(EndpointClasses).State())
+}
@@ -0,0 +1,56 @@
+package source
+
+import (
+	"context"
+	"time"
+
+	"github.com/rafa-garcia/go-playtomic-api/models"
+	"github.com/rafa-garcia/padel-alert/internal/metrics"
+)
+
+// InstrumentedSource records request counts and durations for every upstream
+// call made through it
+type InstrumentedSource struct {
+	next ActivitySource
+}
+
+// NewInstrumentedSource wraps next with Playtomic API metrics
+func NewInstrumentedSource(next ActivitySource) *InstrumentedSource {
+	return &InstrumentedSource{next: next}
+}
+
+// GetMatches fetches matches and records the call
+func (s *InstrumentedSource) GetMatches(ctx context.Context, params *models.SearchMatchesParams) ([]models.Match, error) {
+	return instrument(EndpointMatches, func() ([]models.Match, error) {
+		return s.next.GetMatches(ctx, params)
+	})
+}
+
+// GetClasses fetches classes and records the call
+func (s *InstrumentedSource) GetClasses(ctx context.Context, params *models.SearchClassesParams) ([]models.Class, error) {
+	return instrument(EndpointClasses, func() ([]models.Class, error) {
+		return s.next.GetClasses(ctx, params)
+	})
+}
+
+// GetLessons fetches lessons and records the call
+func (s *InstrumentedSource) GetLessons(ctx context.Context, params *models.SearchLessonsParams) ([]models.Lesson, error) {
+	return instrument(EndpointLessons, func() ([]models.Lesson, error) {
+		return s.next.GetLessons(ctx, params)
+	})
+}
+
+// instrument times call and counts it by endpoint and status
+func instrument[T any](endpoint string, call func() ([]T, error)) ([]T, error) {
+	start := time.Now()
+	result, err := call()
+	metrics.PlaytomicApiDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	metrics.PlaytomicApiRequests.WithLabelValues(endpoint, status).Inc()
+
+	return result, err
+}
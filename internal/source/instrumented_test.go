@@ -0,0 +1,44 @@
+package source
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rafa-garcia/go-playtomic-api/models"
+	"github.com/rafa-garcia/padel-alert/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrumentedSource_RecordsRequests(t *testing.T) {
+	metrics.PlaytomicApiRequests.Reset()
+	metrics.PlaytomicApiDuration.Reset()
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(metrics.PlaytomicApiRequests, metrics.PlaytomicApiDuration)
+
+	instrumented := NewInstrumentedSource(&failingSource{})
+	ctx := context.Background()
+
+	_, err := instrumented.GetMatches(ctx, &models.SearchMatchesParams{})
+	assert.Error(t, err)
+	_, err = instrumented.GetClasses(ctx, &models.SearchClassesParams{})
+	require.NoError(t, err)
+	_, err = instrumented.GetClasses(ctx, &models.SearchClassesParams{})
+	require.NoError(t, err)
+
+	expected := `
+# HELP padel_alert_playtomic_api_requests_total The total number of requests to Playtomic API
+# TYPE padel_alert_playtomic_api_requests_total counter
+padel_alert_playtomic_api_requests_total{endpoint="classes",status="success"} 2
+padel_alert_playtomic_api_requests_total{endpoint="matches",status="error"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "padel_alert_playtomic_api_requests_total"))
+
+	count, err := testutil.GatherAndCount(registry, "padel_alert_playtomic_api_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "Durations should be observed per endpoint")
+}
@@ -0,0 +1,70 @@
+// Package source provides access to upstream activity data from Playtomic.
+package source
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rafa-garcia/padel-alert/internal/logger"
+)
+
+// Pagination controls how many result pages are requested from Playtomic
+type Pagination struct {
+	PageSize int // Results requested per page
+	MaxPages int // Maximum pages fetched per search
+}
+
+// DefaultPagination is used when no pagination settings are configured
+var DefaultPagination = Pagination{
+	PageSize: 100,
+	MaxPages: 10,
+}
+
+// PageFunc fetches a single zero-based page of results
+type PageFunc[T any] func(ctx context.Context, page, size int) ([]T, error)
+
+// FetchAll requests pages until a short page comes back or the page cap is
+// reached. When the cap is hit the partial results are returned, truncated is
+// true and a warning is logged. If a later page fails, the pages fetched so
+// far are returned together with the error.
+func FetchAll[T any](ctx context.Context, endpoint string, pagination Pagination, fetch PageFunc[T]) ([]T, bool, error) {
+	pagination = pagination.withDefaults()
+
+	var results []T
+	for page := 0; page < pagination.MaxPages; page++ {
+		if err := ctx.Err(); err != nil {
+			return results, false, err
+		}
+
+		items, err := fetch(ctx, page, pagination.PageSize)
+		if err != nil {
+			return results, false, fmt.Errorf("fetch %s page %d: %w", endpoint, page, err)
+		}
+
+		results = append(results, items...)
+
+		if len(items) < pagination.PageSize {
+			return results, false, nil
+		}
+	}
+
+	logger.Warn("Page cap reached, returning partial results",
+		"endpoint", endpoint,
+		"max_pages", pagination.MaxPages,
+		"page_size", pagination.PageSize,
+		"results", len(results),
+	)
+
+	return results, true, nil
+}
+
+// withDefaults fills unset fields from DefaultPagination
+func (p Pagination) withDefaults() Pagination {
+	if p.PageSize <= 0 {
+		p.PageSize = DefaultPagination.PageSize
+	}
+	if p.MaxPages <= 0 {
+		p.MaxPages = DefaultPagination.MaxPages
+	}
+	return p
+}
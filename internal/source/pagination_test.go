@@ -0,0 +1,84 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedFetcher serves total items in pages and records requested pages
+type pagedFetcher struct {
+	total     int
+	failPage  int
+	requested []int
+}
+
+func (f *pagedFetcher) fetch(ctx context.Context, page, size int) ([]int, error) {
+	f.requested = append(f.requested, page)
+	if f.failPage > 0 && page == f.failPage {
+		return nil, errors.New("upstream error")
+	}
+
+	var items []int
+	for i := page * size; i < (page+1)*size && i < f.total; i++ {
+		items = append(items, i)
+	}
+	return items, nil
+}
+
+func TestFetchAll_StopsOnShortPage(t *testing.T) {
+	fetcher := &pagedFetcher{total: 25}
+
+	items, truncated, err := FetchAll(context.Background(), "matches", Pagination{PageSize: 10, MaxPages: 5}, fetcher.fetch)
+
+	require.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Len(t, items, 25)
+	assert.Equal(t, []int{0, 1, 2}, fetcher.requested)
+}
+
+func TestFetchAll_ExactMultipleRequestsEmptyPage(t *testing.T) {
+	fetcher := &pagedFetcher{total: 20}
+
+	items, truncated, err := FetchAll(context.Background(), "matches", Pagination{PageSize: 10, MaxPages: 5}, fetcher.fetch)
+
+	require.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Len(t, items, 20)
+	assert.Equal(t, []int{0, 1, 2}, fetcher.requested)
+}
+
+func TestFetchAll_PageCap(t *testing.T) {
+	fetcher := &pagedFetcher{total: 100}
+
+	items, truncated, err := FetchAll(context.Background(), "classes", Pagination{PageSize: 10, MaxPages: 3}, fetcher.fetch)
+
+	require.NoError(t, err)
+	assert.True(t, truncated)
+	assert.Len(t, items, 30)
+	assert.Equal(t, []int{0, 1, 2}, fetcher.requested)
+}
+
+func TestFetchAll_ErrorReturnsPartialResults(t *testing.T) {
+	fetcher := &pagedFetcher{total: 100, failPage: 2}
+
+	items, _, err := FetchAll(context.Background(), "lessons", Pagination{PageSize: 10, MaxPages: 5}, fetcher.fetch)
+
+	assert.Error(t, err)
+	assert.Len(t, items, 20)
+}
+
+func TestFetchAll_ContextCancelled(t *testing.T) {
+	fetcher := &pagedFetcher{total: 100}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	items, _, err := FetchAll(ctx, "matches", Pagination{PageSize: 10, MaxPages: 5}, fetcher.fetch)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, items)
+	assert.Empty(t, fetcher.requested)
+}
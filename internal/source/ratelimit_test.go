@@ -0,0 +1,54 @@
+package source
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket_Burst(t *testing.T) {
+	bucket := NewTokenBucket(1, 3)
+	now := time.Now()
+	bucket.now = func() time.Time { return now }
+	bucket.last = now
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, time.Duration(0), bucket.reserve())
+	}
+
+	// The bucket is empty until a token refills
+	assert.Equal(t, time.Second, bucket.reserve())
+
+	now = now.Add(500 * time.Millisecond)
+	assert.Equal(t, 500*time.Millisecond, bucket.reserve())
+
+	now = now.Add(500 * time.Millisecond)
+	assert.Equal(t, time.Duration(0), bucket.reserve())
+
+	// Refills never exceed the burst
+	now = now.Add(time.Hour)
+	assert.Equal(t, float64(3), bucket.Tokens())
+}
+
+func TestTokenBucket_Wait(t *testing.T) {
+	bucket := NewTokenBucket(100, 1)
+
+	require.NoError(t, bucket.Wait(context.Background()))
+
+	start := time.Now()
+	require.NoError(t, bucket.Wait(context.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), 5*time.Millisecond)
+}
+
+func TestTokenBucket_WaitCancelled(t *testing.T) {
+	bucket := NewTokenBucket(0.001, 1)
+	require.NoError(t, bucket.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, bucket.Wait(ctx), context.DeadlineExceeded)
+}
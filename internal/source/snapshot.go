@@ -0,0 +1,114 @@
+package source
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/metrics"
+)
+
+// DefaultSnapshotTTL is used when no snapshot TTL is configured
+const DefaultSnapshotTTL = 60 * time.Second
+
+// SnapshotKey identifies the activities of one type at one club from a start date
+type SnapshotKey struct {
+	Type     string
+	ClubID   string
+	FromDate string
+}
+
+// LoadFunc fetches the normalized activities for a snapshot key
+type LoadFunc func(ctx context.Context) ([]model.Activity, error)
+
+// snapshot is a cached set of activities
+type snapshot struct {
+	activities []model.Activity
+	expiresAt  time.Time
+}
+
+// snapshotCall is a load in progress that concurrent callers wait on
+type snapshotCall struct {
+	done       chan struct{}
+	activities []model.Activity
+	err        error
+}
+
+// SnapshotCache keeps short-lived activity snapshots per club so that rules
+// watching the same club share a single upstream fetch
+type SnapshotCache struct {
+	ttl      time.Duration
+	now      func() time.Time
+	mu       sync.Mutex
+	entries  map[SnapshotKey]snapshot
+	inflight map[SnapshotKey]*snapshotCall
+}
+
+// NewSnapshotCache creates a new snapshot cache
+func NewSnapshotCache(ttl time.Duration) *SnapshotCache {
+	if ttl <= 0 {
+		ttl = DefaultSnapshotTTL
+	}
+
+	return &SnapshotCache{
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[SnapshotKey]snapshot),
+		inflight: make(map[SnapshotKey]*snapshotCall),
+	}
+}
+
+// Get returns the cached activities for key, calling load when the snapshot
+// is missing or expired. Concurrent callers for the same key share one load.
+// The returned slice is shared and must not be modified.
+func (c *SnapshotCache) Get(ctx context.Context, key SnapshotKey, load LoadFunc) ([]model.Activity, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && c.now().Before(entry.expiresAt) {
+		c.mu.Unlock()
+		metrics.SnapshotCacheRequests.WithLabelValues(key.Type, "hit").Inc()
+		return entry.activities, nil
+	}
+
+	if call, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		metrics.SnapshotCacheRequests.WithLabelValues(key.Type, "shared").Inc()
+		select {
+		case <-call.done:
+			return call.activities, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	call := &snapshotCall{done: make(chan struct{})}
+	c.inflight[key] = call
+	c.mu.Unlock()
+
+	metrics.SnapshotCacheRequests.WithLabelValues(key.Type, "miss").Inc()
+	call.activities, call.err = load(ctx)
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if call.err == nil {
+		c.evictExpired()
+		c.entries[key] = snapshot{
+			activities: call.activities,
+			expiresAt:  c.now().Add(c.ttl),
+		}
+	}
+	c.mu.Unlock()
+	close(call.done)
+
+	return call.activities, call.err
+}
+
+// evictExpired removes expired snapshots; callers must hold c.mu
+func (c *SnapshotCache) evictExpired() {
+	now := c.now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
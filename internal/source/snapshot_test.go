@@ -0,0 +1,98 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotCache_ReusesSnapshotWithinTTL(t *testing.T) {
+	cache := NewSnapshotCache(time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	var loads int32
+	load := func(ctx context.Context) ([]model.Activity, error) {
+		atomic.AddInt32(&loads, 1)
+		return []model.Activity{{ID: "match-1"}}, nil
+	}
+
+	key := SnapshotKey{Type: "match", ClubID: "club-1", FromDate: "2025-05-01"}
+
+	first, err := cache.Get(context.Background(), key, load)
+	require.NoError(t, err)
+	second, err := cache.Get(context.Background(), key, load)
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+
+	// A different club is fetched separately
+	_, err = cache.Get(context.Background(), SnapshotKey{Type: "match", ClubID: "club-2", FromDate: "2025-05-01"}, load)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&loads))
+
+	// Expired snapshots are reloaded
+	now = now.Add(2 * time.Minute)
+	_, err = cache.Get(context.Background(), key, load)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&loads))
+}
+
+func TestSnapshotCache_ErrorsAreNotCached(t *testing.T) {
+	cache := NewSnapshotCache(time.Minute)
+	key := SnapshotKey{Type: "class", ClubID: "club-1", FromDate: "2025-05-01"}
+
+	_, err := cache.Get(context.Background(), key, func(ctx context.Context) ([]model.Activity, error) {
+		return nil, errors.New("upstream error")
+	})
+	assert.Error(t, err)
+
+	activities, err := cache.Get(context.Background(), key, func(ctx context.Context) ([]model.Activity, error) {
+		return []model.Activity{{ID: "class-1"}}, nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, activities, 1)
+}
+
+func TestSnapshotCache_ConcurrentCallersShareLoad(t *testing.T) {
+	cache := NewSnapshotCache(time.Minute)
+	key := SnapshotKey{Type: "lesson", ClubID: "club-1", FromDate: "2025-05-01"}
+
+	var loads int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) ([]model.Activity, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return []model.Activity{{ID: "lesson-1"}}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([][]model.Activity, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			activities, err := cache.Get(context.Background(), key, load)
+			assert.NoError(t, err)
+			results[i] = activities
+		}(i)
+	}
+
+	// Give the callers time to queue up behind the first load
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+	for _, activities := range results {
+		assert.Len(t, activities, 1)
+	}
+}
@@ -0,0 +1,50 @@
+package source
+
+import (
+	"context"
+	"time"
+
+	playtomic "github.com/rafa-garcia/go-playtomic-api/client"
+	"github.com/rafa-garcia/go-playtomic-api/models"
+	"github.com/rafa-garcia/padel-alert/internal/config"
+	"github.com/rafa-garcia/padel-alert/internal/logger"
+)
+
+// ActivitySource provides matches, classes and lessons in Playtomic's API shapes
+type ActivitySource interface {
+	GetMatches(ctx context.Context, params *models.SearchMatchesParams) ([]models.Match, error)
+	GetClasses(ctx context.Context, params *models.SearchClassesParams) ([]models.Class, error)
+	GetLessons(ctx context.Context, params *models.SearchLessonsParams) ([]models.Lesson, error)
+}
+
+// The Playtomic API client is the production activity source
+var _ ActivitySource = (*playtomic.Client)(nil)
+
+// New creates the activity source selected by the configuration: fixtures from
+// PLAYTOMIC_FIXTURES_DIR when set, otherwise the Playtomic API guarded by
+// circuit breakers and a rate limit
+func New(cfg *config.Config) (ActivitySource, error) {
+	if cfg.PlaytomicFixturesDir != "" {
+		logger.Info("Serving Playtomic data from fixtures", "dir", cfg.PlaytomicFixturesDir)
+		return NewFileSource(cfg.PlaytomicFixturesDir)
+	}
+
+	// The client doesn't retry, so each rate limit token is one HTTP request.
+	// Failed checks are retried with the rule's backoff instead.
+	client := playtomic.NewClient(
+		playtomic.WithTimeout(60*time.Second),
+		playtomic.WithRetries(0),
+	)
+
+	var limiter *TokenBucket
+	if cfg.PlaytomicRateLimit > 0 {
+		limiter = NewTokenBucket(cfg.PlaytomicRateLimit, cfg.PlaytomicRateBurst)
+	}
+
+	// Rejected and rate-limited calls never reach the instrumented client, so
+	// the request metrics only count calls actually sent to Playtomic
+	return NewGuardedSource(NewInstrumentedSource(client), limiter, BreakerSettings{
+		FailureThreshold: cfg.CircuitFailureThreshold,
+		Cooldown:         time.Duration(cfg.CircuitCooldown) * time.Second,
+	}), nil
+}
@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/redis/go-redis/v9"
+)
+
+// digestScheduleKey holds each user with pending digest entries, scored by
+// the earliest time one of them is due
+const digestScheduleKey = "digests:schedule"
+
+// DigestStorage accumulates matched activities per user until their digest is due
+type DigestStorage interface {
+	AddDigestEntries(ctx context.Context, userID string, entries []model.DigestEntry) error
+	DueDigestUsers(ctx context.Context, now time.Time, limit int) ([]string, error)
+	TakeDueDigest(ctx context.Context, userID string, now time.Time) ([]model.DigestEntry, error)
+	GetDigestEntries(ctx context.Context, userID string) ([]model.DigestEntry, error)
+	RemoveDigestEntries(ctx context.Context, userID string, entries []model.DigestEntry) error
+	DeleteDigest(ctx context.Context, userID string) error
+}
+
+// takeDigestScript removes and returns the user's entries due by ARGV[1],
+// then moves the user in the digest schedule to the next entry still pending
+//
+// KEYS[1] entries, KEYS[2] due times, KEYS[3] schedule, ARGV[1] now, ARGV[2] user ID
+var takeDigestScript = redis.NewScript(`
+local fields = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1])
+local items = {}
+for i = 1, #fields, 500 do
+	local batch = {unpack(fields, i, math.min(i + 499, #fields))}
+	local values = redis.call('HMGET', KEYS[1], unpack(batch))
+	for _, value in ipairs(values) do
+		if value then
+			table.insert(items, value)
+		end
+	end
+	redis.call('HDEL', KEYS[1], unpack(batch))
+	redis.call('ZREM', KEYS[2], unpack(batch))
+end
+local upcoming = redis.call('ZRANGE', KEYS[2], 0, 0, 'WITHSCORES')
+if #upcoming == 0 then
+	redis.call('ZREM', KEYS[3], ARGV[2])
+else
+	redis.call('ZADD', KEYS[3], upcoming[2], ARGV[2])
+end
+return items
+`)
+
+// removeDigestScript removes entries from the user's digest, then moves the
+// user in the digest schedule to the next entry still pending
+//
+// KEYS[1] entries, KEYS[2] due times, KEYS[3] schedule, ARGV[1] user ID,
+// ARGV[2...] entry fields
+var removeDigestScript = redis.NewScript(`
+local fields = {unpack(ARGV, 2)}
+redis.call('HDEL', KEYS[1], unpack(fields))
+redis.call('ZREM', KEYS[2], unpack(fields))
+local upcoming = redis.call('ZRANGE', KEYS[2], 0, 0, 'WITHSCORES')
+if #upcoming == 0 then
+	redis.call('ZREM', KEYS[3], ARGV[1])
+else
+	redis.call('ZADD', KEYS[3], upcoming[2], ARGV[1])
+end
+return 1
+`)
+
+// RedisDigestStorage implements DigestStorage with a hash of entries per
+// user, a sorted set of their due times and a schedule of users
+type RedisDigestStorage struct {
+	redis *RedisClient
+}
+
+// NewRedisDigestStorage creates a new Redis digest storage
+func NewRedisDigestStorage(redis *RedisClient) *RedisDigestStorage {
+	return &RedisDigestStorage{
+		redis: redis,
+	}
+}
+
+// digestKey returns the digest entries key for a user
+func digestKey(userID string) string {
+	return fmt.Sprintf("digest:%s", userID)
+}
+
+// digestDueKey returns the digest due times key for a user
+func digestDueKey(userID string) string {
+	return fmt.Sprintf("digestdue:%s", userID)
+}
+
+// digestField identifies an entry in a user's digest, so an activity matched
+// again by the same rule replaces its earlier entry
+func digestField(entry model.DigestEntry) string {
+	return entry.RuleID + ":" + entry.Activity.ID
+}
+
+// AddDigestEntries stores entries in the user's digest and moves the user's
+// digest earlier in the schedule if one of them is due sooner. An entry added
+// again takes the latest details but keeps the earlier of its due times, so
+// activities found on every check until they are sent are not held back.
+func (s *RedisDigestStorage) AddDigestEntries(ctx context.Context, userID string, entries []model.DigestEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	values := make([]interface{}, 0, len(entries)*2)
+	due := make([]redis.Z, 0, len(entries))
+	earliest := entries[0].DueAt
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("marshal digest entry: %w", err)
+		}
+
+		field := digestField(entry)
+		values = append(values, field, data)
+		due = append(due, redis.Z{Score: float64(entry.DueAt.Unix()), Member: field})
+		if entry.DueAt.Before(earliest) {
+			earliest = entry.DueAt
+		}
+	}
+
+	_, err := s.redis.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, digestKey(userID), values...)
+		pipe.ZAddLT(ctx, digestDueKey(userID), due...)
+		pipe.ZAddLT(ctx, digestScheduleKey, redis.Z{Score: float64(earliest.Unix()), Member: userID})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("add digest entries: %w", err)
+	}
+
+	return nil
+}
+
+// DueDigestUsers lists users with digest entries due by now
+func (s *RedisDigestStorage) DueDigestUsers(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	users, err := s.redis.Client.ZRangeByScore(ctx, digestScheduleKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.Unix(), 10),
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get due digests: %w", err)
+	}
+
+	return users, nil
+}
+
+// TakeDueDigest removes and returns the user's entries due by now. Entries
+// are taken atomically, so concurrent callers never receive the same entry.
+func (s *RedisDigestStorage) TakeDueDigest(ctx context.Context, userID string, now time.Time) ([]model.DigestEntry, error) {
+	keys := []string{digestKey(userID), digestDueKey(userID), digestScheduleKey}
+	items, err := takeDigestScript.Run(ctx, s.redis.Client, keys, now.Unix(), userID).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("take digest: %w", err)
+	}
+
+	entries := make([]model.DigestEntry, 0, len(items))
+	for _, item := range items {
+		var entry model.DigestEntry
+		if err := json.Unmarshal([]byte(item), &entry); err != nil {
+			continue // Skip entries that can't be decoded
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// GetDigestEntries returns every entry in the user's digest, whenever it is due
+func (s *RedisDigestStorage) GetDigestEntries(ctx context.Context, userID string) ([]model.DigestEntry, error) {
+	items, err := s.redis.Client.HVals(ctx, digestKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get digest entries: %w", err)
+	}
+
+	entries := make([]model.DigestEntry, 0, len(items))
+	for _, item := range items {
+		var entry model.DigestEntry
+		if err := json.Unmarshal([]byte(item), &entry); err != nil {
+			continue // Skip entries that can't be decoded
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// RemoveDigestEntries removes entries from the user's digest, and the user
+// from the digest schedule once nothing is left to send
+func (s *RedisDigestStorage) RemoveDigestEntries(ctx context.Context, userID string, entries []model.DigestEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(entries)+1)
+	args = append(args, userID)
+	for _, entry := range entries {
+		args = append(args, digestField(entry))
+	}
+
+	keys := []string{digestKey(userID), digestDueKey(userID), digestScheduleKey}
+	if err := removeDigestScript.Run(ctx, s.redis.Client, keys, args...).Err(); err != nil {
+		return fmt.Errorf("remove digest entries: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteDigest removes a user's pending digest
+func (s *RedisDigestStorage) DeleteDigest(ctx context.Context, userID string) error {
+	pipe := s.redis.Client.Pipeline()
+	pipe.Del(ctx, digestKey(userID), digestDueKey(userID))
+	pipe.ZRem(ctx, digestScheduleKey, userID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("delete digest: %w", err)
+	}
+	return nil
+}
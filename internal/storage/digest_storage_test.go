@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisDigestStorage_AddAndTake(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	digestStorage := NewRedisDigestStorage(redisClient)
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	require.NoError(t, digestStorage.AddDigestEntries(ctx, "user-1", []model.DigestEntry{
+		{RuleID: "rule-1", Activity: model.Activity{ID: "activity-1"}, DueAt: now.Add(time.Hour)},
+		{RuleID: "rule-2", Activity: model.Activity{ID: "activity-2"}, DueAt: now.Add(24 * time.Hour)},
+	}))
+
+	// Nothing is due yet
+	users, err := digestStorage.DueDigestUsers(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+
+	users, err = digestStorage.DueDigestUsers(ctx, now.Add(time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-1"}, users)
+
+	entries, err := digestStorage.TakeDueDigest(ctx, "user-1", now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "activity-1", entries[0].Activity.ID)
+
+	// The user moves on to the next pending entry
+	score, err := mini.ZScore("digests:schedule", "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, float64(now.Add(24*time.Hour).Unix()), score)
+
+	// Taking again returns nothing
+	entries, err = digestStorage.TakeDueDigest(ctx, "user-1", now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	entries, err = digestStorage.TakeDueDigest(ctx, "user-1", now.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "activity-2", entries[0].Activity.ID)
+
+	assert.False(t, mini.Exists("digest:user-1"))
+	assert.False(t, mini.Exists("digestdue:user-1"))
+	assert.False(t, mini.Exists("digests:schedule"))
+}
+
+func TestRedisDigestStorage_ReplacesEntries(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	digestStorage := NewRedisDigestStorage(redisClient)
+	ctx := context.Background()
+	due := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	require.NoError(t, digestStorage.AddDigestEntries(ctx, "user-1", []model.DigestEntry{
+		{RuleID: "rule-1", Activity: model.Activity{ID: "activity-1", AvailablePlaces: 1}, DueAt: due},
+	}))
+	// Matched again before the digest goes out, the entry keeps its due time
+	require.NoError(t, digestStorage.AddDigestEntries(ctx, "user-1", []model.DigestEntry{
+		{RuleID: "rule-1", Activity: model.Activity{ID: "activity-1", AvailablePlaces: 3}, DueAt: due.Add(24 * time.Hour)},
+	}))
+
+	entries, err := digestStorage.TakeDueDigest(ctx, "user-1", due)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 3, entries[0].Activity.AvailablePlaces)
+}
+
+func TestRedisDigestStorage_EarlierEntryMovesScheduleForward(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	digestStorage := NewRedisDigestStorage(redisClient)
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	require.NoError(t, digestStorage.AddDigestEntries(ctx, "user-1", []model.DigestEntry{
+		{RuleID: "rule-1", Activity: model.Activity{ID: "activity-1"}, DueAt: now.Add(24 * time.Hour)},
+	}))
+	require.NoError(t, digestStorage.AddDigestEntries(ctx, "user-1", []model.DigestEntry{
+		{RuleID: "rule-2", Activity: model.Activity{ID: "activity-2"}, DueAt: now.Add(time.Hour)},
+	}))
+	require.NoError(t, digestStorage.AddDigestEntries(ctx, "user-1", []model.DigestEntry{
+		{RuleID: "rule-3", Activity: model.Activity{ID: "activity-3"}, DueAt: now.Add(7 * 24 * time.Hour)},
+	}))
+
+	score, err := mini.ZScore("digests:schedule", "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, float64(now.Add(time.Hour).Unix()), score)
+
+	require.NoError(t, digestStorage.DeleteDigest(ctx, "user-1"))
+	assert.False(t, mini.Exists("digest:user-1"))
+	assert.False(t, mini.Exists("digests:schedule"))
+}
+
+func TestRedisDigestStorage_RemoveEntries(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	digestStorage := NewRedisDigestStorage(redisClient)
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	soon := model.DigestEntry{RuleID: "rule-1", Activity: model.Activity{ID: "activity-1"}, DueAt: now.Add(time.Hour)}
+	later := model.DigestEntry{RuleID: "rule-2", Activity: model.Activity{ID: "activity-2"}, DueAt: now.Add(24 * time.Hour)}
+	require.NoError(t, digestStorage.AddDigestEntries(ctx, "user-1", []model.DigestEntry{soon, later}))
+
+	entries, err := digestStorage.GetDigestEntries(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// The schedule moves on to the entry left
+	require.NoError(t, digestStorage.RemoveDigestEntries(ctx, "user-1", []model.DigestEntry{soon}))
+	score, err := mini.ZScore("digests:schedule", "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, float64(later.DueAt.Unix()), score)
+
+	entries, err = digestStorage.GetDigestEntries(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "activity-2", entries[0].Activity.ID)
+
+	// And the user leaves it once nothing is left
+	require.NoError(t, digestStorage.RemoveDigestEntries(ctx, "user-1", []model.DigestEntry{later}))
+	assert.False(t, mini.Exists("digest:user-1"))
+	assert.False(t, mini.Exists("digests:schedule"))
+}
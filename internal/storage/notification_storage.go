@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+)
+
+// NotificationStorage defines operations for notification history persistence
+type NotificationStorage interface {
+	AddNotification(ctx context.Context, notification *model.Notification) error
+	ListNotifications(ctx context.Context, ruleID string, offset, limit int) ([]*model.Notification, int64, error)
+	DeleteNotifications(ctx context.Context, ruleID string) error
+}
+
+// RedisNotificationStorage implements NotificationStorage using capped Redis lists
+type RedisNotificationStorage struct {
+	redis     *RedisClient
+	retention int
+}
+
+// NewRedisNotificationStorage creates a new Redis notification storage keeping
+// at most retention entries per rule
+func NewRedisNotificationStorage(redis *RedisClient, retention int) *RedisNotificationStorage {
+	return &RedisNotificationStorage{
+		redis:     redis,
+		retention: retention,
+	}
+}
+
+// notificationsKey returns the history list key for a rule
+func notificationsKey(ruleID string) string {
+	return fmt.Sprintf("notifications:%s", ruleID)
+}
+
+// AddNotification prepends a notification to the rule's history and trims it to the retention cap
+func (s *RedisNotificationStorage) AddNotification(ctx context.Context, notification *model.Notification) error {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("marshal notification: %w", err)
+	}
+
+	key := notificationsKey(notification.RuleID)
+
+	pipe := s.redis.Client.Pipeline()
+	pipe.LPush(ctx, key, data)
+	if s.retention > 0 {
+		pipe.LTrim(ctx, key, 0, int64(s.retention-1))
+	}
+	_, err = pipe.Exec(ctx)
+
+	if err != nil {
+		return fmt.Errorf("add notification: %w", err)
+	}
+
+	return nil
+}
+
+// ListNotifications lists a page of a rule's notifications, newest first, with the total count
+func (s *RedisNotificationStorage) ListNotifications(ctx context.Context, ruleID string, offset, limit int) ([]*model.Notification, int64, error) {
+	key := notificationsKey(ruleID)
+
+	pipe := s.redis.Client.Pipeline()
+	totalCmd := pipe.LLen(ctx, key)
+	rangeCmd := pipe.LRange(ctx, key, int64(offset), int64(offset+limit-1))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, 0, fmt.Errorf("list notifications: %w", err)
+	}
+
+	items := rangeCmd.Val()
+	notifications := make([]*model.Notification, 0, len(items))
+	for _, item := range items {
+		var notification model.Notification
+		if err := json.Unmarshal([]byte(item), &notification); err != nil {
+			continue // Skip entries that can't be decoded
+		}
+		notifications = append(notifications, &notification)
+	}
+
+	return notifications, totalCmd.Val(), nil
+}
+
+// DeleteNotifications removes a rule's notification history
+func (s *RedisNotificationStorage) DeleteNotifications(ctx context.Context, ruleID string) error {
+	if err := s.redis.Client.Del(ctx, notificationsKey(ruleID)).Err(); err != nil {
+		return fmt.Errorf("delete notifications: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisNotificationStorage_AddAndList(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	notificationStorage := NewRedisNotificationStorage(redisClient, 100)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		err := notificationStorage.AddNotification(ctx, &model.Notification{
+			ID:          fmt.Sprintf("notification-%d", i),
+			RuleID:      "rule-1",
+			Channel:     model.ChannelEmail,
+			ActivityIDs: []string{"activity-1"},
+			Subject:     "PadelAlert: 1 new activities available",
+			Status:      model.NotificationStatusSent,
+			CreatedAt:   time.Now(),
+		})
+		require.NoError(t, err)
+	}
+
+	notifications, total, err := notificationStorage.ListNotifications(ctx, "rule-1", 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, notifications, 2)
+	assert.Equal(t, "notification-2", notifications[0].ID, "Newest notification should come first")
+	assert.Equal(t, "notification-1", notifications[1].ID)
+
+	notifications, _, err = notificationStorage.ListNotifications(ctx, "rule-1", 2, 2)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "notification-0", notifications[0].ID)
+}
+
+func TestRedisNotificationStorage_RetentionCap(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	notificationStorage := NewRedisNotificationStorage(redisClient, 5)
+	ctx := context.Background()
+
+	for i := 0; i < 8; i++ {
+		err := notificationStorage.AddNotification(ctx, &model.Notification{
+			ID:     fmt.Sprintf("notification-%d", i),
+			RuleID: "rule-1",
+		})
+		require.NoError(t, err)
+	}
+
+	notifications, total, err := notificationStorage.ListNotifications(ctx, "rule-1", 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Equal(t, "notification-7", notifications[0].ID)
+	assert.Equal(t, "notification-3", notifications[4].ID)
+}
+
+func TestRedisNotificationStorage_DeletedWithRule(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	ruleStorage := NewRedisRuleStorage(redisClient)
+	notificationStorage := NewRedisNotificationStorage(redisClient, 10)
+	ctx := context.Background()
+
+	rule := &model.Rule{ID: "rule-1", UserID: "user-1", Type: "match", Name: "Test Rule"}
+	require.NoError(t, ruleStorage.CreateRule(ctx, rule))
+	require.NoError(t, notificationStorage.AddNotification(ctx, &model.Notification{ID: "notification-1", RuleID: "rule-1"}))
+
+	require.NoError(t, ruleStorage.DeleteRule(ctx, "rule-1"))
+
+	assert.False(t, mini.Exists("notifications:rule-1"))
+}
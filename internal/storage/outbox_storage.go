@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/redis/go-redis/v9"
+)
+
+// OutboxStorage holds each rule's activities until their notifications are
+// delivered, so that deliveries that fail are retried, including after a restart
+type OutboxStorage interface {
+	GetOutbox(ctx context.Context, ruleID string) ([]model.OutboxEntry, error)
+	SaveOutbox(ctx context.Context, ruleID string, entries []model.OutboxEntry) error
+	DeleteOutbox(ctx context.Context, ruleID string) error
+}
+
+// RedisOutboxStorage implements OutboxStorage with a hash per rule, keyed by activity ID
+type RedisOutboxStorage struct {
+	redis *RedisClient
+}
+
+// NewRedisOutboxStorage creates a new Redis outbox storage
+func NewRedisOutboxStorage(redis *RedisClient) *RedisOutboxStorage {
+	return &RedisOutboxStorage{
+		redis: redis,
+	}
+}
+
+// outboxKey returns the outbox key for a rule
+func outboxKey(ruleID string) string {
+	return fmt.Sprintf("outbox:%s", ruleID)
+}
+
+// GetOutbox returns the rule's outbox in the order the activities were queued
+func (s *RedisOutboxStorage) GetOutbox(ctx context.Context, ruleID string) ([]model.OutboxEntry, error) {
+	items, err := s.redis.Client.HVals(ctx, outboxKey(ruleID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get outbox: %w", err)
+	}
+
+	entries := make([]model.OutboxEntry, 0, len(items))
+	for _, item := range items {
+		var entry model.OutboxEntry
+		if err := json.Unmarshal([]byte(item), &entry); err != nil {
+			continue // Skip entries that can't be decoded
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].QueuedAt.Equal(entries[j].QueuedAt) {
+			return entries[i].QueuedAt.Before(entries[j].QueuedAt)
+		}
+		return entries[i].Activity.ID < entries[j].Activity.ID
+	})
+
+	return entries, nil
+}
+
+// SaveOutbox replaces the rule's outbox with entries, removing it when there are none
+func (s *RedisOutboxStorage) SaveOutbox(ctx context.Context, ruleID string, entries []model.OutboxEntry) error {
+	values := make([]interface{}, 0, len(entries)*2)
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("marshal outbox entry: %w", err)
+		}
+		values = append(values, entry.Activity.ID, data)
+	}
+
+	key := outboxKey(ruleID)
+	_, err := s.redis.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		if len(values) > 0 {
+			pipe.HSet(ctx, key, values...)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("save outbox: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteOutbox removes a rule's outbox
+func (s *RedisOutboxStorage) DeleteOutbox(ctx context.Context, ruleID string) error {
+	if err := s.redis.Client.Del(ctx, outboxKey(ruleID)).Err(); err != nil {
+		return fmt.Errorf("delete outbox: %w", err)
+	}
+	return nil
+}
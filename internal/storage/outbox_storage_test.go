@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisOutboxStorage_SaveAndGet(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	outboxStorage := NewRedisOutboxStorage(redisClient)
+	ctx := context.Background()
+
+	queuedAt := time.Now().UTC().Truncate(time.Second)
+	entries := []model.OutboxEntry{
+		{
+			Activity:      model.Activity{ID: "activity-2", AvailablePlaces: 1},
+			NextAttemptAt: queuedAt,
+			QueuedAt:      queuedAt.Add(time.Minute),
+		},
+		{
+			Activity:      model.Activity{ID: "activity-1", AvailablePlaces: 2},
+			Delivered:     []string{model.ChannelTelegram},
+			Attempts:      2,
+			NextAttemptAt: queuedAt.Add(4 * time.Minute),
+			LastError:     "email: smtp unavailable",
+			QueuedAt:      queuedAt,
+		},
+	}
+	require.NoError(t, outboxStorage.SaveOutbox(ctx, "rule-1", entries))
+
+	got, err := outboxStorage.GetOutbox(ctx, "rule-1")
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "activity-1", got[0].Activity.ID, "Entries should be ordered by when they were queued")
+	assert.Equal(t, 2, got[0].Attempts)
+	assert.Equal(t, []string{model.ChannelTelegram}, got[0].Delivered)
+	assert.Equal(t, "email: smtp unavailable", got[0].LastError)
+	assert.True(t, got[0].NextAttemptAt.Equal(queuedAt.Add(4*time.Minute)))
+	assert.Equal(t, "activity-2", got[1].Activity.ID)
+
+	// Saving replaces the outbox
+	require.NoError(t, outboxStorage.SaveOutbox(ctx, "rule-1", entries[:1]))
+	got, err = outboxStorage.GetOutbox(ctx, "rule-1")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "activity-2", got[0].Activity.ID)
+
+	// Saving nothing removes it
+	require.NoError(t, outboxStorage.SaveOutbox(ctx, "rule-1", nil))
+	assert.False(t, mini.Exists("outbox:rule-1"))
+	got, err = outboxStorage.GetOutbox(ctx, "rule-1")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestRedisOutboxStorage_DeleteOutbox(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	outboxStorage := NewRedisOutboxStorage(redisClient)
+	ctx := context.Background()
+
+	require.NoError(t, outboxStorage.SaveOutbox(ctx, "rule-1", []model.OutboxEntry{{Activity: model.Activity{ID: "activity-1"}}}))
+	require.NoError(t, outboxStorage.DeleteOutbox(ctx, "rule-1"))
+	assert.False(t, mini.Exists("outbox:rule-1"))
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/util"
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimitResult is the outcome of counting a request against a limit
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	Reset     time.Duration // Until the oldest counted request leaves the window
+}
+
+// RateLimitStorage counts requests against a limit over a sliding window
+type RateLimitStorage interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error)
+}
+
+// slidingWindowScript drops requests older than the window, then records the
+// request if fewer than the limit remain. It returns whether the request was
+// allowed, the requests in the window, and the milliseconds until the oldest
+// of them expires.
+//
+// KEYS[1] window, ARGV[1] now ms, ARGV[2] window ms, ARGV[3] limit, ARGV[4] request ID
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < tonumber(ARGV[3]) then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+local reset = 0
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if #oldest > 0 then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, count, reset}
+`)
+
+// RedisRateLimitStorage implements RateLimitStorage with a sorted set of
+// request times per key, shared by every replica
+type RedisRateLimitStorage struct {
+	redis *RedisClient
+}
+
+// NewRedisRateLimitStorage creates a new Redis rate limit storage
+func NewRedisRateLimitStorage(redis *RedisClient) *RedisRateLimitStorage {
+	return &RedisRateLimitStorage{redis: redis}
+}
+
+// rateLimitKey returns the key holding the requests counted for a limit
+func rateLimitKey(key string) string {
+	return "ratelimit:" + key
+}
+
+// Allow counts a request for key, allowing it if fewer than limit requests
+// were allowed during the last window. Rejected requests are not counted.
+func (s *RedisRateLimitStorage) Allow(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	values, err := slidingWindowScript.Run(ctx, s.redis.Client, []string{rateLimitKey(key)},
+		time.Now().UnixMilli(),
+		window.Milliseconds(),
+		limit,
+		util.GenerateID(),
+	).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("check rate limit: %w", err)
+	}
+	if len(values) != 3 {
+		return nil, fmt.Errorf("check rate limit: unexpected reply %v", values)
+	}
+
+	return &RateLimitResult{
+		Allowed:   values[0] == 1,
+		Remaining: max(limit-int(values[1]), 0),
+		Reset:     time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisRateLimitStorage_Allow(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	limiter := NewRedisRateLimitStorage(redisClient)
+	ctx := context.Background()
+
+	for i := 2; i >= 0; i-- {
+		result, err := limiter.Allow(ctx, "search:ip:192.0.2.1", 3, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+		assert.InDelta(t, time.Minute, result.Reset, float64(time.Second))
+	}
+
+	result, err := limiter.Allow(ctx, "search:ip:192.0.2.1", 3, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Positive(t, result.Reset)
+
+	// Rejected requests are not counted, and each key has its own window
+	card, err := redisClient.Client.ZCard(ctx, rateLimitKey("search:ip:192.0.2.1")).Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), card)
+
+	result, err = limiter.Allow(ctx, "search:ip:192.0.2.2", 3, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Positive(t, mini.TTL(rateLimitKey("search:ip:192.0.2.2")))
+}
+
+func TestRedisRateLimitStorage_SlidingWindow(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	limiter := NewRedisRateLimitStorage(redisClient)
+	ctx := context.Background()
+	window := 200 * time.Millisecond
+
+	result, err := limiter.Allow(ctx, "user:user-1", 1, window)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	result, err = limiter.Allow(ctx, "user:user-1", 1, window)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+
+	// Once the first request leaves the window another is allowed
+	time.Sleep(result.Reset + 10*time.Millisecond)
+
+	result, err = limiter.Allow(ctx, "user:user-1", 1, window)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
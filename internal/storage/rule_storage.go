@@ -3,10 +3,14 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/metrics"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -16,11 +20,149 @@ type RuleStorage interface {
 	ListRules(ctx context.Context, userID string) ([]*model.Rule, error)
 	CreateRule(ctx context.Context, rule *model.Rule) error
 	UpdateRule(ctx context.Context, rule *model.Rule) error
+	UpdateRuleState(ctx context.Context, ruleID string, state model.RuleState) error
 	DeleteRule(ctx context.Context, ruleID string) error
 	ScheduleRule(ctx context.Context, ruleID string, nextRun time.Time) error
 	GetScheduledRules(ctx context.Context, until time.Time) ([]string, error)
+	ClaimScheduledRules(ctx context.Context, now time.Time, owner string, lease time.Duration, limit int) ([]ScheduledRule, error)
+	CompleteScheduledRule(ctx context.Context, ruleID, owner string, nextRun time.Time) (bool, error)
+	OldestScheduledRule(ctx context.Context) (*ScheduledRule, error)
+	ClaimRule(ctx context.Context, ruleID, owner string, lease time.Duration) error
+	RenewRuleLease(ctx context.Context, ruleID, owner string, lease time.Duration) (bool, error)
+	ListAllRules(ctx context.Context) ([]*model.Rule, error)
+	ListScheduledRules(ctx context.Context, offset, limit int) ([]ScheduledRule, int64, error)
 }
 
+var (
+	// ErrRuleNotFound is returned when updating a rule that does not exist
+	ErrRuleNotFound = errors.New("rule not found")
+	// ErrRuleVersionConflict is returned when updating a rule that has
+	// changed since it was read
+	ErrRuleVersionConflict = errors.New("rule version conflict")
+	// ErrRuleLeased is returned when claiming a rule another scheduler is processing
+	ErrRuleLeased = errors.New("rule is being processed")
+)
+
+// ScheduledRule is a rule claimed from the schedule along with the time it was due
+type ScheduledRule struct {
+	RuleID string
+	DueAt  time.Time
+	Owner  string // Scheduler holding the rule's lease, only set when listing the schedule
+}
+
+// claimRulesScript atomically claims due rules, returning each with its due
+// time. Each claimed rule is pushed back in the schedule to the end of its
+// lease, so that it becomes due again if the owner never completes it, and a
+// lease key records the owner. Rules leased by another scheduler are skipped
+// and pushed back to the end of that lease instead.
+//
+// KEYS[1] schedule, ARGV[1] now, ARGV[2] lease end, ARGV[3] limit,
+// ARGV[4] owner, ARGV[5] lease milliseconds, ARGV[6] lease key prefix
+var claimRulesScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'WITHSCORES', 'LIMIT', 0, tonumber(ARGV[3]))
+local claimed = {}
+for i = 1, #due, 2 do
+	local lease = ARGV[6] .. due[i]
+	local holder = redis.call('GET', lease)
+	if holder and holder ~= ARGV[4] then
+		local ttl = redis.call('PTTL', lease)
+		redis.call('ZADD', KEYS[1], tonumber(ARGV[1]) + math.ceil(math.max(ttl, 0) / 1000), due[i])
+	else
+		redis.call('ZADD', KEYS[1], ARGV[2], due[i])
+		redis.call('SET', lease, ARGV[4], 'PX', tonumber(ARGV[5]))
+		table.insert(claimed, due[i])
+		table.insert(claimed, due[i + 1])
+	end
+end
+return claimed
+`)
+
+// claimRuleScript claims a single rule regardless of when it is due, unless
+// another scheduler holds its lease. Like claimRulesScript it pushes the rule
+// back to the end of its lease; rules missing from the schedule stay out of it.
+//
+// KEYS[1] schedule, KEYS[2] lease, ARGV[1] lease end, ARGV[2] owner,
+// ARGV[3] lease milliseconds, ARGV[4] rule ID
+var claimRuleScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	return 0
+end
+redis.call('ZADD', KEYS[1], 'XX', ARGV[1], ARGV[4])
+redis.call('SET', KEYS[2], ARGV[2], 'PX', tonumber(ARGV[3]))
+return 1
+`)
+
+// renewLeaseScript extends a lease from now, pushing the rule back in the
+// schedule to the new end of its lease, but only while the caller still owns
+// the lease.
+//
+// KEYS[1] schedule, KEYS[2] lease, ARGV[1] owner, ARGV[2] lease end,
+// ARGV[3] lease milliseconds, ARGV[4] rule ID
+var renewLeaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[2]) ~= ARGV[1] then
+	return 0
+end
+redis.call('ZADD', KEYS[1], 'XX', ARGV[2], ARGV[4])
+redis.call('PEXPIRE', KEYS[2], tonumber(ARGV[3]))
+return 1
+`)
+
+// completeRuleScript reschedules a claimed rule and releases its lease, but
+// only while the caller still owns the lease. Rules deleted while processing
+// are not added back to the schedule.
+//
+// KEYS[1] schedule, KEYS[2] lease, ARGV[1] owner, ARGV[2] next run, ARGV[3] rule ID
+var completeRuleScript = redis.NewScript(`
+if redis.call('GET', KEYS[2]) ~= ARGV[1] then
+	return 0
+end
+redis.call('ZADD', KEYS[1], 'XX', ARGV[2], ARGV[3])
+redis.call('DEL', KEYS[2])
+return 1
+`)
+
+// scheduleRuleScript schedules a rule, unless a scheduler holds its lease
+// and will reschedule it on completion.
+//
+// KEYS[1] schedule, KEYS[2] lease, ARGV[1] next run, ARGV[2] rule ID
+var scheduleRuleScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[1], ARGV[2])
+return 1
+`)
+
+// updateRuleScript replaces a rule, but only if its stored version is the one
+// the caller read. Rules stored before versioning count as version 0.
+//
+// KEYS[1] rule, ARGV[1] expected version, ARGV[2] rule data
+var updateRuleScript = redis.NewScript(`
+local data = redis.call('GET', KEYS[1])
+if not data then
+	return -1
+end
+local version = tonumber(cjson.decode(data).version) or 0
+if version ~= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2])
+return 1
+`)
+
+// updateRuleStateScript replaces a rule's runtime state, unless the rule has
+// been deleted.
+//
+// KEYS[1] rule, KEYS[2] state, ARGV field and value pairs
+var updateRuleStateScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('DEL', KEYS[2])
+redis.call('HSET', KEYS[2], unpack(ARGV))
+return 1
+`)
+
 // RedisRuleStorage implements RuleStorage using Redis
 type RedisRuleStorage struct {
 	redis *RedisClient
@@ -31,19 +173,29 @@ func NewRedisRuleStorage(redis *RedisClient) *RedisRuleStorage {
 	return &RedisRuleStorage{redis: redis}
 }
 
-// GetRule gets a rule by ID
+// GetRule gets a rule by ID, along with its runtime state
 func (s *RedisRuleStorage) GetRule(ctx context.Context, ruleID string) (*model.Rule, error) {
-	key := fmt.Sprintf("rule:%s", ruleID)
-	data, err := s.redis.Client.Get(ctx, key).Result()
-	if err != nil {
+	pipe := s.redis.Client.Pipeline()
+	ruleCmd := pipe.Get(ctx, ruleKey(ruleID))
+	stateCmd := pipe.HGetAll(ctx, ruleStateKey(ruleID))
+	if _, err := pipe.Exec(ctx); err != nil {
 		return nil, fmt.Errorf("get rule: %w", err)
 	}
 
 	var rule model.Rule
-	if err := json.Unmarshal([]byte(data), &rule); err != nil {
+	if err := json.Unmarshal([]byte(ruleCmd.Val()), &rule); err != nil {
 		return nil, fmt.Errorf("unmarshal rule: %w", err)
 	}
 
+	// Rules not checked since the state moved out of the rule keep it inline
+	if fields := stateCmd.Val(); len(fields) > 0 {
+		state, err := decodeRuleState(fields)
+		if err != nil {
+			return nil, fmt.Errorf("decode rule state: %w", err)
+		}
+		rule.SetState(state)
+	}
+
 	return &rule, nil
 }
 
@@ -73,10 +225,11 @@ func (s *RedisRuleStorage) CreateRule(ctx context.Context, rule *model.Rule) err
 	now := time.Now()
 	rule.CreatedAt = now
 	rule.UpdatedAt = now
+	rule.Version = 1
 
 	// Store rule
-	key := fmt.Sprintf("rule:%s", rule.ID)
-	data, err := json.Marshal(rule)
+	key := ruleKey(rule.ID)
+	data, err := json.Marshal(ruleSettings(rule))
 	if err != nil {
 		return fmt.Errorf("marshal rule: %w", err)
 	}
@@ -94,32 +247,62 @@ func (s *RedisRuleStorage) CreateRule(ctx context.Context, rule *model.Rule) err
 		return fmt.Errorf("create rule: %w", err)
 	}
 
+	metrics.RulesCount.WithLabelValues(rule.Type).Inc()
+
 	return nil
 }
 
-// UpdateRule updates an existing rule
+// UpdateRule updates an existing rule's settings, leaving its runtime state
+// alone. The rule must carry the version it was read at: if it has changed
+// since, ErrRuleVersionConflict is returned and nothing is written. On success
+// the rule's version is incremented.
 func (s *RedisRuleStorage) UpdateRule(ctx context.Context, rule *model.Rule) error {
-	// Check if rule exists
-	key := fmt.Sprintf("rule:%s", rule.ID)
-	exists, err := s.redis.Client.Exists(ctx, key).Result()
-	if err != nil {
-		return fmt.Errorf("check rule existence: %w", err)
+	version, updatedAt := rule.Version, rule.UpdatedAt
+	rule.Version++
+	rule.UpdatedAt = time.Now()
+
+	result, err := s.updateRule(ctx, rule, version)
+	if err != nil || result != 1 {
+		rule.Version, rule.UpdatedAt = version, updatedAt
 	}
-	if exists == 0 {
-		return fmt.Errorf("rule not found: %s", rule.ID)
+
+	switch {
+	case err != nil:
+		return err
+	case result == -1:
+		return fmt.Errorf("update rule %s: %w", rule.ID, ErrRuleNotFound)
+	case result == 0:
+		return fmt.Errorf("update rule %s at version %d: %w", rule.ID, version, ErrRuleVersionConflict)
 	}
 
-	// Update timestamp
-	rule.UpdatedAt = time.Now()
+	return nil
+}
 
-	// Serialize and store
-	data, err := json.Marshal(rule)
+// updateRule stores a rule if its stored version is the expected one
+func (s *RedisRuleStorage) updateRule(ctx context.Context, rule *model.Rule, version int64) (int64, error) {
+	data, err := json.Marshal(ruleSettings(rule))
 	if err != nil {
-		return fmt.Errorf("marshal rule: %w", err)
+		return 0, fmt.Errorf("marshal rule: %w", err)
 	}
 
-	if err := s.redis.Client.Set(ctx, key, data, 0).Err(); err != nil {
-		return fmt.Errorf("update rule: %w", err)
+	result, err := updateRuleScript.Run(ctx, s.redis.Client, []string{ruleKey(rule.ID)}, version, data).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("update rule: %w", err)
+	}
+	return result, nil
+}
+
+// UpdateRuleState records a rule's runtime state. It never touches the rule's
+// settings, so it cannot undo a concurrent update. ErrRuleNotFound is returned
+// if the rule has been deleted.
+func (s *RedisRuleStorage) UpdateRuleState(ctx context.Context, ruleID string, state model.RuleState) error {
+	updated, err := updateRuleStateScript.Run(ctx, s.redis.Client,
+		[]string{ruleKey(ruleID), ruleStateKey(ruleID)}, encodeRuleState(state)...).Int()
+	if err != nil {
+		return fmt.Errorf("update rule state: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("update rule state %s: %w", ruleID, ErrRuleNotFound)
 	}
 
 	return nil
@@ -133,34 +316,65 @@ func (s *RedisRuleStorage) DeleteRule(ctx context.Context, ruleID string) error
 		return fmt.Errorf("get rule: %w", err)
 	}
 
-	key := fmt.Sprintf("rule:%s", ruleID)
+	key := ruleKey(ruleID)
 	userKey := fmt.Sprintf("rules:user:%s", rule.UserID)
 	scheduleKey := "rules:schedule"
-	seenKey := fmt.Sprintf("seen:%s", ruleID)
 
 	pipe := s.redis.Client.Pipeline()
-	pipe.Del(ctx, key)
+	pipe.Del(ctx, key, ruleStateKey(ruleID))
 	pipe.SRem(ctx, userKey, ruleID)
 	pipe.ZRem(ctx, scheduleKey, ruleID)
-	pipe.Del(ctx, seenKey)
+	pipe.Del(ctx, seenKey(ruleID), seenFingerprintKey(ruleID))
+	pipe.Del(ctx, notificationsKey(ruleID))
+	pipe.Del(ctx, ruleLeaseKey(ruleID))
+	pipe.Del(ctx, outboxKey(ruleID))
 	_, err = pipe.Exec(ctx)
 
 	if err != nil {
 		return fmt.Errorf("delete rule: %w", err)
 	}
 
+	metrics.RulesCount.WithLabelValues(rule.Type).Dec()
+
 	return nil
 }
 
-// ScheduleRule schedules a rule for execution
-func (s *RedisRuleStorage) ScheduleRule(ctx context.Context, ruleID string, nextRun time.Time) error {
-	key := "rules:schedule"
-	score := float64(nextRun.Unix())
+// SyncRuleMetrics sets the rules count metric from the rules stored in Redis
+func SyncRuleMetrics(ctx context.Context, redisClient *RedisClient) error {
+	counts := make(map[string]int)
+
+	iter := redisClient.Client.Scan(ctx, 0, "rule:*", 100).Iterator()
+	for iter.Next(ctx) {
+		data, err := redisClient.Client.Get(ctx, iter.Val()).Bytes()
+		if err != nil {
+			continue // Deleted since the scan found it
+		}
 
-	if err := s.redis.Client.ZAdd(ctx, key, redis.Z{
-		Score:  score,
-		Member: ruleID,
-	}).Err(); err != nil {
+		var rule model.Rule
+		if err := json.Unmarshal(data, &rule); err != nil {
+			continue
+		}
+		counts[rule.Type]++
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("scan rules: %w", err)
+	}
+
+	metrics.RulesCount.Reset()
+	for ruleType, count := range counts {
+		metrics.RulesCount.WithLabelValues(ruleType).Set(float64(count))
+	}
+
+	return nil
+}
+
+// ScheduleRule schedules a rule for execution. Rules currently leased are
+// left alone, since their owner reschedules them when it completes.
+func (s *RedisRuleStorage) ScheduleRule(ctx context.Context, ruleID string, nextRun time.Time) error {
+	if err := scheduleRuleScript.Run(ctx, s.redis.Client, []string{"rules:schedule", ruleLeaseKey(ruleID)},
+		nextRun.Unix(),
+		ruleID,
+	).Err(); err != nil {
 		return fmt.Errorf("schedule rule: %w", err)
 	}
 
@@ -184,3 +398,266 @@ func (s *RedisRuleStorage) GetScheduledRules(ctx context.Context, until time.Tim
 
 	return rules, nil
 }
+
+// ruleKeyPrefix prefixes the keys holding each rule's settings
+const ruleKeyPrefix = "rule:"
+
+// ruleKey returns the key holding a rule's settings
+func ruleKey(ruleID string) string {
+	return ruleKeyPrefix + ruleID
+}
+
+// ruleStateKey returns the key of the hash holding a rule's runtime state
+func ruleStateKey(ruleID string) string {
+	return "rulestate:" + ruleID
+}
+
+// ruleSettings returns a copy of a rule without its runtime state, which is
+// stored separately
+func ruleSettings(rule *model.Rule) *model.Rule {
+	settings := *rule
+	settings.SetState(model.RuleState{})
+	return &settings
+}
+
+// encodeRuleState flattens a rule's runtime state into hash fields and values.
+// Every field is written, empty when unset, so a hash always holds a whole state.
+func encodeRuleState(state model.RuleState) []interface{} {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339Nano)
+	}
+
+	formatTimePtr := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return formatTime(*t)
+	}
+
+	return []interface{}{
+		"last_checked", formatTime(state.LastChecked),
+		"last_notification", formatTime(state.LastNotification),
+		"last_match_count", state.LastMatchCount,
+		"consecutive_failures", state.ConsecutiveFailures,
+		"next_retry_at", formatTimePtr(state.NextRetryAt),
+		"last_error", state.LastError,
+		"next_delivery_at", formatTimePtr(state.NextDeliveryAt),
+	}
+}
+
+// decodeRuleState reads a rule's runtime state from its hash fields
+func decodeRuleState(fields map[string]string) (model.RuleState, error) {
+	var state model.RuleState
+	var err error
+
+	parseTime := func(name string) time.Time {
+		value := fields[name]
+		if value == "" || err != nil {
+			return time.Time{}
+		}
+		var t time.Time
+		if t, err = time.Parse(time.RFC3339Nano, value); err != nil {
+			err = fmt.Errorf("parse %s: %w", name, err)
+		}
+		return t
+	}
+	parseInt := func(name string) int {
+		value := fields[name]
+		if value == "" || err != nil {
+			return 0
+		}
+		var n int
+		if n, err = strconv.Atoi(value); err != nil {
+			err = fmt.Errorf("parse %s: %w", name, err)
+		}
+		return n
+	}
+
+	state.LastChecked = parseTime("last_checked")
+	state.LastNotification = parseTime("last_notification")
+	state.LastMatchCount = parseInt("last_match_count")
+	state.ConsecutiveFailures = parseInt("consecutive_failures")
+	if nextRetryAt := parseTime("next_retry_at"); !nextRetryAt.IsZero() {
+		state.NextRetryAt = &nextRetryAt
+	}
+	state.LastError = fields["last_error"]
+	if nextDeliveryAt := parseTime("next_delivery_at"); !nextDeliveryAt.IsZero() {
+		state.NextDeliveryAt = &nextDeliveryAt
+	}
+
+	return state, err
+}
+
+// ruleLeaseKeyPrefix prefixes the keys recording which scheduler owns a claimed rule
+const ruleLeaseKeyPrefix = "lease:rule:"
+
+// ruleLeaseKey returns the lease key for a rule
+func ruleLeaseKey(ruleID string) string {
+	return ruleLeaseKeyPrefix + ruleID
+}
+
+// ClaimScheduledRules atomically claims up to limit rules due by now for
+// owner. Claimed rules are leased for the given duration; a rule whose lease
+// expires without being completed becomes due again and can be claimed by
+// another scheduler.
+func (s *RedisRuleStorage) ClaimScheduledRules(ctx context.Context, now time.Time, owner string, lease time.Duration, limit int) ([]ScheduledRule, error) {
+	values, err := claimRulesScript.Run(ctx, s.redis.Client, []string{"rules:schedule"},
+		now.Unix(),
+		now.Add(lease).Unix(),
+		limit,
+		owner,
+		lease.Milliseconds(),
+		ruleLeaseKeyPrefix,
+	).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("claim scheduled rules: %w", err)
+	}
+
+	rules := make([]ScheduledRule, 0, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		score, err := strconv.ParseFloat(values[i+1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse schedule score: %w", err)
+		}
+		rules = append(rules, ScheduledRule{RuleID: values[i], DueAt: time.Unix(int64(score), 0)})
+	}
+
+	return rules, nil
+}
+
+// CompleteScheduledRule reschedules a claimed rule for nextRun and releases
+// its lease. It returns false without rescheduling if owner no longer holds
+// the lease, because it expired and the rule may have been claimed again.
+func (s *RedisRuleStorage) CompleteScheduledRule(ctx context.Context, ruleID, owner string, nextRun time.Time) (bool, error) {
+	completed, err := completeRuleScript.Run(ctx, s.redis.Client, []string{"rules:schedule", ruleLeaseKey(ruleID)},
+		owner,
+		nextRun.Unix(),
+		ruleID,
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("complete scheduled rule: %w", err)
+	}
+
+	return completed == 1, nil
+}
+
+// OldestScheduledRule returns the rule that has been due the longest, or nil
+// when no rules are scheduled. Rules under lease are pushed back to the end
+// of their lease, so a due time in the past means nobody has claimed it yet.
+func (s *RedisRuleStorage) OldestScheduledRule(ctx context.Context) (*ScheduledRule, error) {
+	entries, err := s.redis.Client.ZRangeWithScores(ctx, "rules:schedule", 0, 0).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get oldest scheduled rule: %w", err)
+	}
+
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	return &ScheduledRule{
+		RuleID: entries[0].Member.(string),
+		DueAt:  time.Unix(int64(entries[0].Score), 0),
+	}, nil
+}
+
+// ClaimRule leases a rule to owner so it can be processed now, whenever it is
+// due. It returns ErrRuleLeased if a scheduler is already processing the rule.
+// The claim is released with CompleteScheduledRule.
+func (s *RedisRuleStorage) ClaimRule(ctx context.Context, ruleID, owner string, lease time.Duration) error {
+	claimed, err := claimRuleScript.Run(ctx, s.redis.Client, []string{"rules:schedule", ruleLeaseKey(ruleID)},
+		time.Now().Add(lease).Unix(),
+		owner,
+		lease.Milliseconds(),
+		ruleID,
+	).Int()
+	if err != nil {
+		return fmt.Errorf("claim rule: %w", err)
+	}
+
+	if claimed == 0 {
+		return ErrRuleLeased
+	}
+	return nil
+}
+
+// RenewRuleLease extends owner's lease on a rule to lease from now. It
+// returns false if owner no longer holds the lease, because it expired and
+// the rule may have been claimed by another scheduler.
+func (s *RedisRuleStorage) RenewRuleLease(ctx context.Context, ruleID, owner string, lease time.Duration) (bool, error) {
+	renewed, err := renewLeaseScript.Run(ctx, s.redis.Client, []string{"rules:schedule", ruleLeaseKey(ruleID)},
+		owner,
+		time.Now().Add(lease).Unix(),
+		lease.Milliseconds(),
+		ruleID,
+	).Int()
+	if err != nil {
+		return false, fmt.Errorf("renew rule lease: %w", err)
+	}
+
+	return renewed == 1, nil
+}
+
+// ListAllRules lists the rules of every user, in no particular order. It
+// scans the keyspace, so it is meant for admin use rather than request paths.
+func (s *RedisRuleStorage) ListAllRules(ctx context.Context) ([]*model.Rule, error) {
+	var rules []*model.Rule
+
+	iter := s.redis.Client.Scan(ctx, 0, ruleKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		rule, err := s.GetRule(ctx, strings.TrimPrefix(iter.Val(), ruleKeyPrefix))
+		if err != nil {
+			continue // Deleted since the scan found it
+		}
+		rules = append(rules, rule)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("scan rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// ListScheduledRules returns a page of the schedule ordered by next run, along
+// with the number of scheduled rules. Rules under lease carry their owner; their
+// next run is the end of the lease.
+func (s *RedisRuleStorage) ListScheduledRules(ctx context.Context, offset, limit int) ([]ScheduledRule, int64, error) {
+	total, err := s.redis.Client.ZCard(ctx, "rules:schedule").Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("count scheduled rules: %w", err)
+	}
+
+	entries, err := s.redis.Client.ZRangeWithScores(ctx, "rules:schedule", int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("list scheduled rules: %w", err)
+	}
+
+	if len(entries) == 0 {
+		return []ScheduledRule{}, total, nil
+	}
+
+	leaseKeys := make([]string, len(entries))
+	for i, entry := range entries {
+		leaseKeys[i] = ruleLeaseKey(entry.Member.(string))
+	}
+
+	owners, err := s.redis.Client.MGet(ctx, leaseKeys...).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("get rule leases: %w", err)
+	}
+
+	rules := make([]ScheduledRule, len(entries))
+	for i, entry := range entries {
+		rules[i] = ScheduledRule{
+			RuleID: entry.Member.(string),
+			DueAt:  time.Unix(int64(entry.Score), 0),
+		}
+		if owner, ok := owners[i].(string); ok {
+			rules[i].Owner = owner
+		}
+	}
+
+	return rules, total, nil
+}
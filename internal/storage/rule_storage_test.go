@@ -2,13 +2,20 @@ package storage
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/metrics"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func setupTestRedis(t *testing.T) (*miniredis.Miniredis, *RedisClient) {
@@ -164,6 +171,132 @@ func TestRedisRuleStorage_UpdateRule(t *testing.T) {
 	assert.Equal(t, []string{"club-1", "club-3"}, updatedRule.ClubIDs)
 }
 
+func TestRedisRuleStorage_UpdateRule_Version(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	ruleStorage := NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+
+	rule := &model.Rule{ID: "rule-1", UserID: "user-1", Type: "match", Name: "Test Rule"}
+	require.NoError(t, ruleStorage.CreateRule(ctx, rule))
+	assert.Equal(t, int64(1), rule.Version)
+
+	// Two writers read the same version; the second update must not win
+	first, err := ruleStorage.GetRule(ctx, "rule-1")
+	require.NoError(t, err)
+	second, err := ruleStorage.GetRule(ctx, "rule-1")
+	require.NoError(t, err)
+
+	first.Name = "First"
+	require.NoError(t, ruleStorage.UpdateRule(ctx, first))
+	assert.Equal(t, int64(2), first.Version)
+
+	second.Name = "Second"
+	err = ruleStorage.UpdateRule(ctx, second)
+	assert.ErrorIs(t, err, ErrRuleVersionConflict)
+	assert.Equal(t, int64(1), second.Version, "A failed update keeps the version read")
+
+	stored, err := ruleStorage.GetRule(ctx, "rule-1")
+	require.NoError(t, err)
+	assert.Equal(t, "First", stored.Name)
+	assert.Equal(t, int64(2), stored.Version)
+
+	missing := &model.Rule{ID: "missing", Version: 1}
+	assert.ErrorIs(t, ruleStorage.UpdateRule(ctx, missing), ErrRuleNotFound)
+}
+
+func TestRedisRuleStorage_UpdateRule_Unversioned(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	ruleStorage := NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+
+	// Rules stored before versioning have no version and count as version 0
+	require.NoError(t, mini.Set("rule:rule-1", `{"id":"rule-1","rule_type":"match","name":"Old Rule","last_error":"timeout","consecutive_failures":2}`))
+
+	rule, err := ruleStorage.GetRule(ctx, "rule-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), rule.Version)
+	assert.Equal(t, 2, rule.ConsecutiveFailures, "State stored inline is still read")
+
+	rule.Name = "Renamed"
+	require.NoError(t, ruleStorage.UpdateRule(ctx, rule))
+	assert.Equal(t, int64(1), rule.Version)
+}
+
+func TestRedisRuleStorage_UpdateRuleState(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	ruleStorage := NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+
+	rule := &model.Rule{ID: "rule-1", UserID: "user-1", Type: "match", Name: "Test Rule"}
+	require.NoError(t, ruleStorage.CreateRule(ctx, rule))
+
+	// The scheduler read the rule before the user renamed it
+	checked, err := ruleStorage.GetRule(ctx, "rule-1")
+	require.NoError(t, err)
+
+	rule.Name = "Renamed"
+	require.NoError(t, ruleStorage.UpdateRule(ctx, rule))
+
+	retryAt := time.Date(2030, 6, 1, 10, 5, 0, 0, time.UTC)
+	deliveryAt := time.Date(2030, 6, 1, 10, 2, 0, 0, time.UTC)
+	state := model.RuleState{
+		LastChecked:         time.Date(2030, 6, 1, 10, 0, 0, 0, time.UTC),
+		LastNotification:    time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC),
+		LastMatchCount:      3,
+		ConsecutiveFailures: 1,
+		NextRetryAt:         &retryAt,
+		LastError:           "upstream down",
+		NextDeliveryAt:      &deliveryAt,
+	}
+	checked.SetState(state)
+	require.NoError(t, ruleStorage.UpdateRuleState(ctx, checked.ID, checked.State()))
+
+	stored, err := ruleStorage.GetRule(ctx, "rule-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", stored.Name, "Recording a check keeps the user's edit")
+	assert.Equal(t, int64(2), stored.Version)
+	assert.True(t, state.LastChecked.Equal(stored.LastChecked))
+	assert.True(t, state.LastNotification.Equal(stored.LastNotification))
+	assert.Equal(t, 3, stored.LastMatchCount)
+	assert.Equal(t, 1, stored.ConsecutiveFailures)
+	require.NotNil(t, stored.NextRetryAt)
+	assert.True(t, retryAt.Equal(*stored.NextRetryAt))
+	assert.Equal(t, "upstream down", stored.LastError)
+	require.NotNil(t, stored.NextDeliveryAt)
+	assert.True(t, deliveryAt.Equal(*stored.NextDeliveryAt))
+
+	// The state is kept out of the rule's settings, and a user update leaves it alone
+	data, err := mini.Get("rule:rule-1")
+	require.NoError(t, err)
+	assert.NotContains(t, data, "upstream down")
+
+	require.NoError(t, ruleStorage.UpdateRule(ctx, stored))
+	stored, err = ruleStorage.GetRule(ctx, "rule-1")
+	require.NoError(t, err)
+	assert.Equal(t, "upstream down", stored.LastError)
+
+	// A recovered rule clears its failure state
+	require.NoError(t, ruleStorage.UpdateRuleState(ctx, "rule-1", model.RuleState{LastChecked: state.LastChecked}))
+	stored, err = ruleStorage.GetRule(ctx, "rule-1")
+	require.NoError(t, err)
+	assert.Zero(t, stored.ConsecutiveFailures)
+	assert.Nil(t, stored.NextRetryAt)
+	assert.Empty(t, stored.LastError)
+	assert.Nil(t, stored.NextDeliveryAt)
+
+	// State is not recorded for deleted rules
+	require.NoError(t, ruleStorage.DeleteRule(ctx, "rule-1"))
+	assert.False(t, mini.Exists("rulestate:rule-1"))
+	assert.ErrorIs(t, ruleStorage.UpdateRuleState(ctx, "rule-1", state), ErrRuleNotFound)
+	assert.False(t, mini.Exists("rulestate:rule-1"))
+}
+
 func TestRedisRuleStorage_DeleteRule(t *testing.T) {
 	mini, redisClient := setupTestRedis(t)
 	defer mini.Close()
@@ -190,6 +323,7 @@ func TestRedisRuleStorage_DeleteRule(t *testing.T) {
 	assert.NoError(t, err)
 	_, err = mini.ZAdd(scheduleKey, 1.0, ruleID)
 	assert.NoError(t, err)
+	mini.HSet("outbox:"+ruleID, "activity-1", "{}")
 
 	err = ruleStorage.DeleteRule(ctx, ruleID)
 	assert.NoError(t, err)
@@ -203,6 +337,7 @@ func TestRedisRuleStorage_DeleteRule(t *testing.T) {
 
 	exists = mini.Exists(seenKey)
 	assert.False(t, exists)
+	assert.False(t, mini.Exists("outbox:"+ruleID))
 
 	scheduleMembers, _ := mini.ZMembers(scheduleKey)
 	assert.NotContains(t, scheduleMembers, ruleID)
@@ -257,3 +392,405 @@ func TestRedisRuleStorage_GetScheduledRules(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, rules, 3)
 }
+
+// scheduledIDs returns the IDs of claimed rules
+func scheduledIDs(rules []ScheduledRule) []string {
+	ids := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		ids = append(ids, rule.RuleID)
+	}
+	return ids
+}
+
+func TestRedisRuleStorage_ClaimScheduledRules(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	ruleStorage := NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, ruleStorage.ScheduleRule(ctx, "rule-1", now.Add(-time.Minute)))
+	require.NoError(t, ruleStorage.ScheduleRule(ctx, "rule-2", now))
+	require.NoError(t, ruleStorage.ScheduleRule(ctx, "rule-3", now.Add(time.Hour)))
+
+	rules, err := ruleStorage.ClaimScheduledRules(ctx, now, "owner-a", time.Minute, 10)
+	require.NoError(t, err)
+	for _, rule := range rules {
+		assert.False(t, rule.DueAt.After(now), "rules are claimed with the time they were due")
+	}
+	assert.ElementsMatch(t, []string{"rule-1", "rule-2"}, scheduledIDs(rules))
+
+	owner, err := mini.Get("lease:rule:rule-1")
+	require.NoError(t, err)
+	assert.Equal(t, "owner-a", owner)
+	assert.Greater(t, mini.TTL("lease:rule:rule-1"), time.Duration(0))
+
+	// Claimed rules are not due again while leased
+	rules, err = ruleStorage.ClaimScheduledRules(ctx, now, "owner-b", time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	// Completing reschedules the rule and releases the lease
+	next := now.Add(5 * time.Minute)
+	completed, err := ruleStorage.CompleteScheduledRule(ctx, "rule-1", "owner-a", next)
+	require.NoError(t, err)
+	assert.True(t, completed)
+	assert.False(t, mini.Exists("lease:rule:rule-1"))
+
+	score, err := mini.ZScore("rules:schedule", "rule-1")
+	require.NoError(t, err)
+	assert.Equal(t, float64(next.Unix()), score)
+}
+
+func TestRedisRuleStorage_ClaimScheduledRules_Limit(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	ruleStorage := NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, ruleStorage.ScheduleRule(ctx, fmt.Sprintf("rule-%d", i), now.Add(-time.Minute)))
+	}
+
+	rules, err := ruleStorage.ClaimScheduledRules(ctx, now, "owner-a", time.Minute, 3)
+	require.NoError(t, err)
+	assert.Len(t, rules, 3)
+
+	rules, err = ruleStorage.ClaimScheduledRules(ctx, now, "owner-b", time.Minute, 3)
+	require.NoError(t, err)
+	assert.Len(t, rules, 2)
+}
+
+func TestRedisRuleStorage_ExpiredLeaseIsReclaimed(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	ruleStorage := NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, ruleStorage.ScheduleRule(ctx, "rule-1", now))
+
+	rules, err := ruleStorage.ClaimScheduledRules(ctx, now, "owner-a", time.Minute, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rule-1"}, scheduledIDs(rules))
+
+	// Owner A never completes; once the lease ends the rule is due again
+	later := now.Add(2 * time.Minute)
+	mini.FastForward(2 * time.Minute)
+
+	rules, err = ruleStorage.ClaimScheduledRules(ctx, later, "owner-b", time.Minute, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rule-1"}, scheduledIDs(rules))
+
+	// The stale owner can no longer reschedule the rule
+	completed, err := ruleStorage.CompleteScheduledRule(ctx, "rule-1", "owner-a", later.Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, completed)
+
+	completed, err = ruleStorage.CompleteScheduledRule(ctx, "rule-1", "owner-b", later.Add(5*time.Minute))
+	require.NoError(t, err)
+	assert.True(t, completed)
+}
+
+func TestRedisRuleStorage_CompleteDeletedRule(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	ruleStorage := NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, ruleStorage.ScheduleRule(ctx, "rule-1", now))
+
+	_, err := ruleStorage.ClaimScheduledRules(ctx, now, "owner-a", time.Minute, 10)
+	require.NoError(t, err)
+
+	// The rule is removed from the schedule while it is being processed
+	_, err = mini.ZRem("rules:schedule", "rule-1")
+	require.NoError(t, err)
+
+	_, err = ruleStorage.CompleteScheduledRule(ctx, "rule-1", "owner-a", now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, mini.Exists("rules:schedule"))
+}
+
+func TestRedisRuleStorage_ConcurrentClaims(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	ruleStorage := NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+	now := time.Now()
+
+	const ruleCount = 200
+	for i := 0; i < ruleCount; i++ {
+		require.NoError(t, ruleStorage.ScheduleRule(ctx, fmt.Sprintf("rule-%d", i), now.Add(-time.Minute)))
+	}
+
+	var mu sync.Mutex
+	claims := make(map[string]int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(owner string) {
+			defer wg.Done()
+			for {
+				rules, err := ruleStorage.ClaimScheduledRules(ctx, now, owner, time.Minute, 7)
+				if !assert.NoError(t, err) || len(rules) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, rule := range rules {
+					claims[rule.RuleID]++
+				}
+				mu.Unlock()
+			}
+		}(fmt.Sprintf("owner-%d", i))
+	}
+	wg.Wait()
+
+	assert.Len(t, claims, ruleCount)
+	for id, count := range claims {
+		assert.Equal(t, 1, count, id)
+	}
+}
+
+func TestRedisRuleStorage_OldestScheduledRule(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	ruleStorage := NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+	now := time.Now()
+
+	oldest, err := ruleStorage.OldestScheduledRule(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, oldest)
+
+	require.NoError(t, ruleStorage.ScheduleRule(ctx, "rule-1", now.Add(-time.Minute)))
+	require.NoError(t, ruleStorage.ScheduleRule(ctx, "rule-2", now.Add(-5*time.Minute)))
+	require.NoError(t, ruleStorage.ScheduleRule(ctx, "rule-3", now.Add(time.Hour)))
+
+	oldest, err = ruleStorage.OldestScheduledRule(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, oldest)
+	assert.Equal(t, "rule-2", oldest.RuleID)
+	assert.Equal(t, now.Add(-5*time.Minute).Unix(), oldest.DueAt.Unix())
+
+	// Claimed rules move to the end of their lease
+	_, err = ruleStorage.ClaimScheduledRules(ctx, now, "owner-a", time.Minute, 10)
+	require.NoError(t, err)
+
+	oldest, err = ruleStorage.OldestScheduledRule(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, oldest)
+	assert.True(t, oldest.DueAt.After(now))
+}
+
+func TestRedisRuleStorage_ClaimRule(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	ruleStorage := NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, ruleStorage.ScheduleRule(ctx, "rule-1", now.Add(time.Hour)))
+
+	// Claimed before it is due, and held against other schedulers
+	require.NoError(t, ruleStorage.ClaimRule(ctx, "rule-1", "owner-a", time.Minute))
+	assert.ErrorIs(t, ruleStorage.ClaimRule(ctx, "rule-1", "owner-b", time.Minute), ErrRuleLeased)
+
+	claimed, err := ruleStorage.ClaimScheduledRules(ctx, now, "owner-b", time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	completed, err := ruleStorage.CompleteScheduledRule(ctx, "rule-1", "owner-a", now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, completed)
+	require.NoError(t, ruleStorage.ClaimRule(ctx, "rule-1", "owner-b", time.Minute))
+
+	// Unscheduled rules can be claimed but are not added to the schedule
+	require.NoError(t, ruleStorage.ClaimRule(ctx, "rule-2", "owner-a", time.Minute))
+	members, err := mini.ZMembers("rules:schedule")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rule-1"}, members)
+}
+
+func TestRedisRuleStorage_RenewRuleLease(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	ruleStorage := NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, ruleStorage.ScheduleRule(ctx, "rule-1", now))
+	claimed, err := ruleStorage.ClaimScheduledRules(ctx, now, "owner-a", time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	// Renewing restarts the lease and keeps the rule out of the due range
+	mini.FastForward(50 * time.Second)
+	renewed, err := ruleStorage.RenewRuleLease(ctx, "rule-1", "owner-a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, renewed)
+	assert.Equal(t, time.Minute, mini.TTL(ruleLeaseKey("rule-1")))
+
+	score, err := mini.ZScore("rules:schedule", "rule-1")
+	require.NoError(t, err)
+	assert.InDelta(t, float64(now.Add(time.Minute).Unix()), score, 1)
+
+	// Only the owner can renew, and not once the lease is gone
+	renewed, err = ruleStorage.RenewRuleLease(ctx, "rule-1", "owner-b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, renewed)
+
+	mini.FastForward(2 * time.Minute)
+	renewed, err = ruleStorage.RenewRuleLease(ctx, "rule-1", "owner-a", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, renewed)
+}
+
+func TestRedisRuleStorage_LeasedRulesAreNotClaimed(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	ruleStorage := NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, ruleStorage.ScheduleRule(ctx, "rule-1", now.Add(time.Hour)))
+	require.NoError(t, ruleStorage.ClaimRule(ctx, "rule-1", "owner-a", time.Minute))
+
+	// Rescheduling a leased rule, as an update does, leaves it with its owner
+	require.NoError(t, ruleStorage.ScheduleRule(ctx, "rule-1", now))
+	score, err := mini.ZScore("rules:schedule", "rule-1")
+	require.NoError(t, err)
+	assert.Greater(t, score, float64(now.Unix()))
+
+	// A leased rule that is due anyway is skipped and pushed back to the end of its lease
+	require.NoError(t, redisClient.Client.ZAdd(ctx, "rules:schedule", redis.Z{Score: float64(now.Unix()), Member: "rule-1"}).Err())
+	claimed, err := ruleStorage.ClaimScheduledRules(ctx, now, "owner-b", time.Minute, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	owner, err := mini.Get(ruleLeaseKey("rule-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "owner-a", owner)
+
+	score, err = mini.ZScore("rules:schedule", "rule-1")
+	require.NoError(t, err)
+	assert.InDelta(t, float64(now.Add(time.Minute).Unix()), score, 1)
+
+	// The owner can still claim it, and others can once the lease is released
+	claimed, err = ruleStorage.ClaimScheduledRules(ctx, now.Add(2*time.Minute), "owner-a", time.Minute, 10)
+	require.NoError(t, err)
+	assert.Len(t, claimed, 1)
+
+	completed, err := ruleStorage.CompleteScheduledRule(ctx, "rule-1", "owner-a", now)
+	require.NoError(t, err)
+	assert.True(t, completed)
+
+	claimed, err = ruleStorage.ClaimScheduledRules(ctx, now, "owner-b", time.Minute, 10)
+	require.NoError(t, err)
+	assert.Len(t, claimed, 1)
+}
+
+func TestRedisRuleStorage_ListAllRules(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	ruleStorage := NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+
+	rules, err := ruleStorage.ListAllRules(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	for i, userID := range []string{"user-1", "user-1", "user-2"} {
+		rule := model.NewRule("match", fmt.Sprintf("Rule %d", i), []string{"club-1"}, userID, "", "")
+		rule.ID = fmt.Sprintf("rule-%d", i)
+		require.NoError(t, ruleStorage.CreateRule(ctx, rule))
+	}
+	require.NoError(t, ruleStorage.ScheduleRule(ctx, "rule-0", time.Now()))
+
+	rules, err = ruleStorage.ListAllRules(ctx)
+	require.NoError(t, err)
+
+	ids := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		ids = append(ids, rule.ID)
+	}
+	assert.ElementsMatch(t, []string{"rule-0", "rule-1", "rule-2"}, ids)
+}
+
+func TestRedisRuleStorage_ListScheduledRules(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	ruleStorage := NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, ruleStorage.ScheduleRule(ctx, "rule-1", now.Add(time.Minute)))
+	require.NoError(t, ruleStorage.ScheduleRule(ctx, "rule-2", now.Add(-time.Minute)))
+	require.NoError(t, ruleStorage.ScheduleRule(ctx, "rule-3", now.Add(time.Hour)))
+	require.NoError(t, ruleStorage.ClaimRule(ctx, "rule-3", "owner-a", 30*time.Second))
+
+	rules, total, err := ruleStorage.ListScheduledRules(ctx, 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "rule-2", rules[0].RuleID)
+	assert.Equal(t, now.Add(-time.Minute).Unix(), rules[0].DueAt.Unix())
+	assert.Empty(t, rules[0].Owner)
+
+	// The leased rule now runs at the end of its lease, before rule-1
+	assert.Equal(t, "rule-3", rules[1].RuleID)
+	assert.Equal(t, "owner-a", rules[1].Owner)
+
+	rules, _, err = ruleStorage.ListScheduledRules(ctx, 2, 2)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, "rule-1", rules[0].RuleID)
+
+	rules, _, err = ruleStorage.ListScheduledRules(ctx, 10, 2)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+}
+
+func TestRedisRuleStorage_RulesCountMetric(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	metrics.RulesCount.Reset()
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(metrics.RulesCount)
+
+	ruleStorage := NewRedisRuleStorage(redisClient)
+	ctx := context.Background()
+
+	require.NoError(t, ruleStorage.CreateRule(ctx, &model.Rule{ID: "rule-1", UserID: "user-1", Type: "match"}))
+	require.NoError(t, ruleStorage.CreateRule(ctx, &model.Rule{ID: "rule-2", UserID: "user-1", Type: "match"}))
+	require.NoError(t, ruleStorage.CreateRule(ctx, &model.Rule{ID: "rule-3", UserID: "user-1", Type: "class"}))
+	require.NoError(t, ruleStorage.DeleteRule(ctx, "rule-1"))
+
+	expected := `
+# HELP padel_alert_rules_count The current number of rules
+# TYPE padel_alert_rules_count gauge
+padel_alert_rules_count{type="class"} 1
+padel_alert_rules_count{type="match"} 1
+`
+	require.NoError(t, promtestutil.GatherAndCompare(registry, strings.NewReader(expected), "padel_alert_rules_count"))
+
+	// Counts are rebuilt from Redis on startup
+	metrics.RulesCount.Reset()
+	require.NoError(t, SyncRuleMetrics(ctx, redisClient))
+	require.NoError(t, promtestutil.GatherAndCompare(registry, strings.NewReader(expected), "padel_alert_rules_count"))
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisSchedulerStorage_PauseAndResume(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	schedulerStorage := NewRedisSchedulerStorage(redisClient)
+	ctx := context.Background()
+
+	pausedAt, err := schedulerStorage.PausedAt(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, pausedAt)
+
+	first := time.Now().Add(-time.Hour)
+	require.NoError(t, schedulerStorage.Pause(ctx, first))
+	require.NoError(t, schedulerStorage.Pause(ctx, time.Now()))
+
+	// Pausing again keeps the original time
+	pausedAt, err = schedulerStorage.PausedAt(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, pausedAt)
+	assert.Equal(t, first.Unix(), pausedAt.Unix())
+
+	require.NoError(t, schedulerStorage.Resume(ctx))
+	pausedAt, err = schedulerStorage.PausedAt(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, pausedAt)
+	assert.False(t, mini.Exists(schedulerPausedKey))
+}
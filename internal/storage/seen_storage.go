@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// seenMigrationKey marks the legacy seen set migration as done
+	seenMigrationKey = "migrations:seen_zset"
+
+	// legacySeenRetention is how long migrated entries, whose start times are
+	// unknown, are kept before being pruned
+	legacySeenRetention = 30 * 24 * time.Hour
+)
+
+// SeenStorage tracks which activities have already been reported for a rule,
+// along with a fingerprint of each one to detect later changes
+type SeenStorage interface {
+	GetSeen(ctx context.Context, ruleID, activityID string) (*model.ActivityFingerprint, bool, error)
+	MarkSeen(ctx context.Context, ruleID string, activity model.Activity) error
+	Prune(ctx context.Context, ruleID string, before time.Time) (int64, error)
+	CountSeen(ctx context.Context, ruleIDs []string) (map[string]int64, error)
+	DeleteSeen(ctx context.Context, ruleID string) error
+}
+
+// pruneSeenScript removes activities that started before ARGV[1] from the
+// seen sorted set and their fingerprints, returning the entries left
+var pruneSeenScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+for i = 1, #ids, 500 do
+	local batch = {unpack(ids, i, math.min(i + 499, #ids))}
+	redis.call('ZREM', KEYS[1], unpack(batch))
+	redis.call('HDEL', KEYS[2], unpack(batch))
+end
+return redis.call('ZCARD', KEYS[1])
+`)
+
+// RedisSeenStorage implements SeenStorage with one sorted set per rule,
+// scored by each activity's start time so past activities can be pruned,
+// and a hash of activity fingerprints
+type RedisSeenStorage struct {
+	redis *RedisClient
+}
+
+// NewRedisSeenStorage creates a new Redis seen storage
+func NewRedisSeenStorage(redis *RedisClient) *RedisSeenStorage {
+	return &RedisSeenStorage{
+		redis: redis,
+	}
+}
+
+// seenKey returns the seen activities key for a rule
+func seenKey(ruleID string) string {
+	return fmt.Sprintf("seen:%s", ruleID)
+}
+
+// seenFingerprintKey returns the seen activity fingerprints key for a rule
+func seenFingerprintKey(ruleID string) string {
+	return fmt.Sprintf("seenfp:%s", ruleID)
+}
+
+// GetSeen checks if an activity has already been reported for a rule and
+// returns its fingerprint. Activities seen before fingerprints were recorded
+// are reported as seen with a nil fingerprint.
+func (s *RedisSeenStorage) GetSeen(ctx context.Context, ruleID, activityID string) (*model.ActivityFingerprint, bool, error) {
+	pipe := s.redis.Client.Pipeline()
+	scoreCmd := pipe.ZScore(ctx, seenKey(ruleID), activityID)
+	fingerprintCmd := pipe.HGet(ctx, seenFingerprintKey(ruleID), activityID)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, false, fmt.Errorf("get seen: %w", err)
+	}
+
+	if scoreCmd.Err() == redis.Nil {
+		return nil, false, nil
+	}
+
+	data, err := fingerprintCmd.Result()
+	if err == redis.Nil {
+		return nil, true, nil
+	}
+
+	var fingerprint model.ActivityFingerprint
+	if err := json.Unmarshal([]byte(data), &fingerprint); err != nil {
+		return nil, true, nil // Treat unreadable fingerprints as missing
+	}
+
+	return &fingerprint, true, nil
+}
+
+// MarkSeen records an activity as reported for a rule along with its start
+// time and fingerprint
+func (s *RedisSeenStorage) MarkSeen(ctx context.Context, ruleID string, activity model.Activity) error {
+	data, err := json.Marshal(activity.Fingerprint())
+	if err != nil {
+		return fmt.Errorf("marshal fingerprint: %w", err)
+	}
+
+	pipe := s.redis.Client.Pipeline()
+	pipe.ZAdd(ctx, seenKey(ruleID), redis.Z{
+		Score:  float64(activity.StartDate.Unix()),
+		Member: activity.ID,
+	})
+	pipe.HSet(ctx, seenFingerprintKey(ruleID), activity.ID, data)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("mark seen: %w", err)
+	}
+
+	return nil
+}
+
+// Prune removes activities that started before the given time and returns
+// the number of entries left for the rule
+func (s *RedisSeenStorage) Prune(ctx context.Context, ruleID string, before time.Time) (int64, error) {
+	keys := []string{seenKey(ruleID), seenFingerprintKey(ruleID)}
+	remaining, err := pruneSeenScript.Run(ctx, s.redis.Client, keys, "("+strconv.FormatInt(before.Unix(), 10)).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("prune seen: %w", err)
+	}
+
+	return remaining, nil
+}
+
+// CountSeen returns the number of seen activities each rule tracks
+func (s *RedisSeenStorage) CountSeen(ctx context.Context, ruleIDs []string) (map[string]int64, error) {
+	if len(ruleIDs) == 0 {
+		return map[string]int64{}, nil
+	}
+
+	pipe := s.redis.Client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(ruleIDs))
+	for i, ruleID := range ruleIDs {
+		cmds[i] = pipe.ZCard(ctx, seenKey(ruleID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("count seen: %w", err)
+	}
+
+	counts := make(map[string]int64, len(ruleIDs))
+	for i, ruleID := range ruleIDs {
+		counts[ruleID] = cmds[i].Val()
+	}
+
+	return counts, nil
+}
+
+// DeleteSeen removes all seen activities for a rule
+func (s *RedisSeenStorage) DeleteSeen(ctx context.Context, ruleID string) error {
+	if err := s.redis.Client.Del(ctx, seenKey(ruleID), seenFingerprintKey(ruleID)).Err(); err != nil {
+		return fmt.Errorf("delete seen: %w", err)
+	}
+	return nil
+}
+
+// MigrateLegacySeenSets converts seen sets written by earlier versions into
+// sorted sets. Legacy entries have no start time, so they are kept for
+// legacySeenRetention from now. The migration runs once; a marker key records
+// that it has completed. It returns the number of sets converted.
+func MigrateLegacySeenSets(ctx context.Context, redisClient *RedisClient) (int, error) {
+	client := redisClient.Client
+
+	done, err := client.Exists(ctx, seenMigrationKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("check seen migration: %w", err)
+	}
+	if done > 0 {
+		return 0, nil
+	}
+
+	score := float64(time.Now().Add(legacySeenRetention).Unix())
+	migrated := 0
+
+	iter := client.Scan(ctx, 0, "seen:*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+
+		keyType, err := client.Type(ctx, key).Result()
+		if err != nil {
+			return migrated, fmt.Errorf("check seen key type: %w", err)
+		}
+		if keyType != "set" {
+			continue
+		}
+
+		members, err := client.SMembers(ctx, key).Result()
+		if err != nil {
+			return migrated, fmt.Errorf("read legacy seen set: %w", err)
+		}
+
+		entries := make([]redis.Z, 0, len(members))
+		for _, member := range members {
+			entries = append(entries, redis.Z{Score: score, Member: member})
+		}
+
+		// Replace the set atomically so a concurrent reader never sees a missing key
+		_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, key)
+			if len(entries) > 0 {
+				pipe.ZAdd(ctx, key, entries...)
+			}
+			return nil
+		})
+		if err != nil {
+			return migrated, fmt.Errorf("convert legacy seen set: %w", err)
+		}
+
+		migrated++
+	}
+	if err := iter.Err(); err != nil {
+		return migrated, fmt.Errorf("scan seen keys: %w", err)
+	}
+
+	if err := client.Set(ctx, seenMigrationKey, time.Now().Unix(), 0).Err(); err != nil {
+		return migrated, fmt.Errorf("mark seen migration: %w", err)
+	}
+
+	return migrated, nil
+}
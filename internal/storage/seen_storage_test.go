@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisSeenStorage_MarkAndGet(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	store := NewRedisSeenStorage(redisClient)
+	ctx := context.Background()
+
+	_, seen, err := store.GetSeen(ctx, "rule-1", "match-1")
+	require.NoError(t, err)
+	assert.False(t, seen)
+
+	activity := model.Activity{
+		ID:              "match-1",
+		StartDate:       time.Now().Add(24 * time.Hour),
+		AvailablePlaces: 2,
+		Price:           "8 EUR",
+		MinLevel:        2.5,
+		MaxLevel:        3.5,
+	}
+	require.NoError(t, store.MarkSeen(ctx, "rule-1", activity))
+
+	fingerprint, seen, err := store.GetSeen(ctx, "rule-1", "match-1")
+	require.NoError(t, err)
+	assert.True(t, seen)
+	require.NotNil(t, fingerprint)
+	assert.Equal(t, activity.Fingerprint(), *fingerprint)
+
+	// Seen activities are tracked per rule
+	_, seen, err = store.GetSeen(ctx, "rule-2", "match-1")
+	require.NoError(t, err)
+	assert.False(t, seen)
+
+	score, err := redisClient.Client.ZScore(ctx, "seen:rule-1", "match-1").Result()
+	require.NoError(t, err)
+	assert.Equal(t, float64(activity.StartDate.Unix()), score)
+}
+
+func TestRedisSeenStorage_Prune(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	store := NewRedisSeenStorage(redisClient)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, store.MarkSeen(ctx, "rule-1", model.Activity{ID: "past", StartDate: now.Add(-72 * time.Hour)}))
+	require.NoError(t, store.MarkSeen(ctx, "rule-1", model.Activity{ID: "recent", StartDate: now.Add(-time.Hour)}))
+	require.NoError(t, store.MarkSeen(ctx, "rule-1", model.Activity{ID: "future", StartDate: now.Add(time.Hour)}))
+
+	remaining, err := store.Prune(ctx, "rule-1", now.Add(-48*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), remaining)
+
+	_, seen, err := store.GetSeen(ctx, "rule-1", "past")
+	require.NoError(t, err)
+	assert.False(t, seen)
+	assert.Empty(t, mini.HGet("seenfp:rule-1", "past"))
+
+	_, seen, err = store.GetSeen(ctx, "rule-1", "recent")
+	require.NoError(t, err)
+	assert.True(t, seen)
+
+	counts, err := store.CountSeen(ctx, []string{"rule-1", "rule-2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"rule-1": 2, "rule-2": 0}, counts)
+
+	require.NoError(t, store.DeleteSeen(ctx, "rule-1"))
+	assert.False(t, mini.Exists("seen:rule-1"))
+	assert.False(t, mini.Exists("seenfp:rule-1"))
+}
+
+func TestMigrateLegacySeenSets(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	ctx := context.Background()
+	store := NewRedisSeenStorage(redisClient)
+
+	_, err := mini.SAdd("seen:rule-1", "match-1", "match-2")
+	require.NoError(t, err)
+	_, err = mini.SAdd("seen:rule-2", "class-1")
+	require.NoError(t, err)
+	require.NoError(t, store.MarkSeen(ctx, "rule-3", model.Activity{ID: "lesson-1", StartDate: time.Now()}))
+
+	migrated, err := MigrateLegacySeenSets(ctx, redisClient)
+	require.NoError(t, err)
+	assert.Equal(t, 2, migrated)
+
+	for _, id := range []string{"match-1", "match-2"} {
+		fingerprint, seen, err := store.GetSeen(ctx, "rule-1", id)
+		require.NoError(t, err)
+		assert.True(t, seen, id)
+		assert.Nil(t, fingerprint, id)
+	}
+
+	// Migrated entries survive a prune at the current time
+	remaining, err := store.Prune(ctx, "rule-2", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), remaining)
+
+	// Existing sorted sets are left alone
+	_, seen, err := store.GetSeen(ctx, "rule-3", "lesson-1")
+	require.NoError(t, err)
+	assert.True(t, seen)
+
+	// The migration only runs once
+	_, err = mini.SAdd("seen:rule-4", "match-9")
+	require.NoError(t, err)
+
+	migrated, err = MigrateLegacySeenSets(ctx, redisClient)
+	require.NoError(t, err)
+	assert.Equal(t, 0, migrated)
+	assert.True(t, mini.Exists(seenMigrationKey))
+}
@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/util"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrTokenNotFound is returned when a token does not exist or has been revoked
+var ErrTokenNotFound = errors.New("token not found")
+
+// tokenSecretPrefix marks token secrets so they are easy to recognise, for
+// example by secret scanners
+const tokenSecretPrefix = "pat_"
+
+// tokenPrefixLength is how much of a secret is kept to tell tokens apart
+const tokenPrefixLength = len(tokenSecretPrefix) + 8
+
+// TokenStorage defines operations for per-user API tokens
+type TokenStorage interface {
+	CreateToken(ctx context.Context, userID, name string) (*model.APIToken, string, error)
+	Authenticate(ctx context.Context, secret string) (*model.APIToken, error)
+	ListTokens(ctx context.Context, userID string) ([]*model.APIToken, error)
+	RevokeToken(ctx context.Context, userID, tokenID string) error
+	RevokeUserTokens(ctx context.Context, userID string) error
+}
+
+// storedToken is a token as kept in Redis, along with the hash of its secret
+type storedToken struct {
+	model.APIToken
+	Hash string `json:"hash"`
+}
+
+// RedisTokenStorage implements TokenStorage using Redis
+type RedisTokenStorage struct {
+	redis *RedisClient
+}
+
+// NewRedisTokenStorage creates a new Redis token storage
+func NewRedisTokenStorage(redis *RedisClient) *RedisTokenStorage {
+	return &RedisTokenStorage{redis: redis}
+}
+
+// tokenKey returns the key holding a token
+func tokenKey(tokenID string) string {
+	return fmt.Sprintf("token:%s", tokenID)
+}
+
+// tokenHashKey returns the key mapping a secret's hash to its token
+func tokenHashKey(hash string) string {
+	return fmt.Sprintf("tokenhash:%s", hash)
+}
+
+// userTokensKey returns the key holding a user's token IDs
+func userTokensKey(userID string) string {
+	return fmt.Sprintf("tokens:user:%s", userID)
+}
+
+// hashTokenSecret hashes a token secret for storage. Secrets are long and
+// random, so a plain SHA-256 is enough to make a leaked hash useless.
+func hashTokenSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateToken creates a token for a user, returning it with its secret. The
+// secret is not stored and cannot be retrieved again.
+func (s *RedisTokenStorage) CreateToken(ctx context.Context, userID, name string) (*model.APIToken, string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, "", fmt.Errorf("generate token secret: %w", err)
+	}
+	secret := tokenSecretPrefix + hex.EncodeToString(random)
+
+	token := storedToken{
+		APIToken: model.APIToken{
+			ID:        util.GenerateID(),
+			UserID:    userID,
+			Name:      name,
+			Prefix:    secret[:tokenPrefixLength],
+			CreatedAt: time.Now(),
+		},
+		Hash: hashTokenSecret(secret),
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return nil, "", fmt.Errorf("marshal token: %w", err)
+	}
+
+	_, err = s.redis.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, tokenKey(token.ID), data, 0)
+		pipe.Set(ctx, tokenHashKey(token.Hash), token.ID, 0)
+		pipe.SAdd(ctx, userTokensKey(userID), token.ID)
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("create token: %w", err)
+	}
+
+	return &token.APIToken, secret, nil
+}
+
+// Authenticate returns the token a secret belongs to
+func (s *RedisTokenStorage) Authenticate(ctx context.Context, secret string) (*model.APIToken, error) {
+	tokenID, err := s.redis.Client.Get(ctx, tokenHashKey(hashTokenSecret(secret))).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("authenticate token: %w", err)
+	}
+
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token.APIToken, nil
+}
+
+// ListTokens lists a user's tokens, oldest first
+func (s *RedisTokenStorage) ListTokens(ctx context.Context, userID string) ([]*model.APIToken, error) {
+	tokenIDs, err := s.redis.Client.SMembers(ctx, userTokensKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("list user tokens: %w", err)
+	}
+
+	tokens := make([]*model.APIToken, 0, len(tokenIDs))
+	for _, id := range tokenIDs {
+		token, err := s.getToken(ctx, id)
+		if err != nil {
+			continue // Skip tokens that can't be loaded
+		}
+		tokens = append(tokens, &token.APIToken)
+	}
+
+	slices.SortFunc(tokens, func(a, b *model.APIToken) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return tokens, nil
+}
+
+// RevokeToken deletes one of a user's tokens
+func (s *RedisTokenStorage) RevokeToken(ctx context.Context, userID, tokenID string) error {
+	token, err := s.getToken(ctx, tokenID)
+	if err != nil {
+		return err
+	}
+
+	if token.UserID != userID {
+		return ErrTokenNotFound
+	}
+
+	_, err = s.redis.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, tokenKey(tokenID), tokenHashKey(token.Hash))
+		pipe.SRem(ctx, userTokensKey(userID), tokenID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("revoke token: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeUserTokens deletes all of a user's tokens
+func (s *RedisTokenStorage) RevokeUserTokens(ctx context.Context, userID string) error {
+	tokenIDs, err := s.redis.Client.SMembers(ctx, userTokensKey(userID)).Result()
+	if err != nil {
+		return fmt.Errorf("list user tokens: %w", err)
+	}
+
+	for _, id := range tokenIDs {
+		if err := s.RevokeToken(ctx, userID, id); err != nil && !errors.Is(err, ErrTokenNotFound) {
+			return err
+		}
+	}
+
+	if err := s.redis.Client.Del(ctx, userTokensKey(userID)).Err(); err != nil {
+		return fmt.Errorf("revoke user tokens: %w", err)
+	}
+
+	return nil
+}
+
+// getToken loads a stored token by ID
+func (s *RedisTokenStorage) getToken(ctx context.Context, tokenID string) (*storedToken, error) {
+	data, err := s.redis.Client.Get(ctx, tokenKey(tokenID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get token: %w", err)
+	}
+
+	var token storedToken
+	if err := json.Unmarshal([]byte(data), &token); err != nil {
+		return nil, fmt.Errorf("unmarshal token: %w", err)
+	}
+
+	return &token, nil
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisTokenStorage_CreateAndAuthenticate(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	tokenStorage := NewRedisTokenStorage(redisClient)
+	ctx := context.Background()
+
+	token, secret, err := tokenStorage.CreateToken(ctx, "user-1", "laptop")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(secret, tokenSecretPrefix))
+	assert.Equal(t, secret[:tokenPrefixLength], token.Prefix)
+	assert.Equal(t, "user-1", token.UserID)
+	assert.Equal(t, "laptop", token.Name)
+
+	// Only the hash of the secret is stored
+	for _, key := range mini.Keys() {
+		value, err := mini.Get(key)
+		if err == nil {
+			assert.NotContains(t, value, secret)
+		}
+	}
+	assert.True(t, mini.Exists(tokenHashKey(hashTokenSecret(secret))))
+
+	authenticated, err := tokenStorage.Authenticate(ctx, secret)
+	require.NoError(t, err)
+	assert.Equal(t, token.ID, authenticated.ID)
+	assert.Equal(t, "user-1", authenticated.UserID)
+
+	_, err = tokenStorage.Authenticate(ctx, secret+"x")
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+}
+
+func TestRedisTokenStorage_ListAndRevoke(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	tokenStorage := NewRedisTokenStorage(redisClient)
+	ctx := context.Background()
+
+	first, firstSecret, err := tokenStorage.CreateToken(ctx, "user-1", "laptop")
+	require.NoError(t, err)
+	second, _, err := tokenStorage.CreateToken(ctx, "user-1", "phone")
+	require.NoError(t, err)
+	other, _, err := tokenStorage.CreateToken(ctx, "user-2", "laptop")
+	require.NoError(t, err)
+
+	tokens, err := tokenStorage.ListTokens(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	assert.ElementsMatch(t, []string{first.ID, second.ID}, []string{tokens[0].ID, tokens[1].ID})
+
+	// Users can't revoke each other's tokens
+	assert.ErrorIs(t, tokenStorage.RevokeToken(ctx, "user-1", other.ID), ErrTokenNotFound)
+
+	require.NoError(t, tokenStorage.RevokeToken(ctx, "user-1", first.ID))
+
+	_, err = tokenStorage.Authenticate(ctx, firstSecret)
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+	assert.ErrorIs(t, tokenStorage.RevokeToken(ctx, "user-1", first.ID), ErrTokenNotFound)
+
+	tokens, err = tokenStorage.ListTokens(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, second.ID, tokens[0].ID)
+
+	require.NoError(t, tokenStorage.RevokeUserTokens(ctx, "user-1"))
+
+	tokens, err = tokenStorage.ListTokens(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+	assert.False(t, mini.Exists(userTokensKey("user-1")))
+
+	tokens, err = tokenStorage.ListTokens(ctx, "user-2")
+	require.NoError(t, err)
+	assert.Len(t, tokens, 1)
+}
@@ -3,18 +3,24 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/redis/go-redis/v9"
 )
 
+// ErrUserNotFound is returned when a user account does not exist
+var ErrUserNotFound = errors.New("user not found")
+
 // UserStorage defines operations for user persistence
 type UserStorage interface {
 	GetUser(ctx context.Context, userID string) (*model.User, error)
 	CreateUser(ctx context.Context, user *model.User) error
 	UpdateUser(ctx context.Context, user *model.User) error
 	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	DeleteUser(ctx context.Context, userID string) error
 }
 
 // RedisUserStorage implements UserStorage using Redis
@@ -31,6 +37,9 @@ func NewRedisUserStorage(redis *RedisClient) *RedisUserStorage {
 func (s *RedisUserStorage) GetUser(ctx context.Context, userID string) (*model.User, error) {
 	key := fmt.Sprintf("user:%s", userID)
 	data, err := s.redis.Client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrUserNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("get user: %w", err)
 	}
@@ -81,7 +90,7 @@ func (s *RedisUserStorage) UpdateUser(ctx context.Context, user *model.User) err
 		return fmt.Errorf("check user existence: %w", err)
 	}
 	if exists == 0 {
-		return fmt.Errorf("user not found: %s", user.ID)
+		return fmt.Errorf("%w: %s", ErrUserNotFound, user.ID)
 	}
 
 	// Get the current user data to check if email changed
@@ -124,6 +133,9 @@ func (s *RedisUserStorage) GetUserByEmail(ctx context.Context, email string) (*m
 	// Get user ID from email index
 	emailKey := fmt.Sprintf("user:email:%s", email)
 	userID, err := s.redis.Client.Get(ctx, emailKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrUserNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("get user by email: %w", err)
 	}
@@ -131,3 +143,45 @@ func (s *RedisUserStorage) GetUserByEmail(ctx context.Context, email string) (*m
 	// Get user from ID
 	return s.GetUser(ctx, userID)
 }
+
+// DeleteUser deletes a user along with their rules, the rules' seen
+// activities and history, any pending digest and their API tokens
+func (s *RedisUserStorage) DeleteUser(ctx context.Context, userID string) error {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	userRulesKey := fmt.Sprintf("rules:user:%s", userID)
+	ruleIDs, err := s.redis.Client.SMembers(ctx, userRulesKey).Result()
+	if err != nil {
+		return fmt.Errorf("list user rules: %w", err)
+	}
+
+	// Each rule is deleted the same way as through the rules API, which
+	// clears its schedule, seen sets, history and outbox
+	rules := NewRedisRuleStorage(s.redis)
+	for _, ruleID := range ruleIDs {
+		if err := rules.DeleteRule(ctx, ruleID); err != nil && !errors.Is(err, redis.Nil) {
+			return fmt.Errorf("delete user rule %s: %w", ruleID, err)
+		}
+	}
+
+	if err := NewRedisDigestStorage(s.redis).DeleteDigest(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := NewRedisTokenStorage(s.redis).RevokeUserTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	pipe := s.redis.Client.Pipeline()
+	pipe.Del(ctx, fmt.Sprintf("user:%s", userID))
+	pipe.Del(ctx, fmt.Sprintf("user:email:%s", user.Email))
+	pipe.Del(ctx, userRulesKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+
+	return nil
+}
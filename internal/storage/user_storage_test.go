@@ -7,6 +7,7 @@ import (
 
 	"github.com/rafa-garcia/padel-alert/internal/domain/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRedisUserStorage_CreateUser(t *testing.T) {
@@ -119,5 +120,80 @@ func TestRedisUserStorage_GetUserByEmail(t *testing.T) {
 	assert.Equal(t, user.Email, fetchedUser.Email)
 
 	_, err = userStorage.GetUserByEmail(ctx, "nonexistent@example.com")
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestRedisUserStorage_GetUser_NotFound(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	userStorage := NewRedisUserStorage(redisClient)
+
+	_, err := userStorage.GetUser(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	err = userStorage.UpdateUser(context.Background(), &model.User{ID: "missing"})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestRedisUserStorage_DeleteUser(t *testing.T) {
+	mini, redisClient := setupTestRedis(t)
+	defer mini.Close()
+
+	userStorage := NewRedisUserStorage(redisClient)
+	ruleStorage := NewRedisRuleStorage(redisClient)
+	seenStorage := NewRedisSeenStorage(redisClient)
+	digestStorage := NewRedisDigestStorage(redisClient)
+	ctx := context.Background()
+
+	require.NoError(t, userStorage.CreateUser(ctx, &model.User{ID: "user-1", Name: "Ana", Email: "ana@example.com"}))
+	require.NoError(t, userStorage.CreateUser(ctx, &model.User{ID: "user-2", Name: "Ben", Email: "ben@example.com"}))
+
+	for _, rule := range []*model.Rule{
+		{ID: "rule-1", UserID: "user-1", Type: "match"},
+		{ID: "rule-2", UserID: "user-1", Type: "class"},
+		{ID: "rule-3", UserID: "user-2", Type: "match"},
+	} {
+		require.NoError(t, ruleStorage.CreateRule(ctx, rule))
+		require.NoError(t, ruleStorage.ScheduleRule(ctx, rule.ID, time.Now()))
+		require.NoError(t, seenStorage.MarkSeen(ctx, rule.ID, model.Activity{ID: "activity-1", StartDate: time.Now().Add(time.Hour)}))
+	}
+
+	require.NoError(t, digestStorage.AddDigestEntries(ctx, "user-1", []model.DigestEntry{
+		{RuleID: "rule-1", Activity: model.Activity{ID: "activity-1"}, DueAt: time.Now().Add(time.Hour)},
+	}))
+
+	_, secret, err := NewRedisTokenStorage(redisClient).CreateToken(ctx, "user-1", "cli")
+	require.NoError(t, err)
+
+	require.NoError(t, userStorage.DeleteUser(ctx, "user-1"))
+
+	_, err = NewRedisTokenStorage(redisClient).Authenticate(ctx, secret)
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+
+	_, err = userStorage.GetUser(ctx, "user-1")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	assert.False(t, mini.Exists("user:email:ana@example.com"))
+	assert.False(t, mini.Exists("rules:user:user-1"))
+
+	for _, ruleID := range []string{"rule-1", "rule-2"} {
+		assert.False(t, mini.Exists("rule:"+ruleID))
+		assert.False(t, mini.Exists(seenKey(ruleID)))
+		assert.False(t, mini.Exists(seenFingerprintKey(ruleID)))
+	}
+	scheduled, err := mini.ZMembers("rules:schedule")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rule-3"}, scheduled)
+
+	assert.False(t, mini.Exists(digestKey("user-1")))
+	assert.False(t, mini.Exists(digestDueKey("user-1")))
+
+	// Other users are untouched
+	other, err := userStorage.GetUser(ctx, "user-2")
+	require.NoError(t, err)
+	assert.Equal(t, "ben@example.com", other.Email)
+	assert.True(t, mini.Exists("rule:rule-3"))
+	assert.True(t, mini.Exists(seenKey("rule-3")))
+
+	assert.ErrorIs(t, userStorage.DeleteUser(ctx, "user-1"), ErrUserNotFound)
 }
@@ -7,15 +7,20 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
-// MockEmailNotifier mocks the email notifier interface
-type MockEmailNotifier struct {
+// MockNotifier mocks the notifier interface for a single channel
+type MockNotifier struct {
 	mock.Mock
 }
 
-// NotifyNewActivities mocks the email notification method
-func (m *MockEmailNotifier) NotifyNewActivities(ctx context.Context, user *model.User, rule *model.Rule, activities []model.Activity) error {
+// NotifyNewActivities mocks the notification method
+func (m *MockNotifier) NotifyNewActivities(ctx context.Context, user *model.User, rule *model.Rule, activities []model.Activity) error {
 	if len(activities) > 0 && rule != nil {
 		return m.Called(ctx, user, rule, activities).Error(0)
 	}
 	return m.Called(ctx, user, rule, activities).Error(0)
 }
+
+// NotifyDigest mocks the digest notification method
+func (m *MockNotifier) NotifyDigest(ctx context.Context, user *model.User, digest *model.Digest) error {
+	return m.Called(ctx, user, digest).Error(0)
+}
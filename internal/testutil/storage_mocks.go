@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/rafa-garcia/padel-alert/internal/storage"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -40,6 +41,12 @@ func (m *MockRuleStorage) UpdateRule(ctx context.Context, rule *model.Rule) erro
 	return args.Error(0)
 }
 
+// UpdateRuleState mocks recording a rule's runtime state
+func (m *MockRuleStorage) UpdateRuleState(ctx context.Context, ruleID string, state model.RuleState) error {
+	args := m.Called(ctx, ruleID, state)
+	return args.Error(0)
+}
+
 // DeleteRule mocks deleting a rule from storage by ID
 func (m *MockRuleStorage) DeleteRule(ctx context.Context, ruleID string) error {
 	args := m.Called(ctx, ruleID)
@@ -58,6 +65,51 @@ func (m *MockRuleStorage) GetScheduledRules(ctx context.Context, until time.Time
 	return args.Get(0).([]string), args.Error(1)
 }
 
+// ClaimScheduledRules mocks atomically claiming due rules for a scheduler
+func (m *MockRuleStorage) ClaimScheduledRules(ctx context.Context, now time.Time, owner string, lease time.Duration, limit int) ([]storage.ScheduledRule, error) {
+	args := m.Called(ctx, now, owner, lease, limit)
+	return args.Get(0).([]storage.ScheduledRule), args.Error(1)
+}
+
+// CompleteScheduledRule mocks rescheduling a claimed rule and releasing its lease
+func (m *MockRuleStorage) CompleteScheduledRule(ctx context.Context, ruleID, owner string, nextRun time.Time) (bool, error) {
+	args := m.Called(ctx, ruleID, owner, nextRun)
+	return args.Bool(0), args.Error(1)
+}
+
+// OldestScheduledRule mocks getting the rule that has been due the longest
+func (m *MockRuleStorage) OldestScheduledRule(ctx context.Context) (*storage.ScheduledRule, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*storage.ScheduledRule), args.Error(1)
+}
+
+// ClaimRule mocks claiming a single rule
+func (m *MockRuleStorage) ClaimRule(ctx context.Context, ruleID, owner string, lease time.Duration) error {
+	args := m.Called(ctx, ruleID, owner, lease)
+	return args.Error(0)
+}
+
+// RenewRuleLease mocks extending a rule's lease
+func (m *MockRuleStorage) RenewRuleLease(ctx context.Context, ruleID, owner string, lease time.Duration) (bool, error) {
+	args := m.Called(ctx, ruleID, owner, lease)
+	return args.Bool(0), args.Error(1)
+}
+
+// ListAllRules mocks listing the rules of every user
+func (m *MockRuleStorage) ListAllRules(ctx context.Context) ([]*model.Rule, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*model.Rule), args.Error(1)
+}
+
+// ListScheduledRules mocks listing a page of the schedule
+func (m *MockRuleStorage) ListScheduledRules(ctx context.Context, offset, limit int) ([]storage.ScheduledRule, int64, error) {
+	args := m.Called(ctx, offset, limit)
+	return args.Get(0).([]storage.ScheduledRule), args.Get(1).(int64), args.Error(2)
+}
+
 // MockRedisClient implements a mock Redis client for testing
 type MockRedisClient struct {
 	mock.Mock
@@ -81,3 +133,70 @@ type StringCmdResult interface {
 	Bool() (bool, error)
 	Int64() (int64, error)
 }
+
+// MockNotificationStorage is a mock of NotificationStorage interface
+type MockNotificationStorage struct {
+	mock.Mock
+}
+
+// AddNotification mocks recording a notification attempt
+func (m *MockNotificationStorage) AddNotification(ctx context.Context, notification *model.Notification) error {
+	args := m.Called(ctx, notification)
+	return args.Error(0)
+}
+
+// ListNotifications mocks listing a page of a rule's notifications
+func (m *MockNotificationStorage) ListNotifications(ctx context.Context, ruleID string, offset, limit int) ([]*model.Notification, int64, error) {
+	args := m.Called(ctx, ruleID, offset, limit)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*model.Notification), args.Get(1).(int64), args.Error(2)
+}
+
+// DeleteNotifications mocks deleting a rule's notification history
+func (m *MockNotificationStorage) DeleteNotifications(ctx context.Context, ruleID string) error {
+	args := m.Called(ctx, ruleID)
+	return args.Error(0)
+}
+
+// MockUserStorage is a mock of UserStorage interface
+type MockUserStorage struct {
+	mock.Mock
+}
+
+// GetUser mocks getting a user by ID
+func (m *MockUserStorage) GetUser(ctx context.Context, userID string) (*model.User, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+// CreateUser mocks storing a new user
+func (m *MockUserStorage) CreateUser(ctx context.Context, user *model.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+// UpdateUser mocks updating an existing user
+func (m *MockUserStorage) UpdateUser(ctx context.Context, user *model.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+// GetUserByEmail mocks getting a user by email
+func (m *MockUserStorage) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+// DeleteUser mocks deleting a user and everything they own
+func (m *MockUserStorage) DeleteUser(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
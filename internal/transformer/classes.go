@@ -40,6 +40,7 @@ func ExternalClassToActivity(class models.Class) (model.Activity, error) {
 			PostalCode: class.Tenant.Address.PostalCode,
 			City:       class.Tenant.Address.City,
 			Country:    class.Tenant.Address.Country,
+			Timezone:   class.Tenant.Address.Timezone,
 		},
 		Link: fmt.Sprintf("https://app.playtomic.io/tenant/%s", class.Tenant.TenantID),
 	}
@@ -47,6 +47,7 @@ func ExternalLessonToActivity(lesson models.Lesson) (model.Activity, error) {
 			PostalCode: lesson.Tenant.TenantAddress.PostalCode,
 			City:       lesson.Tenant.TenantAddress.City,
 			Country:    lesson.Tenant.TenantAddress.Country,
+			Timezone:   lesson.Tenant.TenantAddress.Timezone,
 		},
 		Link: fmt.Sprintf("https://app.playtomic.io/tenant/%s", lesson.Tenant.TenantID),
 	}
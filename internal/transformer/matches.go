@@ -48,6 +48,7 @@ func ExternalMatchToActivity(match models.Match) (model.Activity, error) {
 			PostalCode: match.Tenant.Address.PostalCode,
 			City:       match.Tenant.Address.City,
 			Country:    match.Tenant.Address.Country,
+			Timezone:   match.Tenant.Address.Timezone,
 		},
 		Link: fmt.Sprintf("https://app.playtomic.io/tenant/%s", match.Tenant.TenantID),
 	}
@@ -0,0 +1,55 @@
+package validation
+
+import (
+	"net"
+	"strings"
+)
+
+// nonPublicNetworks are ranges the net package doesn't classify that must not
+// be reached from inside the deployment
+var nonPublicNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),     // "This" network
+	mustParseCIDR("100.64.0.0/10"), // Carrier-grade NAT
+}
+
+// IsPublicIP checks that an address is not loopback, private, link-local,
+// multicast or unspecified, so the server can safely connect to it on a
+// user's behalf
+func IsPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsPublicHost checks that a URL host is not obviously local: localhost or an
+// address IsPublicIP rejects. Names are only resolved when connecting, so
+// this is a first line of defence rather than a guarantee.
+func IsPublicHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return IsPublicIP(ip)
+	}
+	return true
+}
+
+// mustParseCIDR parses a CIDR range known to be valid
+func mustParseCIDR(value string) *net.IPNet {
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
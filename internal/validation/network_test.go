@@ -0,0 +1,37 @@
+package validation
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	for _, address := range []string{"93.184.216.34", "2606:4700::1111"} {
+		assert.True(t, IsPublicIP(net.ParseIP(address)), address)
+	}
+
+	for _, address := range []string{
+		"127.0.0.1", "::1", // Loopback
+		"10.1.2.3", "172.16.0.1", "192.168.1.10", "fd00::1", // Private
+		"169.254.169.254", "fe80::1", // Link-local, including cloud metadata
+		"0.0.0.0", "::", "0.1.2.3", // Unspecified
+		"100.64.0.1",       // Carrier-grade NAT
+		"224.0.0.1",        // Multicast
+		"::ffff:127.0.0.1", // IPv4-mapped loopback
+	} {
+		assert.False(t, IsPublicIP(net.ParseIP(address)), address)
+	}
+}
+
+func TestIsPublicHost(t *testing.T) {
+	assert.True(t, IsPublicHost("hooks.example.com"))
+	assert.True(t, IsPublicHost("93.184.216.34"))
+
+	assert.False(t, IsPublicHost("localhost"))
+	assert.False(t, IsPublicHost("LOCALHOST."))
+	assert.False(t, IsPublicHost("redis.localhost"))
+	assert.False(t, IsPublicHost("10.0.0.5"))
+	assert.False(t, IsPublicHost("::1"))
+}
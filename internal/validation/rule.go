@@ -0,0 +1,259 @@
+package validation
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+)
+
+// Limits on rule fields
+const (
+	MaxNameLength          = 100
+	MaxTitleContainsLength = 100
+	MinLevel               = 0.0 // Playtomic levels run from 0 to 7
+	MaxLevel               = 7.0
+	MaxSnooze              = 365 * 24 * time.Hour
+)
+
+// ValidateRule checks every field of a rule, returning all the errors found,
+// or nil if the rule is valid. Day names are normalized in place, so a valid
+// rule is ready to store.
+func ValidateRule(rule *model.Rule) Errors {
+	var errs Errors
+
+	validateIdentity(&errs, rule)
+	validateClubs(&errs, rule.ClubIDs)
+	validateLevels(&errs, rule)
+	validateDates(&errs, rule)
+	validateSchedule(&errs, rule)
+	validateText(&errs, rule)
+	validateChannels(&errs, rule)
+	validateReAlertOn(&errs, rule.ReAlertOn)
+	validateCheckSchedule(&errs, rule)
+	validateDigest(&errs, rule)
+
+	if rule.ConsecutiveFailures < 0 {
+		errs.Add("consecutive_failures", CodeOutOfRange, "must not be negative")
+	}
+
+	return errs
+}
+
+// validateIdentity checks the rule's ID, type, name and owner
+func validateIdentity(errs *Errors, rule *model.Rule) {
+	if rule.ID == "" {
+		errs.Add("id", CodeRequired, "is required")
+	}
+
+	switch {
+	case rule.Type == "":
+		errs.Add("rule_type", CodeRequired, "is required")
+	case !slices.Contains(model.RuleTypes, rule.Type):
+		errs.Add("rule_type", CodeUnsupported, "must be one of "+strings.Join(model.RuleTypes, ", "))
+	}
+
+	switch name := strings.TrimSpace(rule.Name); {
+	case name == "":
+		errs.Add("name", CodeRequired, "is required")
+	case len([]rune(name)) > MaxNameLength:
+		errs.Add("name", CodeTooLong, fmt.Sprintf("must be at most %d characters", MaxNameLength))
+	}
+
+	if rule.UserID == "" {
+		errs.Add("user_id", CodeRequired, "is required")
+	}
+
+	// Contact details kept on rules created before user accounts
+	if rule.Email != "" && !IsEmail(rule.Email) {
+		errs.Add("email", CodeInvalid, "must be a valid email address")
+	}
+}
+
+// validateClubs checks that the rule watches at least one club and that the
+// club IDs are neither blank nor repeated
+func validateClubs(errs *Errors, clubIDs []string) {
+	if len(clubIDs) == 0 {
+		errs.Add("club_ids", CodeRequired, "must list at least one club")
+		return
+	}
+
+	seen := make(map[string]bool, len(clubIDs))
+	for i, clubID := range clubIDs {
+		switch {
+		case strings.TrimSpace(clubID) == "":
+			errs.Add(indexed("club_ids", i), CodeRequired, "must not be empty")
+		case seen[clubID]:
+			errs.Add(indexed("club_ids", i), CodeDuplicate, fmt.Sprintf("club %q is listed more than once", clubID))
+		}
+		seen[clubID] = true
+	}
+}
+
+// validateLevels checks the ranking bounds
+func validateLevels(errs *Errors, rule *model.Rule) {
+	checkLevel(errs, "min_ranking", rule.MinRanking)
+	checkLevel(errs, "max_ranking", rule.MaxRanking)
+
+	if rule.MinRanking != nil && rule.MaxRanking != nil && *rule.MaxRanking < *rule.MinRanking {
+		errs.Add("max_ranking", CodeInvalidRange, "must not be less than min_ranking")
+	}
+}
+
+// checkLevel checks that a level is within Playtomic's scale
+func checkLevel(errs *Errors, field string, level *float64) {
+	if level != nil && (*level < MinLevel || *level > MaxLevel) {
+		errs.Add(field, CodeOutOfRange, fmt.Sprintf("must be between %g and %g", MinLevel, MaxLevel))
+	}
+}
+
+// validateDates checks that the date range does not end before it starts
+func validateDates(errs *Errors, rule *model.Rule) {
+	if rule.StartDate != nil && rule.EndDate != nil && rule.EndDate.Before(*rule.StartDate) {
+		errs.Add("end_date", CodeInvalidRange, "must not be before start_date")
+	}
+}
+
+// validateSchedule checks the day of week and time of day filters,
+// normalizing days to lowercase full names
+func validateSchedule(errs *Errors, rule *model.Rule) {
+	days := make([]string, 0, len(rule.DaysOfWeek))
+	valid := true
+	for i, name := range rule.DaysOfWeek {
+		day, err := model.ParseWeekday(name)
+		if err != nil {
+			errs.Add(indexed("days_of_week", i), CodeInvalid, fmt.Sprintf("%q is not a day of the week", name))
+			valid = false
+			continue
+		}
+		days = append(days, strings.ToLower(day.String()))
+	}
+	if valid && len(days) > 0 {
+		rule.DaysOfWeek = days
+	}
+
+	for i, value := range rule.TimeOfDay {
+		if _, err := model.ParseTimeOfDay(value); err != nil {
+			errs.Add(indexed("time_of_day", i), CodeInvalid, fmt.Sprintf("%q: use HH:MM-HH:MM or morning, afternoon, evening, night", value))
+		}
+	}
+}
+
+// validateText checks the title filter and class types
+func validateText(errs *Errors, rule *model.Rule) {
+	if rule.TitleContains != nil {
+		switch title := strings.TrimSpace(*rule.TitleContains); {
+		case title == "":
+			errs.Add("title_contains", CodeInvalid, "must not be blank, leave it out to match any title")
+		case len([]rune(title)) > MaxTitleContainsLength:
+			errs.Add("title_contains", CodeTooLong, fmt.Sprintf("must be at most %d characters", MaxTitleContainsLength))
+		}
+	}
+
+	if len(rule.ClassTypes) > 0 && !rule.IsClass() {
+		errs.Add("class_types", CodeNotAllowed, "only applies to class rules")
+	}
+	for i, classType := range rule.ClassTypes {
+		if strings.TrimSpace(classType) == "" {
+			errs.Add(indexed("class_types", i), CodeRequired, "must not be empty")
+		}
+	}
+}
+
+// validateChannels checks that every channel is supported and has the
+// settings it needs
+func validateChannels(errs *Errors, rule *model.Rule) {
+	seen := make(map[string]bool, len(rule.Channels))
+	for i, channel := range rule.Channels {
+		field := indexed("channels", i)
+		if !model.IsValidChannel(channel) {
+			errs.Add(field, CodeUnsupported, fmt.Sprintf("unknown channel %q, must be one of %s", channel, strings.Join(model.Channels, ", ")))
+			continue
+		}
+		if seen[channel] {
+			errs.Add(field, CodeDuplicate, fmt.Sprintf("channel %q is listed more than once", channel))
+			continue
+		}
+		seen[channel] = true
+
+		switch {
+		case channel == model.ChannelTelegram && rule.TelegramID == "":
+			errs.Add("telegram_id", CodeRequired, "is required for the telegram channel")
+		case channel == model.ChannelWebhook && rule.WebhookURL == "":
+			errs.Add("webhook_url", CodeRequired, "is required for the webhook channel")
+		case channel == model.ChannelPush && rule.PushTopic == "":
+			errs.Add("push_topic", CodeRequired, "is required for the push channel")
+		}
+	}
+
+	if rule.WebhookURL != "" {
+		parsed, err := url.Parse(rule.WebhookURL)
+		switch {
+		case err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "":
+			errs.Add("webhook_url", CodeInvalid, "must be an http or https URL")
+		case !IsPublicHost(parsed.Hostname()):
+			errs.Add("webhook_url", CodeNotAllowed, "must not point to a local or private address")
+		}
+	}
+}
+
+// validateReAlertOn checks that every re-alert change type is supported
+func validateReAlertOn(errs *Errors, changeTypes []string) {
+	for i, changeType := range changeTypes {
+		if !model.IsValidChangeType(changeType) {
+			errs.Add(indexed("re_alert_on", i), CodeUnsupported, fmt.Sprintf("unknown change type %q, must be one of %s", changeType, strings.Join(model.ChangeTypes, ", ")))
+		}
+	}
+}
+
+// validateCheckSchedule checks the rule's own check interval and quiet hours
+func validateCheckSchedule(errs *Errors, rule *model.Rule) {
+	interval := rule.CheckIntervalSeconds
+	if interval != 0 && (interval < model.MinCheckIntervalSeconds || interval > model.MaxCheckIntervalSeconds) {
+		errs.Add("check_interval_seconds", CodeOutOfRange, fmt.Sprintf("must be between %d and %d", model.MinCheckIntervalSeconds, model.MaxCheckIntervalSeconds))
+	}
+
+	if rule.QuietHours != nil {
+		if err := rule.QuietHours.Validate(); err != nil {
+			errs.Add("quiet_hours", CodeInvalid, err.Error())
+		}
+	}
+}
+
+// validateDigest checks the digest schedule. Digests are delivered by email,
+// so digest rules must notify through the email channel.
+func validateDigest(errs *Errors, rule *model.Rule) {
+	if rule.Digest == nil {
+		return
+	}
+
+	if err := rule.Digest.Validate(); err != nil {
+		errs.Add("digest", CodeInvalid, err.Error())
+		return
+	}
+
+	if rule.IsDigest() && !slices.Contains(rule.NotificationChannels(), model.ChannelEmail) {
+		errs.Add("digest", CodeInvalid, "digests are sent by email, add email to channels")
+	}
+}
+
+// ValidateSnooze checks when a snooze ends, which must be in the future and no
+// more than MaxSnooze away. Past snoozes are left on stored rules, so this is
+// checked only when a snooze is set rather than by ValidateRule.
+func ValidateSnooze(field string, until, now time.Time) Errors {
+	var errs Errors
+
+	switch {
+	case until.IsZero():
+		errs.Add(field, CodeRequired, "is required")
+	case !until.After(now):
+		errs.Add(field, CodeOutOfRange, "must be in the future")
+	case until.Sub(now) > MaxSnooze:
+		errs.Add(field, CodeOutOfRange, "must be at most a year away")
+	}
+
+	return errs
+}
@@ -0,0 +1,220 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rafa-garcia/padel-alert/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func validRule() *model.Rule {
+	return &model.Rule{
+		ID:      "rule-1",
+		UserID:  "user-1",
+		Type:    "match",
+		Name:    "Evening matches",
+		ClubIDs: []string{"club-1", "club-2"},
+	}
+}
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func TestValidateRule_Valid(t *testing.T) {
+	assert.Empty(t, ValidateRule(validRule()))
+}
+
+func TestValidateRule_NormalizesDays(t *testing.T) {
+	rule := validRule()
+	rule.DaysOfWeek = []string{"Mon", "SATURDAY"}
+
+	assert.Empty(t, ValidateRule(rule))
+	assert.Equal(t, []string{"monday", "saturday"}, rule.DaysOfWeek)
+}
+
+func TestValidateRule_Invalid(t *testing.T) {
+	start := time.Date(2030, 6, 10, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, -1)
+	blank := "  "
+
+	tests := []struct {
+		name     string
+		modify   func(rule *model.Rule)
+		expected []FieldError
+	}{
+		{
+			name: "Missing identity",
+			modify: func(rule *model.Rule) {
+				rule.Type = ""
+				rule.Name = " "
+				rule.UserID = ""
+			},
+			expected: []FieldError{
+				{Field: "rule_type", Code: CodeRequired},
+				{Field: "name", Code: CodeRequired},
+				{Field: "user_id", Code: CodeRequired},
+			},
+		},
+		{
+			name:     "Unknown type",
+			modify:   func(rule *model.Rule) { rule.Type = "tournament" },
+			expected: []FieldError{{Field: "rule_type", Code: CodeUnsupported}},
+		},
+		{
+			name:     "Name too long",
+			modify:   func(rule *model.Rule) { rule.Name = strings.Repeat("a", MaxNameLength+1) },
+			expected: []FieldError{{Field: "name", Code: CodeTooLong}},
+		},
+		{
+			name:     "Malformed email",
+			modify:   func(rule *model.Rule) { rule.Email = "ana@" },
+			expected: []FieldError{{Field: "email", Code: CodeInvalid}},
+		},
+		{
+			name:     "No clubs",
+			modify:   func(rule *model.Rule) { rule.ClubIDs = nil },
+			expected: []FieldError{{Field: "club_ids", Code: CodeRequired}},
+		},
+		{
+			name:   "Blank and repeated clubs",
+			modify: func(rule *model.Rule) { rule.ClubIDs = []string{"club-1", "", "club-1"} },
+			expected: []FieldError{
+				{Field: "club_ids[1]", Code: CodeRequired},
+				{Field: "club_ids[2]", Code: CodeDuplicate},
+			},
+		},
+		{
+			name: "Levels out of range",
+			modify: func(rule *model.Rule) {
+				rule.MinRanking = float(-1)
+				rule.MaxRanking = float(8)
+			},
+			expected: []FieldError{
+				{Field: "min_ranking", Code: CodeOutOfRange},
+				{Field: "max_ranking", Code: CodeOutOfRange},
+			},
+		},
+		{
+			name: "Max level below min",
+			modify: func(rule *model.Rule) {
+				rule.MinRanking = float(4)
+				rule.MaxRanking = float(3)
+			},
+			expected: []FieldError{{Field: "max_ranking", Code: CodeInvalidRange}},
+		},
+		{
+			name: "End date before start",
+			modify: func(rule *model.Rule) {
+				rule.StartDate = &start
+				rule.EndDate = &end
+			},
+			expected: []FieldError{{Field: "end_date", Code: CodeInvalidRange}},
+		},
+		{
+			name: "Bad schedule filters",
+			modify: func(rule *model.Rule) {
+				rule.DaysOfWeek = []string{"monday", "someday"}
+				rule.TimeOfDay = []string{"25:00-26:00"}
+			},
+			expected: []FieldError{
+				{Field: "days_of_week[1]", Code: CodeInvalid},
+				{Field: "time_of_day[0]", Code: CodeInvalid},
+			},
+		},
+		{
+			name:     "Blank title filter",
+			modify:   func(rule *model.Rule) { rule.TitleContains = &blank },
+			expected: []FieldError{{Field: "title_contains", Code: CodeInvalid}},
+		},
+		{
+			name:     "Class types on a match rule",
+			modify:   func(rule *model.Rule) { rule.ClassTypes = []string{"beginner"} },
+			expected: []FieldError{{Field: "class_types", Code: CodeNotAllowed}},
+		},
+		{
+			name: "Channels",
+			modify: func(rule *model.Rule) {
+				rule.Channels = []string{"email", "sms", "email", "telegram", "webhook"}
+				rule.WebhookURL = "ftp://example.com/hook"
+			},
+			expected: []FieldError{
+				{Field: "channels[1]", Code: CodeUnsupported},
+				{Field: "channels[2]", Code: CodeDuplicate},
+				{Field: "telegram_id", Code: CodeRequired},
+				{Field: "webhook_url", Code: CodeInvalid},
+			},
+		},
+		{
+			name:     "Webhook on a private network",
+			modify:   func(rule *model.Rule) { rule.WebhookURL = "http://169.254.169.254/latest/meta-data" },
+			expected: []FieldError{{Field: "webhook_url", Code: CodeNotAllowed}},
+		},
+		{
+			name:     "Unknown re-alert change",
+			modify:   func(rule *model.Rule) { rule.ReAlertOn = []string{"weather_changed"} },
+			expected: []FieldError{{Field: "re_alert_on[0]", Code: CodeUnsupported}},
+		},
+		{
+			name: "Check schedule",
+			modify: func(rule *model.Rule) {
+				rule.CheckIntervalSeconds = 10
+				rule.QuietHours = &model.QuietHours{Start: "23:00", End: "8am"}
+			},
+			expected: []FieldError{
+				{Field: "check_interval_seconds", Code: CodeOutOfRange},
+				{Field: "quiet_hours", Code: CodeInvalid},
+			},
+		},
+		{
+			name: "Digest without email",
+			modify: func(rule *model.Rule) {
+				rule.Channels = []string{"push"}
+				rule.PushTopic = "topic"
+				rule.Digest = &model.DigestSchedule{Mode: model.DigestHourly}
+			},
+			expected: []FieldError{{Field: "digest", Code: CodeInvalid}},
+		},
+		{
+			name:     "Negative failure count",
+			modify:   func(rule *model.Rule) { rule.ConsecutiveFailures = -1 },
+			expected: []FieldError{{Field: "consecutive_failures", Code: CodeOutOfRange}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := validRule()
+			tt.modify(rule)
+
+			errs := ValidateRule(rule)
+
+			got := make([]FieldError, len(errs))
+			for i, err := range errs {
+				assert.NotEmpty(t, err.Message, err.Field)
+				got[i] = FieldError{Field: err.Field, Code: err.Code}
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestIsEmail(t *testing.T) {
+	assert.True(t, IsEmail("ana@example.com"))
+	assert.False(t, IsEmail("ana@"))
+	assert.False(t, IsEmail("Ana <ana@example.com>"))
+	assert.False(t, IsEmail(""))
+}
+
+func TestValidateSnooze(t *testing.T) {
+	now := time.Date(2030, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Empty(t, ValidateSnooze("until", now.Add(time.Hour), now))
+	assert.Empty(t, ValidateSnooze("until", now.Add(MaxSnooze), now))
+
+	assert.Equal(t, CodeRequired, ValidateSnooze("until", time.Time{}, now)[0].Code)
+	assert.Equal(t, CodeOutOfRange, ValidateSnooze("until", now, now)[0].Code)
+	assert.Equal(t, CodeOutOfRange, ValidateSnooze("until", now.Add(MaxSnooze+time.Second), now)[0].Code)
+}
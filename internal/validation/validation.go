@@ -0,0 +1,57 @@
+// Package validation checks API input and reports every invalid field at once,
+// as machine-readable errors clients can show next to the field.
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// Error codes, stable for clients to match on
+const (
+	CodeRequired     = "required"      // A value is missing
+	CodeInvalid      = "invalid"       // A value is malformed
+	CodeUnsupported  = "unsupported"   // A value is not one of the allowed options
+	CodeOutOfRange   = "out_of_range"  // A number is outside its bounds
+	CodeInvalidRange = "invalid_range" // A range ends before it starts
+	CodeTooLong      = "too_long"      // A string is longer than allowed
+	CodeDuplicate    = "duplicate"     // A list repeats a value
+	CodeNotAllowed   = "not_allowed"   // A field does not apply in this context
+)
+
+// FieldError describes why one field is invalid. Fields inside lists are
+// named with their index, such as "club_ids[1]".
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors collects the errors found while validating a request
+type Errors []FieldError
+
+// Add records an error for a field
+func (e *Errors) Add(field, code, message string) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Error joins the errors into a single message
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Field + ": " + err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// IsEmail checks that a value is a bare email address, without a display name
+func IsEmail(value string) bool {
+	address, err := mail.ParseAddress(value)
+	return err == nil && address.Address == value
+}
+
+// indexed names an item in a list field
+func indexed(field string, index int) string {
+	return fmt.Sprintf("%s[%d]", field, index)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/rafa-garcia/padel-alert/internal/domain/model"
 	"github.com/rafa-garcia/padel-alert/internal/logger"
 	"github.com/rafa-garcia/padel-alert/internal/scheduler"
+	"github.com/rafa-garcia/padel-alert/internal/source"
 	"github.com/rafa-garcia/padel-alert/internal/storage"
 )
 
@@ -156,9 +157,14 @@ func benchmarkStorage(ctx context.Context, ruleStorage *storage.RedisRuleStorage
 func benchmarkScheduler(ctx context.Context, cfg *config.Config, ruleStorage *storage.RedisRuleStorage) {
 	fmt.Println("Running scheduler benchmark...")
 
-	sched := scheduler.NewScheduler(cfg, ruleStorage)
+	activitySource, err := source.New(cfg)
+	if err != nil {
+		log.Fatalf("Error creating activity source: %v", err)
+	}
+
+	sched := scheduler.NewScheduler(cfg, ruleStorage, activitySource)
 
-	err := sched.Start()
+	err = sched.Start()
 	if err != nil {
 		log.Fatalf("Error starting scheduler: %v", err)
 	}